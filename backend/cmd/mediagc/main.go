@@ -0,0 +1,82 @@
+// Command mediagc reconciles the media store with the database once and prints a report.
+//
+//	go run ./cmd/mediagc -dry-run      # show what would be removed
+//	go run ./cmd/mediagc               # remove orphan files and dangling rows
+//
+// It reads the same .env as the server (DB_* and STORAGE_* / S3_* variables).
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"wewatch-backend/internal/mediagc"
+	"wewatch-backend/internal/storage"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report what would be removed without deleting anything")
+	grace := flag.Duration("grace", mediagc.DefaultGracePeriod, "never treat objects younger than this as orphans")
+	asJSON := flag.Bool("json", false, "print the full report as JSON")
+	verbose := flag.Bool("v", false, "list every orphan object")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: Error loading .env file, using environment variables or defaults")
+	}
+
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
+		os.Getenv("DB_HOST"), os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"),
+		os.Getenv("DB_NAME"), os.Getenv("DB_PORT"))
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Warn)})
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	store, err := storage.NewFromEnv()
+	if err != nil {
+		log.Fatal("Failed to initialize media storage:", err)
+	}
+
+	report, err := mediagc.Run(context.Background(), db, store, mediagc.Options{DryRun: *dryRun, GracePeriod: *grace})
+	if err != nil {
+		log.Fatal("Media GC failed:", err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		if *verbose || *dryRun {
+			prefix := "deleted"
+			if *dryRun {
+				prefix = "would delete"
+			}
+			for _, key := range report.OrphanObjects {
+				fmt.Printf("%s %s\n", prefix, key)
+			}
+			if len(report.OrphanObjects) > 0 {
+				fmt.Println()
+			}
+		}
+		fmt.Print(report.Summary())
+		if len(report.Errors) > 0 {
+			fmt.Println("\nErrors:\n  " + strings.Join(report.Errors, "\n  "))
+		}
+	}
+
+	if len(report.Errors) > 0 {
+		os.Exit(1)
+	}
+}
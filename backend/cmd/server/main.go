@@ -0,0 +1,337 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+	// "strconv" 
+
+	"github.com/joho/godotenv"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-contrib/cors"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"wewatch-backend/internal/models"
+	"wewatch-backend/internal/handlers"
+	"wewatch-backend/internal/mediagc"
+	"wewatch-backend/internal/storage"
+)
+
+// Global variable to hold the database connection
+var DB *gorm.DB
+// Note: No need to declare a global 'hub' variable here anymore,
+// as it's managed internally by the handlers package.
+
+// Global variable to hold the WebSocket hub instance
+//var hub *handlers.Hub // Declare the global hub variable
+func main() {
+	// --- Load .env file ---
+	err := godotenv.Load()
+	log.Println("LIVEKIT_API_KEY =", os.Getenv("LIVEKIT_API_KEY"))
+	log.Println("LIVEKIT_API_SECRET =", os.Getenv("LIVEKIT_API_SECRET"))
+	if err != nil {
+		log.Println("Warning: Error loading .env file, using environment variables or defaults")
+	}
+
+	// --- Database Connection ---
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
+		os.Getenv("DB_HOST"), os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"),
+		os.Getenv("DB_NAME"), os.Getenv("DB_PORT"))
+
+	// Open connection to the database using GORM
+	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	log.Println("Connected to the database successfully")
+
+	// Make the DB connection available to handlers
+	handlers.DB = DB // Pass DB to handlers package
+
+	// --- Media Storage (local disk or S3-compatible, see STORAGE_DRIVER) ---
+	mediaStore, err := storage.NewFromEnv()
+	if err != nil {
+		log.Fatal("Failed to initialize media storage:", err)
+	}
+	handlers.MediaStore = mediaStore
+
+	// --- Auto Migrate Schema ---
+	// GORM to auto creates/updates db tables based on the models
+	err = DB.AutoMigrate(&models.User{}, &models.Room{}, &models.MediaItem{}, &models.TemporaryMediaItem{}, &models.UserRoom{}, &models.ScheduledEvent{}, &models.ChatMessage{},&models.Reaction{}, 
+		&models.WatchSession{}, &models.WatchSessionMember{}, &models.RoomMessage{}, &models.RoomTVContent{},
+		&models.Theater{}, &models.UserTheaterAssignment{}, &models.BroadcastPermission{}, &models.BroadcastRequest{}, &models.MediaBlob{}, &models.MediaChapter{}, &models.MediaItemTag{}, &models.MediaSlide{},
+		&models.SessionSummary{}, &models.SessionMediaPlay{}, &models.RetentionPurge{}, &models.SessionTimelineEvent{}, &models.SessionJoinRequest{}, &models.SessionWaitlistEntry{}, &models.BreakoutGroup{}, &models.BreakoutGroupMember{}) // Pass pointers to model structs
+	if err != nil {
+		log.Fatal("Failed to migrate database schema:", err)
+	}
+	log.Println("Database schema migrated successfully")
+
+	
+	// --- Initialize WebSocket Hub ---
+	//hub = handlers.NewHub() // Create the global hub instance
+	// Make the hub available to handlers
+	//handlers.hub = hub // Pass hub to the handlers package // <-- Fix assignment
+	// Start the hub's main loop in a separate goroutine
+	//go hub.Run()
+	handlers.InitializeHub()
+	log.Println("WebSocket Hub initialized and running")
+
+	// Start background cleanup goroutine
+	go func() {
+		ticker := time.NewTicker(10 * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			log.Println("🕗 Running scheduled cleanup of expired watch sessions...")
+			handlers.CleanupExpiredSessions()
+		}
+	}()
+
+	// Media garbage collector: orphan files, dangling rows, blob ref counts.
+	// MEDIA_GC_INTERVAL is a Go duration (default 6h); "0" disables it.
+	gcInterval := 6 * time.Hour
+	if v := os.Getenv("MEDIA_GC_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			gcInterval = d
+		} else {
+			log.Printf("Warning: invalid MEDIA_GC_INTERVAL %q, using %s", v, gcInterval)
+		}
+	}
+	if gcInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(gcInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				log.Println("🧹 Running scheduled media garbage collection...")
+				report, err := mediagc.Run(context.Background(), DB, mediaStore, mediagc.Options{})
+				if err != nil {
+					log.Printf("⚠️ Media GC failed: %v", err)
+					continue
+				}
+				log.Print(report.Summary())
+			}
+		}()
+	}
+
+	// --- Setup GIN ROUTER ---
+	// Set Gin to Release mode in production
+	gin.SetMode(gin.ReleaseMode)
+	//gin.DefaultMaxMultipartMemory = 1 << 30 // 1 GB
+
+	r := gin.Default()
+
+	// ✅ ADD THIS LINE: Allow up to 1GB file uploads
+	r.MaxMultipartMemory = 1 << 30 // 1 GB — allows large file uploads
+	
+	// --- CORS Configuration ---
+	config := cors.Config{
+		AllowOrigins:     []string{"http://localhost:5173"}, // Allow requests from your frontend origin
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization", "Range"}, // Important: Allow Authorization header for JWT; Range for video seeking
+		AllowCredentials: true, // If you need to send cookies or Authorization headers
+		ExposeHeaders:    []string{"Content-Length", "Content-Range", "Accept-Ranges"},
+		// AllowOriginFunc: func(origin string) bool { return origin == "http://localhost:5173" }, // Alternative way
+	}
+	r.Use(cors.New(config)) // Apply the CORS middleware
+
+	// --- MEDIA FILE SERVING ---
+	// Serve uploaded media at /uploads/<key> from the configured storage backend.
+	// Local disk still uses sendfile(); S3 objects are streamed with Range support.
+	// Media in private rooms requires a signed URL (see handlers/media_urls.go);
+	// CORS (incl. Range preflights) is handled by the middleware above.
+	r.GET("/uploads/*filepath", handlers.ServeUploadsHandler)
+	
+	// --- --- ---
+
+	// Health check endpoint
+	r.GET("/api/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"status":   "ok",
+			"message":  "WeWatch Backend is running!",
+			"database": "connected",
+		})
+	})
+
+	// --- Auth Routes ---
+	// Public routes (no auth required)
+	r.POST("/api/auth/register", handlers.RegisterHandler)
+	r.POST("/api/auth/login", handlers.LoginHandler)
+	r.POST("/api/auth/logout", handlers.LogoutHandler)
+
+	// Protected routes (auth required)
+	// Apply the AuthMiddleware to the /api/auth/me route
+	r.GET("/api/auth/me", handlers.AuthMiddleware(), handlers.GetCurrentUserHandler)
+
+	// --- Invite Routes (Semi-public - requires auth) ---
+	inviteGroup := r.Group("/api/invites")
+	inviteGroup.Use(handlers.CookieToAuthHeaderMiddleware(), handlers.AuthMiddleware())
+	{
+		inviteGroup.POST("/:token/accept", handlers.AcceptInviteByTokenHandler) // POST /api/invites/:token/accept (Accept invite link)
+	}
+
+	// --- Room Routes (Protected) ---
+	// All room-related endpoints require authentication
+	roomGroup := r.Group("/api/rooms")
+	roomGroup.Use(handlers.CookieToAuthHeaderMiddleware(), handlers.AuthMiddleware()) // Apply AuthMiddleware to all routes in this group
+	{
+		roomGroup.POST("", handlers.CreateRoomHandler)                    // POST /api/rooms (Create a new room)
+		roomGroup.GET("", handlers.GetRoomsHandler)                       // GET /api/rooms (Get list of rooms)
+		roomGroup.GET("/:id", handlers.GetRoomHandler)                    // GET /api/rooms/:id (Get a specific room)
+		roomGroup.GET("/:id/livekit-token", handlers.GenerateLiveKitTokenHandler) // ✅ ADD THIS LINE (Generate LiveKit token for a room)
+		// --- Media Item Routes (Permanent) ---
+		roomGroup.GET("/:id/media", handlers.GetMediaItemsForRoomHandler) // GET /api/rooms/:id/media (Get media items for a room)
+		roomGroup.POST("/:id/upload", handlers.UploadMediaHandler)        // POST /api/rooms/:id/upload (Upload media to a room)
+		roomGroup.GET("/:id/session-summaries", handlers.GetRoomSessionSummariesHandler) // GET /api/rooms/:id/session-summaries (Host browses past session recaps, paginated)
+		roomGroup.GET("/:id/media/search", handlers.SearchMediaItemsHandler)         // GET /api/rooms/:id/media/search (Search/filter the library, paginated)
+		roomGroup.GET("/:id/media/:media_id", handlers.GetMediaItemHandler)          // GET /api/rooms/:id/media/:media_id (One library item)
+		roomGroup.PUT("/:id/media/:media_id", handlers.UpdateMediaItemHandler)       // PUT /api/rooms/:id/media/:media_id (Host edits title, description, year, rating, tags)
+		roomGroup.DELETE("/:id/media/:media_id", handlers.DeleteMediaItemHandler)    // DELETE /api/rooms/:id/media/:media_id (Host deletes an item)
+		roomGroup.GET("/:id/media/:media_id/signed-url", handlers.GetMediaSignedURLHandler) // GET /api/rooms/:id/media/:media_id/signed-url (Refresh signed playback URL)
+		roomGroup.GET("/:id/storage", handlers.GetRoomStorageUsageHandler) // GET /api/rooms/:id/storage (Host: storage usage vs quota)
+		roomGroup.PUT("/:id/media/:media_id/poster", handlers.SetMediaPosterHandler) // PUT /api/rooms/:id/media/:media_id/poster (Host uploads a poster or picks a frame)
+		roomGroup.GET("/:id/media/:media_id/storyboard.vtt", handlers.GetMediaStoryboardHandler) // GET /api/rooms/:id/media/:media_id/storyboard.vtt (Seek-preview thumbnails track)
+		roomGroup.POST("/:id/media/:media_id/clips", handlers.CreateMediaClipHandler) // POST /api/rooms/:id/media/:media_id/clips (Cut a clip, optionally post it to chat)
+		roomGroup.GET("/:id/media/:media_id/clips", handlers.GetMediaClipsHandler)     // GET /api/rooms/:id/media/:media_id/clips (Clips cut from a media item)
+		roomGroup.GET("/:id/media/:media_id/waveform", handlers.GetMediaWaveformHandler)                  // GET /api/rooms/:id/media/:media_id/waveform (Audio peaks for the seek bar)
+		roomGroup.GET("/:id/media/:media_id/slides", handlers.GetMediaSlidesHandler)                      // GET /api/rooms/:id/media/:media_id/slides (Slideshow slides in order)
+		roomGroup.PUT("/:id/media/:media_id/slides", handlers.UpdateMediaSlidesHandler)                   // PUT /api/rooms/:id/media/:media_id/slides (Reorder, captions, per-slide duration)
+		roomGroup.DELETE("/:id/media/:media_id/slides/:slide_id", handlers.DeleteMediaSlideHandler)       // DELETE /api/rooms/:id/media/:media_id/slides/:slide_id (Remove a slide)
+		roomGroup.GET("/:id/media/:media_id/chapters", handlers.GetMediaChaptersHandler)                  // GET /api/rooms/:id/media/:media_id/chapters (Chapters and bookmarks)
+		roomGroup.GET("/:id/media/:media_id/chapters.vtt", handlers.GetMediaChaptersVTTHandler)           // GET /api/rooms/:id/media/:media_id/chapters.vtt (WebVTT chapters track)
+		roomGroup.POST("/:id/media/:media_id/chapters", handlers.CreateMediaChapterHandler)               // POST /api/rooms/:id/media/:media_id/chapters (Host adds a chapter/bookmark)
+		roomGroup.PUT("/:id/media/:media_id/chapters/:chapter_id", handlers.UpdateMediaChapterHandler)    // PUT /api/rooms/:id/media/:media_id/chapters/:chapter_id (Host edits)
+		roomGroup.DELETE("/:id/media/:media_id/chapters/:chapter_id", handlers.DeleteMediaChapterHandler) // DELETE /api/rooms/:id/media/:media_id/chapters/:chapter_id (Host removes)
+		roomGroup.POST("/:id/media/:media_id/markers/detect", handlers.DetectMediaMarkersHandler)        // POST /api/rooms/:id/media/:media_id/markers/detect (Host starts intro/credits detection)
+		roomGroup.PUT("/:id/media/:media_id/markers", handlers.UpdateMediaMarkersHandler)                // PUT /api/rooms/:id/media/:media_id/markers (Host sets skip markers)
+		roomGroup.GET("/:id/temporary-media/:item_id/signed-url", handlers.GetTemporaryMediaSignedURLHandler) // GET /api/rooms/:id/temporary-media/:item_id/signed-url
+		roomGroup.GET("/:id/temporary-media/:item_id/waveform", handlers.GetTemporaryMediaWaveformHandler) // GET /api/rooms/:id/temporary-media/:item_id/waveform (Audio peaks)
+		roomGroup.POST("/:id/temporary-media/:item_id/promote", handlers.PromoteTemporaryMediaHandler) // POST /api/rooms/:id/temporary-media/:item_id/promote (Host keeps a session upload in the library)
+		roomGroup.GET("/:id/temporary-media", handlers.GetTemporaryMediaItemsForRoomHandler) // GET /api/rooms/:id/temporary-media (Get list of temporary media items)
+		roomGroup.DELETE("/:id/temporary-media", handlers.DeleteTemporaryMediaItemsForRoomHandler) // DELETE /api/rooms/:id/temporary-media (Delete all temporary media items - Host only)
+		// --- Instant Watch (Temporary Rooms) ---
+		roomGroup.POST("/instant-watch", handlers.CreateInstantWatchHandler) // POST /api/rooms/instant-watch (Create an instant watch temporary room)
+		roomGroup.GET("/:id/members", handlers.GetRoomMembersHandler)
+		roomGroup.POST("/watch-sessions/:session_id/end", handlers.EndWatchSessionHandler)
+		roomGroup.GET("/watch-sessions/:session_id/join-requests", handlers.GetSessionJoinRequestsHandler)
+		roomGroup.GET("/watch-sessions/:session_id/waitlist", handlers.GetSessionWaitlistHandler)
+		roomGroup.PUT("/watch-sessions/:session_id/capacity", handlers.UpdateSessionCapacityHandler)
+		roomGroup.GET("/watch-sessions/:session_id/breakouts", handlers.GetBreakoutsHandler)
+		roomGroup.POST("/watch-sessions/:session_id/breakouts", handlers.CreateBreakoutsHandler)
+		roomGroup.PUT("/watch-sessions/:session_id/breakouts/members/:user_id", handlers.AssignBreakoutMemberHandler)
+		roomGroup.POST("/watch-sessions/:session_id/breakouts/broadcast", handlers.BroadcastToBreakoutsHandler)
+		roomGroup.POST("/watch-sessions/:session_id/breakouts/recall", handlers.RecallBreakoutsHandler)
+		roomGroup.PUT("/:id/users/:user_id/role", handlers.SetUserRoleHandler)
+    	roomGroup.GET("/:id/users/:user_id/role", handlers.GetUserRoleHandler)
+		roomGroup.POST("/:id/join", handlers.JoinRoomHandler)
+		roomGroup.POST("/:id/leave", handlers.LeaveRoomHandler)
+		roomGroup.DELETE("/:id", handlers.DeleteRoomHandler)
+		roomGroup.PUT("/:id", handlers.UpdateRoomHandler)
+		roomGroup.PUT("/:id/media/order", handlers.UpdateMediaOrderHandler)
+ 		roomGroup.PUT("/:id/loop-mode", handlers.UpdateRoomLoopModeHandler)
+		roomGroup.GET("/:id/retention", handlers.GetRoomRetentionHandler)               // GET /api/rooms/:id/retention (Host reads chat/media retention)
+		roomGroup.PUT("/:id/retention", handlers.UpdateRoomRetentionHandler)            // PUT /api/rooms/:id/retention (Host sets chat/media retention)
+		roomGroup.GET("/:id/retention/purges", handlers.GetRoomRetentionPurgesHandler)  // GET /api/rooms/:id/retention/purges (What the purge job deleted)
+		roomGroup.POST("/:id/scheduled-events", handlers.CreateScheduledEventHandler)
+		roomGroup.GET("/:id/scheduled-events", handlers.GetScheduledEventsHandler)
+		roomGroup.POST("/:id/chat", handlers.CreateChatMessageHandler)
+		roomGroup.GET("/:id/chat/history", handlers.GetChatHistoryHandler)
+		roomGroup.DELETE("/:id/chat/:message_id", handlers.DeleteChatMessageHandler)
+		roomGroup.PUT("/:id/chat/:message_id", handlers.UpdateChatMessageHandler)
+		
+		// --- Room-level persistent chat (new) ---
+		roomGroup.GET("/:id/messages", handlers.GetRoomMessages)         // GET /api/rooms/:id/messages (Get all room messages)
+		roomGroup.POST("/:id/messages", handlers.CreateRoomMessage)      // POST /api/rooms/:id/messages (Send room message)
+		roomGroup.DELETE("/:id/messages/:message_id", handlers.DeleteRoomMessage) // DELETE /api/rooms/:id/messages/:message_id (Delete room message)
+		roomGroup.PUT("/:id/messages/:message_id", handlers.EditRoomMessage)      // PUT /api/rooms/:id/messages/:message_id (Edit room message)
+		
+		// --- RoomTV content routes (new) ---
+		roomGroup.GET("/:id/tv-content", handlers.GetRoomTVContent)           // GET /api/rooms/:id/tv-content (Get active TV content)
+		roomGroup.POST("/:id/tv-content", handlers.CreateRoomTVContent)       // POST /api/rooms/:id/tv-content (Host creates content)
+		roomGroup.DELETE("/:id/tv-content/:content_id", handlers.DeleteRoomTVContent) // DELETE /api/rooms/:id/tv-content/:content_id (Host dismisses content)
+		
+		// --- Room invitation routes (for private rooms) ---
+		roomGroup.POST("/:id/invites/link", handlers.CreateRoomInviteLinkHandler)         // POST /api/rooms/:id/invites/link (Create invite link)
+		roomGroup.GET("/:id/invites", handlers.GetRoomInvitesHandler)                     // GET /api/rooms/:id/invites (List invites)
+		roomGroup.DELETE("/:id/invites/:invite_id", handlers.RevokeRoomInviteHandler)     // DELETE /api/rooms/:id/invites/:invite_id (Revoke invite)
+		roomGroup.GET("/:id/check-access", handlers.CheckUserRoomAccessHandler)           // GET /api/rooms/:id/check-access (Check user access)
+		
+		// --- Session management routes (new) ---
+		roomGroup.POST("/:id/sessions", handlers.CreateWatchSession)   // POST /api/rooms/:id/sessions (Create new watch session)
+		roomGroup.GET("/:id/sessions", handlers.GetRoomSessionsHandler)                 // GET /api/rooms/:id/sessions (Session history, paginated and filterable)
+		roomGroup.GET("/:id/sessions/:session_id", handlers.GetRoomSessionHandler)      // GET /api/rooms/:id/sessions/:session_id (One session with members and recap)
+		roomGroup.GET("/:id/sessions/:session_id/timeline", handlers.GetSessionTimelineHandler) // GET /api/rooms/:id/sessions/:session_id/timeline (Replay chat/reactions against the video)
+		
+		roomGroup.POST("/:id/watch-session", handlers.CreateWatchSessionForRoomHandler) // Regular Room Video Watch
+		roomGroup.GET("/:id/active-session", handlers.GetActiveSessionHandler)
+		roomGroup.PUT("/:id/status", handlers.UpdateRoomStatusHandler)
+		roomGroup.DELETE("/:id/temporary-media/:item_id", handlers.DeleteSingleTemporaryMediaItemHandler)
+		
+		// --- WebSocket Route (Protected) ---
+		// This endpoint upgrades HTTP to WebSocket for real-time communication.
+		// It requires authentication.
+		// The route parameter is :room_id to distinguish it from other room routes.
+		roomGroup.GET("/:id/ws", handlers.WebSocketHandler) // GET /api/rooms/:id/ws (WebSocket connection)
+    	// This creates the route: GET /api/rooms/ws/:room_id
+    	// Which resolves to: ws://localhost:8080/api/rooms/ws/2 (for room ID 2)
+    	// --- --- ---
+	}
+
+	// --- THEATER & BROADCAST ROUTES (Protected) ---
+	sessionGroup := r.Group("/api/sessions")
+	sessionGroup.Use(handlers.AuthMiddleware())
+	{
+		// Get all active sessions for lobby
+		sessionGroup.GET("/active", handlers.GetAllActiveSessionsHandler)        // GET /api/sessions/active
+
+		// Recaps of past sessions the user hosted
+		sessionGroup.GET("/summaries", handlers.GetSessionSummariesHandler)              // GET /api/sessions/summaries
+		sessionGroup.GET("/summaries/:session_id", handlers.GetSessionSummaryHandler)    // GET /api/sessions/summaries/:session_id
+		
+		// Theater management
+		sessionGroup.GET("/:id/theaters", handlers.GetSessionTheaters)           // GET /api/sessions/:id/theaters
+		
+		// Broadcast permissions
+		sessionGroup.POST("/:id/broadcast/request", handlers.RequestBroadcast)   // POST /api/sessions/:id/broadcast/request
+		sessionGroup.POST("/:id/broadcast/grant", handlers.GrantBroadcast)       // POST /api/sessions/:id/broadcast/grant
+		sessionGroup.POST("/:id/broadcast/revoke", handlers.RevokeBroadcast)     // POST /api/sessions/:id/broadcast/revoke
+		sessionGroup.GET("/:id/broadcast/active", handlers.GetActiveBroadcasters) // GET /api/sessions/:id/broadcast/active
+		sessionGroup.GET("/:id/broadcast/requests", handlers.GetPendingBroadcastRequests) // GET /api/sessions/:id/broadcast/requests
+	}
+
+	theaterGroup := r.Group("/api/theaters")
+	theaterGroup.Use(handlers.AuthMiddleware())
+	{
+		theaterGroup.PUT("/:id/name", handlers.RenameTheater)             // PUT /api/theaters/:id/name
+		theaterGroup.GET("/:id/occupancy", handlers.GetTheaterOccupancy)  // GET /api/theaters/:id/occupancy
+	}
+
+	// --- SCHEDULED EVENTS ROUTES (Protected, not in roomGroup) ---
+	protected := r.Group("/api")
+	protected.Use(handlers.AuthMiddleware()) // ✅ Apply auth to all sub-routes
+
+	{
+		protected.PUT("/scheduled-events/:id", handlers.UpdateScheduledEventHandler)
+		protected.DELETE("/scheduled-events/:id", handlers.DeleteScheduledEventHandler)
+		protected.GET("/scheduled-events/:id/ical", handlers.DownloadICalHandler)
+		
+		// --- USER PROFILE ROUTES ---
+		protected.PUT("/users/profile", handlers.UpdateProfileHandler) // Update current user's profile
+		protected.GET("/users/me/storage", handlers.GetMyStorageUsageHandler) // Current user's storage usage vs quota
+	}
+	// --- Placeholder for Future Routes ---
+	// roomGroup.PUT("/:id", handlers.UpdateRoomHandler)
+    // roomGroup.DELETE("/:id", handlers.DeleteRoomHandler)
+    // roomGroup.POST("/:id/join", handlers.JoinRoomHandler)
+    // roomGroup.POST("/:id/upload", handlers.UploadMediaHandler)
+    // roomGroup.POST("/:id/playback", handlers.UpdatePlaybackHandler)
+
+	port := ":8080"
+	log.Printf("Starting WeWatch backend server on port %s", port)
+	err = r.Run(port) // Use = because err is already declared
+	if err != nil {
+		log.Fatalf("Failed to run server: %v", err)
+	}
+}
\ No newline at end of file
@@ -5,11 +5,11 @@ import (
     "fmt"
     "log"
     "net/http"
-    "os"
     "path/filepath"
     "strings"
     "time"
     "wewatch-backend/internal/models"
+    "wewatch-backend/internal/storage"
     "wewatch-backend/internal/utils"
 
     "github.com/gin-gonic/gin"
@@ -284,22 +284,26 @@ func UpdateProfileHandler(c *gin.Context) {
         // Handle avatar file upload
         file, err := c.FormFile("avatar")
         if err == nil {
-            // Avatar file provided - save it
-            uploadDir := "./uploads/avatars"
-            os.MkdirAll(uploadDir, os.ModePerm)
-            
+            // Avatar file provided - save it to media storage
             // Generate unique filename
             ext := filepath.Ext(file.Filename)
             filename := fmt.Sprintf("avatar_%d_%d%s", userID, time.Now().Unix(), ext)
-            filepath := filepath.Join(uploadDir, filename)
+            avatarKey := "avatars/" + filename
             
-            if err := c.SaveUploadedFile(file, filepath); err != nil {
+            src, err := file.Open()
+            if err != nil {
+                log.Printf("Failed to read avatar file: %v", err)
+                c.JSON(500, gin.H{"error": "Failed to save avatar"})
+                return
+            }
+            defer src.Close()
+            if err := MediaStore.Put(c.Request.Context(), avatarKey, src, file.Size, file.Header.Get("Content-Type")); err != nil {
                 log.Printf("Failed to save avatar file: %v", err)
                 c.JSON(500, gin.H{"error": "Failed to save avatar"})
                 return
             }
             
-            avatarURL = fmt.Sprintf("/uploads/avatars/%s", filename)
+            avatarURL = storage.PublicURL(avatarKey)
         }
     } else {
         // Handle JSON request
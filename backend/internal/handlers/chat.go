@@ -53,6 +53,11 @@ func CreateChatMessageHandler(c *gin.Context) {
 		sessionID = c.Query("session_id")
 	}
 
+	if sessionChatArchived(sessionID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This session's chat is archived"})
+		return
+	}
+
 	var user models.User
 	if err := DB.First(&user, userID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "User not found"})
@@ -109,6 +114,11 @@ func DeleteChatMessageHandler(c *gin.Context) {
         return
     }
 
+    if sessionChatArchived(message.SessionID) {
+        c.JSON(http.StatusForbidden, gin.H{"error": "This session's chat is archived"})
+        return
+    }
+
     // Check if user is the sender or room host
     isOwner := message.UserID == userID
     isHost := false
@@ -190,6 +200,11 @@ func UpdateChatMessageHandler(c *gin.Context) {
 		return
 	}
 
+	if sessionChatArchived(message.SessionID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This session's chat is archived"})
+		return
+	}
+
 	// Check if user owns the message
 	if message.UserID != userID {
 		c.JSON(http.StatusForbidden, gin.H{"error": "You can only edit your own messages"})
@@ -227,8 +242,31 @@ func GetChatHistoryHandler(c *gin.Context) {
 	query := DB.Where("room_id = ?", uint(roomID))
 
 	if sessionID != "" {
-		// Fetch only session-scoped messages
+		// Fetch only session-scoped messages, of the main chat or of one breakout group
 		query = query.Where("session_id = ?", sessionID)
+		if v := c.Query("breakout_group_id"); v != "" {
+			groupID, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid breakout_group_id"})
+				return
+			}
+			var session models.WatchSession
+			var group models.BreakoutGroup
+			userID, _ := c.Get("user_id")
+			uid, _ := userID.(uint)
+			if DB.Where("session_id = ?", sessionID).First(&session).Error != nil ||
+				DB.Where("id = ? AND session_id = ?", groupID, sessionID).First(&group).Error != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Breakout group not found"})
+				return
+			}
+			if !canJoinBreakoutGroup(&session, &group, uid) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "You are not in this breakout group"})
+				return
+			}
+			query = query.Where("breakout_group_id = ?", groupID)
+		} else {
+			query = query.Where("breakout_group_id IS NULL")
+		}
 	} else {
 		// Fetch only room-level (non-session) messages
 		query = query.Where("session_id = ?", "") // or use IS NULL if you switch to *string
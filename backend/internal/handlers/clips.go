@@ -0,0 +1,391 @@
+// WeWatch/backend/internal/handlers/clips.go
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"wewatch-backend/internal/mediatools"
+	"wewatch-backend/internal/models"
+	"wewatch-backend/internal/storage"
+)
+
+// Clip limits, overridable with CLIP_MAX_SECONDS, CLIP_RATE_LIMIT and CLIP_RATE_WINDOW.
+const (
+	defaultClipMaxSeconds = 120.0
+	defaultClipRateLimit  = 10 // clips per user per window (0 = unlimited)
+	defaultClipRateWindow = time.Hour
+	minClipSeconds        = 1.0
+)
+
+// ClipLimits holds the configured clip length and rate limits.
+type ClipLimits struct {
+	MaxSeconds float64       `json:"max_seconds"`
+	RateLimit  int64         `json:"rate_limit"`
+	RateWindow time.Duration `json:"-"`
+}
+
+// clipLimits reads the current limits from the environment.
+func clipLimits() ClipLimits {
+	limits := ClipLimits{
+		MaxSeconds: defaultClipMaxSeconds,
+		RateLimit:  quotaFromEnv("CLIP_RATE_LIMIT", defaultClipRateLimit),
+		RateWindow: defaultClipRateWindow,
+	}
+	if v := os.Getenv("CLIP_MAX_SECONDS"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f >= minClipSeconds {
+			limits.MaxSeconds = f
+		} else {
+			log.Printf("⚠️ Invalid CLIP_MAX_SECONDS=%q, using default %.0f", v, defaultClipMaxSeconds)
+		}
+	}
+	if v := os.Getenv("CLIP_RATE_WINDOW"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			limits.RateWindow = d
+		} else {
+			log.Printf("⚠️ Invalid CLIP_RATE_WINDOW=%q, using default %s", v, defaultClipRateWindow)
+		}
+	}
+	return limits
+}
+
+// clipName builds the display name of a clip, e.g. "Movie (clip 00:01:05-00:01:30).mp4".
+func clipName(source *models.MediaItem, start, end float64, ext string) string {
+	stem := strings.TrimSuffix(source.OriginalName, path.Ext(source.OriginalName))
+	return fmt.Sprintf("%s (clip %s-%s)%s", stem, mediatools.FormatDuration(start), mediatools.FormatDuration(end), ext)
+}
+
+// CreateMediaClipHandler handles POST /api/rooms/:id/media/:media_id/clips.
+// Any room member can cut [start, end) seconds out of a media item. The clip becomes a new
+// media item in the room (own file, poster and storyboard) uploaded by the member, and can
+// optionally be posted to the room chat.
+func CreateMediaClipHandler(c *gin.Context) {
+	userID, room, ok := requireRoomAccess(c)
+	if !ok {
+		return
+	}
+
+	mediaID, err := strconv.ParseUint(c.Param("media_id"), 10, 64)
+	if err != nil || mediaID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid media item ID"})
+		return
+	}
+
+	var req struct {
+		Start      *float64 `json:"start" binding:"required"`
+		End        *float64 `json:"end" binding:"required"`
+		Title      string   `json:"title"`
+		PostToChat bool     `json:"post_to_chat"`
+		Message    string   `json:"message"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start and end (seconds) are required"})
+		return
+	}
+
+	var source models.MediaItem
+	if err := DB.Where("id = ? AND room_id = ?", mediaID, room.ID).First(&source).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Media item not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return
+	}
+	if source.MediaKind != models.MediaKindVideo {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Clips can only be cut from videos"})
+		return
+	}
+
+	limits := clipLimits()
+	start, end := *req.Start, *req.End
+	if start < 0 || end <= start || (source.DurationSeconds > 0 && end > source.DurationSeconds+0.5) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Clip must lie within 0 and %.1f seconds, with end after start", source.DurationSeconds)})
+		return
+	}
+	if source.DurationSeconds > 0 {
+		end = math.Min(end, source.DurationSeconds)
+	}
+	if end-start < minClipSeconds || end-start > limits.MaxSeconds {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Clips must be between %.0f and %.0f seconds long", minClipSeconds, limits.MaxSeconds)})
+		return
+	}
+
+	// ✅ RATE LIMIT - checked here so a limited user doesn't wait for the cut, and again
+	// when the clip is saved (see below)
+	if retryAfter, err := clipRetryAfter(DB, userID, limits); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	} else if retryAfter > 0 {
+		respondClipRateLimited(c, limits, retryAfter)
+		return
+	}
+
+	// ✅ QUOTA - estimated from the source bitrate now, checked exactly once the clip exists
+	estimate := source.FileSize
+	if source.DurationSeconds > 0 {
+		estimate = int64(float64(source.FileSize) * (end - start) / source.DurationSeconds)
+	}
+	if err := checkUploadQuota(userID, room.ID, "", false, estimate); err != nil {
+		var qe *QuotaError
+		if errors.As(err, &qe) {
+			respondQuotaError(c, qe)
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check storage quota"})
+		}
+		return
+	}
+
+	ctx := c.Request.Context()
+	localSource, cleanup, err := storage.FetchToLocal(ctx, MediaStore, storage.KeyFromPath(source.FilePath))
+	if err != nil {
+		log.Printf("CreateMediaClipHandler: Failed to fetch %s: %v", source.FilePath, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read media file"})
+		return
+	}
+	defer cleanup()
+
+	workDir, err := uploadWorkDir()
+	if err != nil {
+		log.Printf("CreateMediaClipHandler: Failed to prepare work directory: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare storage"})
+		return
+	}
+
+	log.Printf("✂️ CreateMediaClipHandler: User %d clipping media item %d [%.2f, %.2f)", userID, source.ID, start, end)
+	cut, err := mediatools.Default().CutClip(ctx, localSource, workDir, uuid.New().String(), start, end)
+	if err != nil {
+		log.Printf("CreateMediaClipHandler: Failed to cut clip: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cut clip"})
+		return
+	}
+	clipPath, streamCopied := cut.Path, cut.StreamCopied
+	defer os.Remove(clipPath)
+	ext := filepath.Ext(clipPath)
+
+	info, err := os.Stat(clipPath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cut clip"})
+		return
+	}
+	if err := checkUploadQuota(userID, room.ID, "", false, info.Size()); err != nil {
+		var qe *QuotaError
+		if errors.As(err, &qe) {
+			respondQuotaError(c, qe)
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check storage quota"})
+		}
+		return
+	}
+
+	contentHash, err := hashFile(clipPath)
+	if err != nil {
+		log.Printf("CreateMediaClipHandler: Failed to hash '%s': %v", clipPath, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process clip"})
+		return
+	}
+	blob, err := acquireBlob(contentHash)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		var mediaInfo *mediatools.MediaInfo
+		mediaInfo, err = mediatools.Default().ProbeMedia(ctx, clipPath)
+		if err == nil {
+			blob, err = storeBlob(ctx, clipPath, contentHash, ext, info.Size(), mediaInfo)
+		}
+	}
+	if err != nil {
+		log.Printf("CreateMediaClipHandler: Failed to store clip: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store clip"})
+		return
+	}
+
+	originalName := clipName(&source, start, end, ext)
+	if title := strings.TrimSpace(req.Title); title != "" {
+		originalName = title + ext
+	}
+	sourceID := source.ID
+	clip := models.MediaItem{
+		FileName:          path.Base(blob.StorageKey),
+		OriginalName:      originalName,
+		MimeType:          blob.MimeType,
+		FileSize:          blob.Size,
+		FilePath:          blob.StorageKey,
+		BlobHash:          blob.Hash,
+		PosterURL:         blob.PosterURL,
+		PosterVariantKeys: blob.PosterVariantKeys,
+		RoomID:            room.ID,
+		UploaderID:        userID,
+		Duration:          blob.Duration,
+		MediaMetadata:     blob.MediaMetadata,
+		ClipOfID:          &sourceID,
+		ClipStart:         cut.Start, // a stream copy starts at a keyframe, possibly before start
+		ClipEnd:           end,
+	}
+	var sibling models.MediaItem
+	if err := DB.Select("storyboard_key").Where("blob_hash = ? AND storyboard_key <> ''", blob.Hash).First(&sibling).Error; err == nil {
+		clip.StoryboardKey = sibling.StoryboardKey
+	}
+	// The rate limit and the quota are checked again with the user's clip and quota locks
+	// held, so concurrent requests can't all pass the early checks and each insert a clip
+	retryAfter := 0
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", fmt.Sprintf("clips:user:%d", userID)).Error; err != nil {
+			return err
+		}
+		var err error
+		if retryAfter, err = clipRetryAfter(tx, userID, limits); err != nil || retryAfter > 0 {
+			return err
+		}
+		if err := lockUploadQuota(tx, userID, room.ID, "", false); err != nil {
+			return err
+		}
+		if err := checkUploadQuotaIn(tx, userID, room.ID, "", false, blob.Size); err != nil {
+			return err
+		}
+		return tx.Create(&clip).Error
+	})
+	if err != nil || retryAfter > 0 {
+		releaseBlob(ctx, blob.Hash)
+		var qe *QuotaError
+		if errors.As(err, &qe) {
+			respondQuotaError(c, qe)
+		} else if err != nil {
+			log.Printf("CreateMediaClipHandler: Error creating MediaItem record: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Clip stored but failed to save media information"})
+		} else {
+			respondClipRateLimited(c, limits, retryAfter)
+		}
+		return
+	}
+	if clip.StoryboardKey == "" {
+		go generateMediaStoryboard(clip.ID)
+	}
+	log.Printf("🎉 CreateMediaClipHandler: Clip %d (%s, stream copy: %v) created from media item %d by user %d", clip.ID, clip.OriginalName, streamCopied, source.ID, userID)
+
+	signMediaItemURLs(&clip, userID)
+	if msg, err := json.Marshal(map[string]interface{}{
+		"type": "media_clip_created",
+		"data": clip,
+	}); err == nil {
+		hub.BroadcastToRoom(room.ID, OutgoingMessage{Data: msg, IsBinary: false}, nil)
+	}
+
+	response := gin.H{
+		"message":       "Clip created successfully",
+		"media_item":    clip,
+		"stream_copied": streamCopied,
+	}
+
+	// ✅ SHARE IN ROOM CHAT
+	if req.PostToChat {
+		var user models.User
+		DB.Select("username").Where("id = ?", userID).First(&user)
+
+		text := strings.TrimSpace(req.Message)
+		if text == "" {
+			text = "🎬 " + strings.TrimSuffix(clip.OriginalName, ext)
+		}
+		clipID := clip.ID
+		chatMessage := models.RoomMessage{
+			RoomID:      room.ID,
+			UserID:      userID,
+			Username:    user.Username,
+			Message:     text,
+			MediaItemID: &clipID,
+			CreatedAt:   time.Now(),
+		}
+		if err := DB.Create(&chatMessage).Error; err != nil {
+			log.Printf("CreateMediaClipHandler: Failed to post clip %d to chat: %v", clip.ID, err)
+		} else {
+			if msg, err := json.Marshal(map[string]interface{}{
+				"type": "room_chat",
+				"data": map[string]interface{}{
+					"id":            chatMessage.ID,
+					"user_id":       chatMessage.UserID,
+					"username":      chatMessage.Username,
+					"message":       chatMessage.Message,
+					"media_item_id": clip.ID,
+					"created_at":    chatMessage.CreatedAt,
+				},
+			}); err == nil {
+				hub.BroadcastToRoom(room.ID, OutgoingMessage{Data: msg, IsBinary: false}, nil)
+			}
+			response["chat_message"] = chatMessage
+		}
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// clipRetryAfter returns how many seconds userID must wait before creating another clip,
+// or 0 if they may create one now. Deleted clips still count, otherwise delete-and-retry
+// would bypass the limit.
+func clipRetryAfter(db *gorm.DB, userID uint, limits ClipLimits) (int, error) {
+	if limits.RateLimit <= 0 {
+		return 0, nil
+	}
+	since := time.Now().Add(-limits.RateWindow)
+	var recent []models.MediaItem
+	if err := db.Unscoped().Select("created_at").
+		Where("uploader_id = ? AND clip_of_id IS NOT NULL AND created_at > ?", userID, since).
+		Order("created_at ASC").Find(&recent).Error; err != nil {
+		return 0, err
+	}
+	if int64(len(recent)) < limits.RateLimit {
+		return 0, nil
+	}
+	// The oldest clip within the limit leaving the window frees a slot
+	oldest := recent[int64(len(recent))-limits.RateLimit]
+	retryAfter := int(math.Ceil(time.Until(oldest.CreatedAt.Add(limits.RateWindow)).Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	return retryAfter, nil
+}
+
+func respondClipRateLimited(c *gin.Context, limits ClipLimits, retryAfter int) {
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       "clip_rate_limited",
+		"message":     fmt.Sprintf("You can create %d clips per %s", limits.RateLimit, limits.RateWindow),
+		"retry_after": retryAfter,
+	})
+}
+
+// GetMediaClipsHandler handles GET /api/rooms/:id/media/:media_id/clips.
+// It lists the clips cut from a media item, newest first, with signed URLs.
+func GetMediaClipsHandler(c *gin.Context) {
+	userID, room, ok := requireRoomAccess(c)
+	if !ok {
+		return
+	}
+
+	mediaID, err := strconv.ParseUint(c.Param("media_id"), 10, 64)
+	if err != nil || mediaID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid media item ID"})
+		return
+	}
+
+	var clips []models.MediaItem
+	if err := DB.Where("room_id = ? AND clip_of_id = ?", room.ID, mediaID).Order("created_at DESC").Find(&clips).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	for i := range clips {
+		signMediaItemURLs(&clips[i], userID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"clips": clips, "limits": clipLimits()})
+}
@@ -0,0 +1,390 @@
+// WeWatch/backend/internal/handlers/media_blobs.go
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"wewatch-backend/internal/mediatools"
+	"wewatch-backend/internal/models"
+	"wewatch-backend/internal/storage"
+)
+
+var sha256HexPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// hashFile returns the hex SHA-256 of a local file.
+func hashFile(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// blobKey is where content with the given hash is stored.
+func blobKey(hash, ext string) string {
+	return "blobs/" + hash + ext
+}
+
+// acquireBlob takes a reference on an existing blob.
+// It returns gorm.ErrRecordNotFound if no blob has that hash.
+func acquireBlob(hash string) (*models.MediaBlob, error) {
+	return acquireBlobTx(DB, hash)
+}
+
+func acquireBlobTx(tx *gorm.DB, hash string) (*models.MediaBlob, error) {
+	res := tx.Model(&models.MediaBlob{}).Where("hash = ?", hash).
+		Update("ref_count", gorm.Expr("ref_count + 1"))
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var blob models.MediaBlob
+	if err := tx.Where("hash = ?", hash).First(&blob).Error; err != nil {
+		return nil, err
+	}
+	return &blob, nil
+}
+
+// createBlob records a freshly stored blob holding one reference. If a concurrent upload
+// of the same content got there first, it takes a reference on that blob instead
+// (both wrote identical bytes to the same key).
+func createBlob(tx *gorm.DB, blob *models.MediaBlob) (*models.MediaBlob, error) {
+	blob.RefCount = 1
+	res := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "hash"}}, DoNothing: true}).Create(blob)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return acquireBlobTx(tx, blob.Hash)
+	}
+	return blob, nil
+}
+
+// blobVisibleTo reports whether userID can already see the content with this hash: a room
+// they have access to holds a library item, session upload or slide backed by it.
+func blobVisibleTo(userID uint, hash string) (bool, error) {
+	var roomIDs, tempRoomIDs, slideRoomIDs []uint
+	if err := DB.Model(&models.MediaItem{}).Where("blob_hash = ?", hash).
+		Distinct("room_id").Pluck("room_id", &roomIDs).Error; err != nil {
+		return false, err
+	}
+	if err := DB.Model(&models.TemporaryMediaItem{}).Where("blob_hash = ?", hash).
+		Distinct("room_id").Pluck("room_id", &tempRoomIDs).Error; err != nil {
+		return false, err
+	}
+	if err := DB.Model(&models.MediaSlide{}).
+		Joins("JOIN media_items ON media_items.id = media_slides.media_item_id AND media_items.deleted_at IS NULL").
+		Where("media_slides.blob_hash = ?", hash).
+		Distinct("media_items.room_id").Pluck("media_items.room_id", &slideRoomIDs).Error; err != nil {
+		return false, err
+	}
+	roomIDs = append(append(roomIDs, tempRoomIDs...), slideRoomIDs...)
+	if len(roomIDs) == 0 {
+		return false, nil
+	}
+
+	var rooms []models.Room
+	if err := DB.Where("id IN ?", roomIDs).Find(&rooms).Error; err != nil {
+		return false, err
+	}
+	for i := range rooms {
+		if userHasRoomAccess(userID, &rooms[i]) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// releasedMedia is stored content that lost its owner: a blob whose last reference was
+// dropped, or a legacy file/custom poster of a deleted row.
+type releasedMedia struct {
+	filePath  string
+	posterURL string
+	blobHash  string // set for a released blob, whose hash may be stored again later
+}
+
+// mediaRelease collects what rows deleted in a transaction freed in storage. The
+// references are dropped inside that transaction; run deletes the files once it has
+// committed, so a rollback never loses files or reference counts.
+type mediaRelease []releasedMedia
+
+// releaseBlobTx drops one reference inside tx, locking the blob row. The last release
+// deletes the row; its files are added to rel. It returns the blob (nil if there is none).
+func releaseBlobTx(tx *gorm.DB, hash string, rel *mediaRelease) (*models.MediaBlob, error) {
+	var blob models.MediaBlob
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("hash = ?", hash).First(&blob).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("⚠️ releaseBlob: No blob with hash %s", hash)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if blob.RefCount > 1 {
+		return &blob, tx.Model(&blob).Update("ref_count", gorm.Expr("ref_count - 1")).Error
+	}
+	if err := tx.Delete(&blob).Error; err != nil {
+		return nil, err
+	}
+	*rel = append(*rel, releasedMedia{filePath: blob.StorageKey, posterURL: blob.PosterURL, blobHash: blob.Hash})
+	return &blob, nil
+}
+
+// releaseMediaTx drops what a deleted MediaItem/TemporaryMediaItem row points at, inside
+// tx. Items backed by a blob drop their reference (plus any custom poster they own); rows
+// from before deduplication own their file and poster.
+func releaseMediaTx(tx *gorm.DB, filePath, posterURL, blobHash string, rel *mediaRelease) error {
+	if blobHash == "" {
+		*rel = append(*rel, releasedMedia{filePath: filePath, posterURL: posterURL})
+		return nil
+	}
+	blob, err := releaseBlobTx(tx, blobHash, rel)
+	if err != nil {
+		return err
+	}
+	if blob == nil || blob.PosterURL != posterURL {
+		*rel = append(*rel, releasedMedia{posterURL: posterURL})
+	}
+	return nil
+}
+
+// releaseBlob drops one reference outside of any other transaction (e.g. undoing an
+// acquire after a failed insert) and deletes the files if it was the last one.
+func releaseBlob(ctx context.Context, hash string) error {
+	var rel mediaRelease
+	if err := DB.Transaction(func(tx *gorm.DB) error {
+		_, err := releaseBlobTx(tx, hash, &rel)
+		return err
+	}); err != nil {
+		return err
+	}
+	return rel.run(ctx)
+}
+
+// releasePoster deletes an item's replaced poster unless it is the shared poster of its blob.
+func releasePoster(ctx context.Context, blobHash, posterURL string) {
+	if blobHash != "" {
+		var blob models.MediaBlob
+		if err := DB.Select("poster_url").Where("hash = ?", blobHash).First(&blob).Error; err == nil && blob.PosterURL == posterURL {
+			return
+		}
+	}
+	mediaRelease{{posterURL: posterURL}}.run(ctx)
+}
+
+// run deletes the released files. Call it after the transaction that released them committed.
+func (rel mediaRelease) run(ctx context.Context) error {
+	var errs []error
+	for _, m := range rel {
+		if m.filePath == "" && !storage.IsStoredURL(m.posterURL) {
+			continue // nothing of ours in storage (e.g. the placeholder poster)
+		}
+		if err := removeUnusedMedia(ctx, m); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// lockMediaKey serializes storing and deleting one storage key until tx ends. putBlob
+// takes the same lock on its connection; the two conflict like any advisory locks.
+func lockMediaKey(tx *gorm.DB, key string) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", key).Error
+}
+
+// removeUnusedMedia deletes released files that no live row points at any more. Under the
+// key lock a blob stored again since its release (same hash, same keys) is left alone.
+func removeUnusedMedia(ctx context.Context, m releasedMedia) error {
+	lockKey := storage.KeyFromPath(m.filePath)
+	if m.filePath == "" {
+		lockKey = storage.KeyFromPath(m.posterURL)
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockMediaKey(tx, lockKey); err != nil {
+			return err
+		}
+		filePath, posterURL := m.filePath, m.posterURL
+		if m.blobHash != "" {
+			var stored int64
+			if err := tx.Model(&models.MediaBlob{}).Where("hash = ?", m.blobHash).Count(&stored).Error; err != nil {
+				return err
+			}
+			if stored > 0 {
+				log.Printf("♻️ removeUnusedMedia: %s was stored again, keeping it", m.filePath)
+				return nil
+			}
+		}
+		if filePath != "" {
+			key := storage.KeyFromPath(filePath)
+			used, err := mediaKeyUsed(tx, "file_path IN ?", []string{key, "uploads/" + key, "./uploads/" + key})
+			if err != nil {
+				return err
+			}
+			if used {
+				filePath = ""
+			}
+		}
+		if storage.IsStoredURL(posterURL) {
+			used, err := mediaKeyUsed(tx, "poster_url = ?", posterURL)
+			if err != nil {
+				return err
+			}
+			if used {
+				posterURL = ""
+			}
+		}
+		if filePath == "" && !storage.IsStoredURL(posterURL) {
+			return nil
+		}
+		if m.blobHash != "" {
+			log.Printf("🗑️ removeUnusedMedia: Last reference to %s dropped, deleting it", m.filePath)
+			if filePath != "" {
+				removeStoryboard(ctx, storyboardPrefix(storage.KeyFromPath(filePath))+storyboardVTTName)
+			}
+		}
+		return removeStoredMedia(ctx, filePath, posterURL)
+	})
+}
+
+// mediaKeyUsed reports whether a live library item or session upload matches the condition.
+func mediaKeyUsed(tx *gorm.DB, query string, args ...interface{}) (bool, error) {
+	for _, model := range []interface{}{&models.MediaItem{}, &models.TemporaryMediaItem{}} {
+		var count int64
+		if err := tx.Model(model).Where(query, args...).Count(&count).Error; err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// renderPoster writes a JPEG poster for a stored file of the given kind to workPosterPath:
+// a non-black video frame, an audio file's album art (or its waveform when it has none),
+// or the image itself. It returns the video position used (0 for other kinds).
+func renderPoster(ctx context.Context, kind, workPath, workPosterPath string, info *mediatools.MediaInfo) (float64, error) {
+	runner := mediatools.Default()
+	switch kind {
+	case models.MediaKindImage:
+		return 0, runner.ConvertToJPEG(ctx, workPath, workPosterPath)
+	case models.MediaKindAudio:
+		if info.HasCoverArt {
+			err := runner.ExtractCoverArt(ctx, workPath, workPosterPath)
+			if err == nil {
+				return 0, nil
+			}
+			log.Printf("⚠️ renderPoster: Failed to extract album art from '%s', drawing the waveform: %v", workPath, err)
+		}
+		return 0, runner.RenderWaveformImage(ctx, workPath, workPosterPath, 1280, 720)
+	default:
+		return runner.SelectPosterFrame(ctx, workPath, workPosterPath, info.DurationSeconds)
+	}
+}
+
+// putBlob stores a new blob's files with store and records blob with one reference. It
+// holds the key lock throughout, so removeUnusedMedia of an earlier blob with the same
+// hash (same keys) can't delete the files before the row exists. The lock is taken on the
+// connection rather than in a transaction, so a long upload doesn't keep a transaction
+// open; only recording the row runs in one. store cleans up after itself when it fails;
+// the files are removed if the row can't be recorded.
+func putBlob(ctx context.Context, blob *models.MediaBlob, store func() error) (*models.MediaBlob, error) {
+	var created *models.MediaBlob
+	err := DB.Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("SELECT pg_advisory_lock(hashtext(?))", blob.StorageKey).Error; err != nil {
+			return err
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(hashtext(?))", blob.StorageKey)
+
+		if err := store(); err != nil {
+			return err
+		}
+		return conn.Transaction(func(tx *gorm.DB) error {
+			var err error
+			if created, err = createBlob(tx, blob); err != nil {
+				removeStoredMedia(ctx, blob.StorageKey, blob.PosterURL)
+				return fmt.Errorf("failed to save media blob record: %w", err)
+			}
+			return nil
+		})
+	})
+	return created, err
+}
+
+// storeBlob stores a processed, probed local file as a new MediaBlob: it picks and stores
+// a poster (placeholder on failure), hands the file to MediaStore under its
+// content-addressed key and records the blob with its metadata and embedded chapters
+// (plus the waveform for audio). The caller holds the returned reference.
+func storeBlob(ctx context.Context, workPath, contentHash, ext string, size int64, info *mediatools.MediaInfo) (*models.MediaBlob, error) {
+	metadata := mediaMetadataFromInfo(info)
+	metadata.MediaKind = mediaKindForExt(ext)
+	// Storage keys are content-addressed, so every room and session shares one copy
+	fileKey := blobKey(contentHash, ext)
+	posterKey := strings.TrimSuffix(fileKey, ext) + "_poster.jpg"
+	workPosterPath := strings.TrimSuffix(workPath, filepath.Ext(workPath)) + "_poster.jpg"
+	defer os.Remove(workPosterPath)
+
+	// ✅ GENERATE POSTER/THUMBNAIL (skips black/flat frames, plus resized JPEG/WebP renditions)
+	log.Printf("🎨 storeBlob: Generating %s poster for '%s'", metadata.MediaKind, workPath)
+	posterAt, posterErr := renderPoster(ctx, metadata.MediaKind, workPath, workPosterPath, info)
+
+	// ✅ WAVEFORM (audio) - best effort, the player falls back to a plain seek bar
+	var waveform []float64
+	if metadata.MediaKind == models.MediaKindAudio {
+		var err error
+		if waveform, err = mediatools.Default().WaveformPeaks(ctx, workPath, mediatools.WaveformBuckets); err != nil {
+			log.Printf("⚠️ storeBlob: Failed to compute waveform for '%s': %v", workPath, err)
+		}
+	}
+
+	blob := &models.MediaBlob{
+		Hash:          contentHash,
+		StorageKey:    fileKey,
+		Size:          size,
+		MimeType:      getMimeType(ext),
+		PosterURL:     "/icons/placeholder-poster.jpg", // Use placeholder poster if thumbnail generation fails
+		Duration:      mediatools.FormatDuration(metadata.DurationSeconds), // HH:MM:SS kept for existing clients
+		MediaMetadata: metadata,
+		Chapters:      chapterMarksFromInfo(info),
+		Waveform:      waveform,
+	}
+	return putBlob(ctx, blob, func() error {
+		if posterErr != nil {
+			log.Printf("⚠️ storeBlob: Failed to generate poster: %v", posterErr)
+		} else if variantKeys, err := storePoster(ctx, workPosterPath, posterKey); err != nil {
+			log.Printf("⚠️ storeBlob: Failed to store poster: %v", err)
+		} else {
+			blob.PosterURL, blob.PosterVariantKeys = storage.PublicURL(posterKey), variantKeys
+			log.Printf("✅ storeBlob: Poster (frame at %.1fs) stored as '%s' with %d renditions", posterAt, posterKey, len(variantKeys))
+		}
+
+		// ✅ HAND THE FILE TO STORAGE
+		if err := MediaStore.PutFile(ctx, fileKey, workPath, getMimeType(ext)); err != nil {
+			removeStoredMedia(ctx, "", blob.PosterURL)
+			return err
+		}
+		log.Printf("✅ storeBlob: File stored as '%s'", fileKey)
+		return nil
+	})
+}
@@ -0,0 +1,380 @@
+// WeWatch/backend/internal/handlers/media_chapters.go
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"wewatch-backend/internal/mediatools"
+	"wewatch-backend/internal/models"
+)
+
+const maxChapterTitleLength = 255 // characters, like the varchar(255) column
+
+// truncateRunes cuts s to at most n characters without splitting a UTF-8 sequence.
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// importEmbeddedChapters creates the chapters found in an uploaded file for a new media item.
+func importEmbeddedChapters(mediaItemID uint, marks []models.ChapterMark) {
+	if len(marks) == 0 {
+		return
+	}
+	chapters := make([]models.MediaChapter, 0, len(marks))
+	for _, mark := range marks {
+		title := truncateRunes(mark.Title, maxChapterTitleLength)
+		chapters = append(chapters, models.MediaChapter{
+			MediaItemID:  mediaItemID,
+			Kind:         models.ChapterKindChapter,
+			Title:        title,
+			StartSeconds: mark.Start,
+			EndSeconds:   mark.End,
+			Source:       models.ChapterSourceEmbedded,
+		})
+	}
+	if err := DB.Create(&chapters).Error; err != nil {
+		log.Printf("⚠️ importEmbeddedChapters: Failed to import %d chapters for media item %d: %v", len(chapters), mediaItemID, err)
+		return
+	}
+	log.Printf("📑 importEmbeddedChapters: Imported %d chapters for media item %d", len(chapters), mediaItemID)
+}
+
+// mediaItemChapters returns a media item's chapters and bookmarks ordered by start time.
+func mediaItemChapters(mediaItemID uint) ([]models.MediaChapter, error) {
+	var chapters []models.MediaChapter
+	err := DB.Where("media_item_id = ?", mediaItemID).Order("start_seconds ASC, id ASC").Find(&chapters).Error
+	return chapters, err
+}
+
+// loadRoomMediaItem reads :media_id and loads that media item from the room, writing the
+// error response itself when it fails.
+func loadRoomMediaItem(c *gin.Context, room *models.Room) (*models.MediaItem, bool) {
+	mediaID, err := strconv.ParseUint(c.Param("media_id"), 10, 64)
+	if err != nil || mediaID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid media item ID"})
+		return nil, false
+	}
+
+	var item models.MediaItem
+	if err := DB.Where("id = ? AND room_id = ?", mediaID, room.ID).First(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Media item not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return nil, false
+	}
+	return &item, true
+}
+
+// chapterInput is the body of the create/update chapter endpoints.
+type chapterInput struct {
+	Kind         string   `json:"kind"`
+	Title        *string  `json:"title"`
+	StartSeconds *float64 `json:"start_seconds"`
+	EndSeconds   *float64 `json:"end_seconds"`
+}
+
+// apply validates the input against the media item and copies it onto chapter.
+func (in *chapterInput) apply(chapter *models.MediaChapter, item *models.MediaItem) error {
+	if in.Kind != "" {
+		if in.Kind != models.ChapterKindChapter && in.Kind != models.ChapterKindBookmark {
+			return fmt.Errorf("kind must be %q or %q", models.ChapterKindChapter, models.ChapterKindBookmark)
+		}
+		chapter.Kind = in.Kind
+	}
+	if in.Title != nil {
+		chapter.Title = strings.TrimSpace(*in.Title)
+	}
+	if in.StartSeconds != nil {
+		chapter.StartSeconds = *in.StartSeconds
+	}
+	if in.EndSeconds != nil {
+		chapter.EndSeconds = *in.EndSeconds
+	}
+	if chapter.Kind == models.ChapterKindBookmark {
+		chapter.EndSeconds = 0
+	}
+
+	if chapter.Title == "" || utf8.RuneCountInString(chapter.Title) > maxChapterTitleLength {
+		return fmt.Errorf("title is required (max %d characters)", maxChapterTitleLength)
+	}
+	if chapter.StartSeconds < 0 || (item.DurationSeconds > 0 && chapter.StartSeconds >= item.DurationSeconds) {
+		return fmt.Errorf("start_seconds must be between 0 and %.1f", item.DurationSeconds)
+	}
+	if chapter.EndSeconds != 0 && (chapter.EndSeconds <= chapter.StartSeconds || (item.DurationSeconds > 0 && chapter.EndSeconds > item.DurationSeconds+0.5)) {
+		return fmt.Errorf("end_seconds must be after start_seconds and within the video")
+	}
+	return nil
+}
+
+// broadcastChaptersUpdated tells the room a media item's chapter list changed.
+func broadcastChaptersUpdated(item *models.MediaItem) {
+	chapters, err := mediaItemChapters(item.ID)
+	if err != nil {
+		log.Printf("⚠️ broadcastChaptersUpdated: Failed to load chapters for media item %d: %v", item.ID, err)
+		return
+	}
+	msg, _ := json.Marshal(map[string]interface{}{
+		"type": "media_chapters_updated",
+		"data": map[string]interface{}{
+			"media_item_id": item.ID,
+			"room_id":       item.RoomID,
+			"chapters":      chapters,
+		},
+	})
+	hub.BroadcastToRoom(item.RoomID, OutgoingMessage{Data: msg, IsBinary: false}, nil)
+}
+
+// GetMediaChaptersHandler handles GET /api/rooms/:id/media/:media_id/chapters.
+// Any room member can list a media item's chapters and bookmarks.
+func GetMediaChaptersHandler(c *gin.Context) {
+	_, room, ok := requireRoomAccess(c)
+	if !ok {
+		return
+	}
+	item, ok := loadRoomMediaItem(c, room)
+	if !ok {
+		return
+	}
+
+	chapters, err := mediaItemChapters(item.ID)
+	if err != nil {
+		log.Printf("GetMediaChaptersHandler: Failed to load chapters for media item %d: %v", item.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch chapters"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"media_item_id": item.ID, "chapters": chapters})
+}
+
+// CreateMediaChapterHandler handles POST /api/rooms/:id/media/:media_id/chapters (host only).
+func CreateMediaChapterHandler(c *gin.Context) {
+	userID, room, ok := requireRoomAccess(c)
+	if !ok {
+		return
+	}
+	if room.HostID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the room host can edit chapters"})
+		return
+	}
+	item, ok := loadRoomMediaItem(c, room)
+	if !ok {
+		return
+	}
+
+	var input chapterInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid chapter data"})
+		return
+	}
+	if input.StartSeconds == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_seconds is required"})
+		return
+	}
+
+	chapter := models.MediaChapter{
+		MediaItemID: item.ID,
+		Kind:        models.ChapterKindChapter,
+		Source:      models.ChapterSourceManual,
+		CreatedByID: &userID,
+	}
+	if err := input.apply(&chapter, item); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := DB.Create(&chapter).Error; err != nil {
+		log.Printf("CreateMediaChapterHandler: Failed to create chapter: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create chapter"})
+		return
+	}
+
+	broadcastChaptersUpdated(item)
+	c.JSON(http.StatusCreated, gin.H{"chapter": chapter})
+}
+
+// UpdateMediaChapterHandler handles PUT /api/rooms/:id/media/:media_id/chapters/:chapter_id (host only).
+// Only the fields present in the body change.
+func UpdateMediaChapterHandler(c *gin.Context) {
+	userID, room, ok := requireRoomAccess(c)
+	if !ok {
+		return
+	}
+	if room.HostID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the room host can edit chapters"})
+		return
+	}
+	item, ok := loadRoomMediaItem(c, room)
+	if !ok {
+		return
+	}
+
+	var chapter models.MediaChapter
+	if err := DB.Where("id = ? AND media_item_id = ?", c.Param("chapter_id"), item.ID).First(&chapter).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Chapter not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	var input chapterInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid chapter data"})
+		return
+	}
+	if err := input.apply(&chapter, item); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := DB.Save(&chapter).Error; err != nil {
+		log.Printf("UpdateMediaChapterHandler: Failed to update chapter %d: %v", chapter.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update chapter"})
+		return
+	}
+
+	broadcastChaptersUpdated(item)
+	c.JSON(http.StatusOK, gin.H{"chapter": chapter})
+}
+
+// DeleteMediaChapterHandler handles DELETE /api/rooms/:id/media/:media_id/chapters/:chapter_id (host only).
+func DeleteMediaChapterHandler(c *gin.Context) {
+	userID, room, ok := requireRoomAccess(c)
+	if !ok {
+		return
+	}
+	if room.HostID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the room host can edit chapters"})
+		return
+	}
+	item, ok := loadRoomMediaItem(c, room)
+	if !ok {
+		return
+	}
+
+	result := DB.Where("id = ? AND media_item_id = ?", c.Param("chapter_id"), item.ID).Delete(&models.MediaChapter{})
+	if result.Error != nil {
+		log.Printf("DeleteMediaChapterHandler: Failed to delete chapter: %v", result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete chapter"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chapter not found"})
+		return
+	}
+
+	broadcastChaptersUpdated(item)
+	c.JSON(http.StatusOK, gin.H{"message": "Chapter deleted"})
+}
+
+// chaptersWebVTT renders the chapters (not bookmarks) as a WebVTT chapters track.
+// A chapter without an end runs until the next chapter starts, or the end of the video.
+func chaptersWebVTT(chapters []models.MediaChapter, durationSeconds float64) string {
+	var sections []models.MediaChapter
+	for _, ch := range chapters {
+		if ch.Kind == models.ChapterKindChapter {
+			sections = append(sections, ch)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("WEBVTT\n")
+	for i, ch := range sections {
+		end := ch.EndSeconds
+		if end == 0 {
+			if i+1 < len(sections) {
+				end = sections[i+1].StartSeconds
+			} else {
+				end = durationSeconds
+			}
+		}
+		if durationSeconds > 0 {
+			end = math.Min(end, durationSeconds)
+		}
+		if end <= ch.StartSeconds {
+			continue
+		}
+		// Cue text can't contain "-->" or blank lines
+		title := strings.ReplaceAll(strings.ReplaceAll(ch.Title, "-->", "->"), "\n", " ")
+		fmt.Fprintf(&b, "\nchapter-%d\n%s --> %s\n%s\n", ch.ID,
+			mediatools.VTTTimestamp(ch.StartSeconds), mediatools.VTTTimestamp(end), title)
+	}
+	return b.String()
+}
+
+// GetMediaChaptersVTTHandler handles GET /api/rooms/:id/media/:media_id/chapters.vtt.
+// It serves the chapters as a WebVTT track for <track kind="chapters">.
+func GetMediaChaptersVTTHandler(c *gin.Context) {
+	_, room, ok := requireRoomAccess(c)
+	if !ok {
+		return
+	}
+	item, ok := loadRoomMediaItem(c, room)
+	if !ok {
+		return
+	}
+
+	chapters, err := mediaItemChapters(item.ID)
+	if err != nil {
+		log.Printf("GetMediaChaptersVTTHandler: Failed to load chapters for media item %d: %v", item.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch chapters"})
+		return
+	}
+
+	c.Header("Cache-Control", "private, no-cache")
+	c.Data(http.StatusOK, "text/vtt; charset=utf-8", []byte(chaptersWebVTT(chapters, item.DurationSeconds)))
+}
+
+// handleJumpToChapter handles the "jump_to_chapter" WebSocket command ({"chapter_id": N}).
+// Only the room host (or the active session's host) may send it; everyone in the room,
+// host included, receives a playback_control that plays the item from the chapter start.
+func (client *Client) handleJumpToChapter(msg WebSocketMessage) {
+	var data struct {
+		ChapterID uint `json:"chapter_id"`
+	}
+	raw, _ := json.Marshal(msg.Data)
+	if err := json.Unmarshal(raw, &data); err != nil || data.ChapterID == 0 {
+		log.Printf("[jump_to_chapter] ❌ Invalid data from user %d: %v", client.userID, err)
+		return
+	}
+
+	var room models.Room
+	if err := DB.First(&room, client.roomID).Error; err != nil {
+		log.Printf("[jump_to_chapter] ❌ Room %d not found: %v", client.roomID, err)
+		return
+	}
+	if !isPlaybackHost(&room, client.userID) {
+		log.Printf("[jump_to_chapter] ❌ User %d is not the host of room %d", client.userID, room.ID)
+		return
+	}
+
+	var chapter models.MediaChapter
+	if err := DB.First(&chapter, data.ChapterID).Error; err != nil {
+		log.Printf("[jump_to_chapter] ❌ Chapter %d not found: %v", data.ChapterID, err)
+		return
+	}
+	var item models.MediaItem
+	if err := DB.Where("id = ? AND room_id = ?", chapter.MediaItemID, room.ID).First(&item).Error; err != nil {
+		log.Printf("[jump_to_chapter] ❌ Chapter %d does not belong to room %d", chapter.ID, room.ID)
+		return
+	}
+	log.Printf("[jump_to_chapter] ⏭️ Host %d jumping room %d to chapter %d (%.1fs) of media item %d", client.userID, room.ID, chapter.ID, chapter.StartSeconds, item.ID)
+	broadcastPlayItem(&room, &item, client.userID, chapter.StartSeconds, map[string]interface{}{
+		"chapter_id":    chapter.ID,
+		"chapter_title": chapter.Title,
+		"initiated_by":  client.userID,
+	})
+}
@@ -14,6 +14,9 @@ import (
 // GetMediaItemsForRoomHandler handles the GET /api/rooms/:id/media endpoint.
 // It requires authentication.
 // It fetches the list of media items associated with a specific room.
+// Each item carries its probed metadata (duration_seconds, width, height, frame_rate,
+// codecs, bitrate, audio_languages, container) so clients can show resolution and pick tracks,
+// plus a signed file_url / poster_url valid until url_expires_at.
 func GetMediaItemsForRoomHandler(c *gin.Context) {
 	// 1. Get the authenticated user's ID from the context (set by AuthMiddleware).
 	// userIDInterface, exists := c.Get("user_id")
@@ -23,7 +26,7 @@ func GetMediaItemsForRoomHandler(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
-	_, ok := userIDValue.(uint)
+	authenticatedUserID, ok := userIDValue.(uint)
 	if !ok {
 		log.Println("GetMediaItemsForRoomHandler: Error asserting user ID type")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
@@ -47,8 +50,7 @@ func GetMediaItemsForRoomHandler(c *gin.Context) {
 	roomIDUint := uint(roomID)
 
 	// 3. Fetch the room from the database to check existence (and potentially authorization later).
-	// For MVP, we assume if the room exists and user is authenticated, they can see media.
-	var room models.Room
+		var room models.Room
 	result := DB.First(&room, roomIDUint)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
@@ -62,16 +64,13 @@ func GetMediaItemsForRoomHandler(c *gin.Context) {
 		}
 	}
 
-	// 4. Authorization Check REMOVED/COMMENTED OUT for MVP.
-	// To allow any authenticated user to see media items, we skip the check: room.HostID == authenticatedUserID.
-	// If you want to restrict media list visibility to the host ONLY, uncomment the lines below:
-	/*
-		if room.HostID != authenticatedUserID {
-			log.Printf("GetMediaItemsForRoomHandler: User %d is not the host (HostID: %d) of room %d", authenticatedUserID, room.HostID, roomIDUint)
-			c.JSON(http.StatusForbidden, gin.H{"error": "Only the room host can view media items"})
-			return
-		}
-	*/
+	// 4. Authorization: public rooms are open to any authenticated user,
+	// private rooms only to the host and members.
+	if !userHasRoomAccess(authenticatedUserID, &room) {
+		log.Printf("GetMediaItemsForRoomHandler: User %d has no access to room %d", authenticatedUserID, roomIDUint)
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have access to this room"})
+		return
+	}
 
 	// --- FETCH MEDIA ITEMS LOGIC STARTS HERE (after successful room fetch) ---
 
@@ -87,7 +86,14 @@ func GetMediaItemsForRoomHandler(c *gin.Context) {
 		return
 	}
 
-	// 6. Media items fetched successfully!
+	// 6. Attach tags and sign playback/poster URLs for this user.
+	attachMediaItemTags(mediaItems)
+	attachMediaItemSlides(mediaItems, authenticatedUserID)
+	for i := range mediaItems {
+		signMediaItemURLs(&mediaItems[i], authenticatedUserID)
+	}
+
+	// 7. Media items fetched successfully!
 	log.Printf("GetMediaItemsForRoomHandler: Fetched %d media items for room %d", len(mediaItems), roomIDUint)
 	// Respond with the list of media items.
 	// Wrap the list in a JSON object for consistency and potential metadata.
@@ -99,6 +105,4 @@ func GetMediaItemsForRoomHandler(c *gin.Context) {
 	})
 }
 
-// --- Placeholder for Future Handlers ---
-// func GetMediaItemHandler(c *gin.Context) { ... } // GET /api/rooms/:id/media/:media_item_id (get details of one item)
-// func DeleteMediaItemHandler(c *gin.Context) { ... } // DELETE /api/rooms/:id/media/:media_item_id (delete an item)
\ No newline at end of file
+// Single-item get/edit/delete and library search live in media_library.go.
\ No newline at end of file
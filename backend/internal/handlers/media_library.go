@@ -0,0 +1,422 @@
+// WeWatch/backend/internal/handlers/media_library.go
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"wewatch-backend/internal/models"
+)
+
+const (
+	maxTagsPerItem     = 20
+	maxTagLength       = 50
+	defaultLibraryPage = 24
+	maxLibraryPage     = 100
+)
+
+// normalizeTags lower-cases, trims and de-duplicates tags, dropping empty ones.
+func normalizeTags(raw []string) ([]string, error) {
+	seen := map[string]bool{}
+	tags := []string{}
+	for _, t := range raw {
+		t = strings.ToLower(strings.Join(strings.Fields(t), " "))
+		if t == "" || seen[t] {
+			continue
+		}
+		if len(t) > maxTagLength {
+			return nil, fmt.Errorf("tags can be at most %d characters", maxTagLength)
+		}
+		seen[t] = true
+		tags = append(tags, t)
+	}
+	if len(tags) > maxTagsPerItem {
+		return nil, fmt.Errorf("an item can have at most %d tags", maxTagsPerItem)
+	}
+	return tags, nil
+}
+
+// attachMediaItemTags fills in the Tags of each item from media_item_tags.
+func attachMediaItemTags(items []models.MediaItem) {
+	if len(items) == 0 {
+		return
+	}
+	ids := make([]uint, len(items))
+	for i := range items {
+		ids[i] = items[i].ID
+		items[i].Tags = []string{}
+	}
+
+	var rows []models.MediaItemTag
+	if err := DB.Where("media_item_id IN ?", ids).Order("tag ASC").Find(&rows).Error; err != nil {
+		log.Printf("⚠️ attachMediaItemTags: Failed to load tags: %v", err)
+		return
+	}
+	byItem := map[uint][]string{}
+	for _, row := range rows {
+		byItem[row.MediaItemID] = append(byItem[row.MediaItemID], row.Tag)
+	}
+	for i := range items {
+		if tags, ok := byItem[items[i].ID]; ok {
+			items[i].Tags = tags
+		}
+	}
+}
+
+// validContentRating reports whether r is "" (unrated) or one of models.ContentRatings.
+func validContentRating(r string) bool {
+	if r == "" {
+		return true
+	}
+	for _, known := range models.ContentRatings {
+		if r == known {
+			return true
+		}
+	}
+	return false
+}
+
+// GetMediaItemHandler handles GET /api/rooms/:id/media/:media_id.
+// It returns one library item with its tags, chapters and signed URLs.
+func GetMediaItemHandler(c *gin.Context) {
+	userID, room, ok := requireRoomAccess(c)
+	if !ok {
+		return
+	}
+	item, ok := loadRoomMediaItem(c, room)
+	if !ok {
+		return
+	}
+
+	items := []models.MediaItem{*item}
+	attachMediaItemTags(items)
+	attachMediaItemSlides(items, userID)
+	signMediaItemURLs(&items[0], userID)
+	chapters, _ := mediaItemChapters(item.ID)
+
+	c.JSON(http.StatusOK, gin.H{"media_item": items[0], "chapters": chapters})
+}
+
+// UpdateMediaItemHandler handles PUT /api/rooms/:id/media/:media_id (host only).
+// It edits the library metadata: title, description, year, content_rating and tags.
+// Only the fields present in the body change; "tags" replaces the whole list.
+func UpdateMediaItemHandler(c *gin.Context) {
+	userID, room, ok := requireRoomAccess(c)
+	if !ok {
+		return
+	}
+	if room.HostID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the room host can edit media items"})
+		return
+	}
+	item, ok := loadRoomMediaItem(c, room)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Title         *string   `json:"title"`
+		Description   *string   `json:"description"`
+		Year          *int      `json:"year"`
+		ClearYear     bool      `json:"clear_year"`
+		ContentRating *string   `json:"content_rating"`
+		Tags          *[]string `json:"tags"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid media item data"})
+		return
+	}
+
+	var columns []string
+	if input.Title != nil {
+		title := strings.TrimSpace(*input.Title)
+		if len(title) > 255 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "title can be at most 255 characters"})
+			return
+		}
+		item.Title = title
+		columns = append(columns, "title")
+	}
+	if input.Description != nil {
+		if len(*input.Description) > 5000 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "description can be at most 5000 characters"})
+			return
+		}
+		item.Description = strings.TrimSpace(*input.Description)
+		columns = append(columns, "description")
+	}
+	if input.ClearYear {
+		item.Year = nil
+		columns = append(columns, "year")
+	} else if input.Year != nil {
+		if *input.Year < 1870 || *input.Year > time.Now().Year()+5 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "year is out of range"})
+			return
+		}
+		item.Year = input.Year
+		columns = append(columns, "year")
+	}
+	if input.ContentRating != nil {
+		rating := strings.ToUpper(strings.TrimSpace(*input.ContentRating))
+		if !validContentRating(rating) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown content_rating", "allowed": models.ContentRatings})
+			return
+		}
+		item.ContentRating = rating
+		columns = append(columns, "content_rating")
+	}
+	var tags []string
+	if input.Tags != nil {
+		var err error
+		if tags, err = normalizeTags(*input.Tags); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if len(columns) > 0 {
+			if err := tx.Model(item).Select(columns).Updates(item).Error; err != nil {
+				return err
+			}
+		}
+		if input.Tags != nil {
+			if err := tx.Where("media_item_id = ?", item.ID).Delete(&models.MediaItemTag{}).Error; err != nil {
+				return err
+			}
+			for _, tag := range tags {
+				if err := tx.Create(&models.MediaItemTag{MediaItemID: item.ID, Tag: tag}).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("UpdateMediaItemHandler: Failed to update media item %d: %v", item.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update media item"})
+		return
+	}
+
+	items := []models.MediaItem{*item}
+	attachMediaItemTags(items)
+	signMediaItemURLs(&items[0], userID)
+
+	if msg, err := json.Marshal(map[string]interface{}{
+		"type": "media_item_updated",
+		"data": items[0],
+	}); err == nil {
+		hub.BroadcastToRoom(room.ID, OutgoingMessage{Data: msg, IsBinary: false}, nil)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Media item updated", "media_item": items[0]})
+}
+
+// DeleteMediaItemHandler handles DELETE /api/rooms/:id/media/:media_id (host only).
+// The row is soft-deleted (clipRetryAfter still counts deleted clips) and its chapters,
+// tags and slides are removed. Its file references are released - the file itself goes
+// once nothing else uses it.
+func DeleteMediaItemHandler(c *gin.Context) {
+	userID, room, ok := requireRoomAccess(c)
+	if !ok {
+		return
+	}
+	if room.HostID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the room host can delete media items"})
+		return
+	}
+	item, ok := loadRoomMediaItem(c, room)
+	if !ok {
+		return
+	}
+
+	var rel mediaRelease
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := releaseSlidesTx(tx, item.ID, &rel); err != nil {
+			return err
+		}
+		if err := tx.Where("media_item_id = ?", item.ID).Delete(&models.MediaChapter{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("media_item_id = ?", item.ID).Delete(&models.MediaItemTag{}).Error; err != nil {
+			return err
+		}
+		// Clips and chat attachments outlive their source
+		if err := tx.Model(&models.MediaItem{}).Where("clip_of_id = ?", item.ID).Update("clip_of_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.RoomMessage{}).Where("media_item_id = ?", item.ID).Update("media_item_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.ScheduledEvent{}).Where("media_item_id = ?", item.ID).Update("media_item_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(item).Error; err != nil {
+			return err
+		}
+		return releaseMediaTx(tx, item.FilePath, item.PosterURL, item.BlobHash, &rel)
+	})
+	if err != nil {
+		log.Printf("DeleteMediaItemHandler: Failed to delete media item %d: %v", item.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete media item"})
+		return
+	}
+
+	if err := rel.run(c.Request.Context()); err != nil {
+		log.Printf("⚠️ DeleteMediaItemHandler: Failed to delete files of %s: %v", item.FilePath, err)
+	}
+	if item.BlobHash == "" {
+		removeStoryboard(c.Request.Context(), item.StoryboardKey)
+	}
+	log.Printf("🗑️ DeleteMediaItemHandler: Media item %d deleted from room %d by host %d", item.ID, room.ID, userID)
+
+	if msg, err := json.Marshal(map[string]interface{}{
+		"type": "media_item_deleted",
+		"data": map[string]interface{}{
+			"media_item_id": item.ID,
+			"room_id":       room.ID,
+		},
+	}); err == nil {
+		hub.BroadcastToRoom(room.ID, OutgoingMessage{Data: msg, IsBinary: false}, nil)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Media item deleted successfully", "media_item_id": item.ID})
+}
+
+// SearchMediaItemsHandler handles GET /api/rooms/:id/media/search.
+// Filters (all optional, combined with AND):
+//   - q: text matched against title, original name and description
+//   - tag: comma-separated tags the item must all have
+//   - min_duration / max_duration: seconds
+//   - year, content_rating
+//   - clips: "include" (default), "exclude" or "only"
+//   - kind: "video", "audio" or "slideshow"
+//
+// sort is "order" (default, library order), "newest", "oldest", "title" or "duration";
+// page (1-based) and page_size (max 100) paginate the result.
+func SearchMediaItemsHandler(c *gin.Context) {
+	userID, room, ok := requireRoomAccess(c)
+	if !ok {
+		return
+	}
+
+	query := DB.Model(&models.MediaItem{}).Where("room_id = ?", room.ID)
+
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		like := "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(q) + "%"
+		query = query.Where("(title ILIKE ? OR original_name ILIKE ? OR description ILIKE ?)", like, like, like)
+	}
+	if rawTags := c.Query("tag"); rawTags != "" {
+		tags, err := normalizeTags(strings.Split(rawTags, ","))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		for _, tag := range tags {
+			query = query.Where("EXISTS (SELECT 1 FROM media_item_tags t WHERE t.media_item_id = media_items.id AND t.tag = ?)", tag)
+		}
+	}
+	for param, cond := range map[string]string{
+		"min_duration": "duration_seconds >= ?",
+		"max_duration": "duration_seconds <= ?",
+	} {
+		if v := c.Query(param); v != "" {
+			seconds, err := strconv.ParseFloat(v, 64)
+			if err != nil || seconds < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": param + " must be a number of seconds"})
+				return
+			}
+			query = query.Where(cond, seconds)
+		}
+	}
+	if v := c.Query("year"); v != "" {
+		year, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "year must be a number"})
+			return
+		}
+		query = query.Where("year = ?", year)
+	}
+	if v := c.Query("content_rating"); v != "" {
+		query = query.Where("content_rating = ?", strings.ToUpper(v))
+	}
+	if v := c.Query("kind"); v != "" {
+		if v != models.MediaKindVideo && v != models.MediaKindAudio && v != models.MediaKindSlideshow {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be video, audio or slideshow"})
+			return
+		}
+		query = query.Where("media_kind = ?", v)
+	}
+	switch c.DefaultQuery("clips", "include") {
+	case "include":
+	case "exclude":
+		query = query.Where("clip_of_id IS NULL")
+	case "only":
+		query = query.Where("clip_of_id IS NOT NULL")
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "clips must be include, exclude or only"})
+		return
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		log.Printf("SearchMediaItemsHandler: Count failed for room %d: %v", room.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search media items"})
+		return
+	}
+
+	orders := map[string]string{
+		"order":    "order_index ASC, created_at ASC",
+		"newest":   "created_at DESC",
+		"oldest":   "created_at ASC",
+		"title":    "LOWER(COALESCE(NULLIF(title, ''), original_name)) ASC",
+		"duration": "duration_seconds ASC",
+	}
+	order, ok := orders[c.DefaultQuery("sort", "order")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be order, newest, oldest, title or duration"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultLibraryPage)))
+	if pageSize < 1 {
+		pageSize = defaultLibraryPage
+	}
+	if pageSize > maxLibraryPage {
+		pageSize = maxLibraryPage
+	}
+
+	var items []models.MediaItem
+	if err := query.Order(order + ", id ASC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&items).Error; err != nil {
+		log.Printf("SearchMediaItemsHandler: Query failed for room %d: %v", room.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search media items"})
+		return
+	}
+	attachMediaItemTags(items)
+	attachMediaItemSlides(items, userID)
+	for i := range items {
+		signMediaItemURLs(&items[i], userID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"media_items": items,
+		"count":       len(items),
+		"total":       total,
+		"page":        page,
+		"page_size":   pageSize,
+		"total_pages": int(math.Ceil(float64(total) / float64(pageSize))),
+		"room_id":     room.ID,
+	})
+}
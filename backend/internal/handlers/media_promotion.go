@@ -0,0 +1,283 @@
+// WeWatch/backend/internal/handlers/media_promotion.go
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"wewatch-backend/internal/models"
+	"wewatch-backend/internal/storage"
+)
+
+// errTempItemGone is returned when a temporary item disappeared (session-end cleanup won the race).
+var errTempItemGone = errors.New("temporary media item no longer exists")
+
+// lockSessionTempMedia loads a session's temporary media rows with FOR UPDATE inside tx.
+// Session-end cleanup uses it so a concurrent promotion either completes first (and its
+// row is no longer returned) or waits until the cleanup has committed.
+func lockSessionTempMedia(tx *gorm.DB, sessionID string) ([]models.TemporaryMediaItem, error) {
+	var items []models.TemporaryMediaItem
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("session_id = ?", sessionID).Find(&items).Error
+	return items, err
+}
+
+// copyStoredObject copies a stored object into a local file (never moving the original).
+func copyStoredObject(ctx context.Context, key, localPath string) error {
+	body, err := MediaStore.GetRange(ctx, key, 0, -1)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	out, err := os.Create(localPath)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, body); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// adoptLegacyTempMedia copies a temporary item from before deduplication ("temp/<uuid>.mp4")
+// into a content-addressed MediaBlob and returns it with a reference held. The original
+// file and poster are left untouched until the promotion commits.
+func adoptLegacyTempMedia(ctx context.Context, item *models.TemporaryMediaItem) (*models.MediaBlob, error) {
+	workDir, err := uploadWorkDir()
+	if err != nil {
+		return nil, err
+	}
+	fileKey := storage.KeyFromPath(item.FilePath)
+	ext := strings.ToLower(path.Ext(fileKey))
+	stem := uuid.New().String()
+
+	workPath := filepath.Join(workDir, stem+ext)
+	defer os.Remove(workPath)
+	if err := copyStoredObject(ctx, fileKey, workPath); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", fileKey, err)
+	}
+	contentHash, err := hashFile(workPath)
+	if err != nil {
+		return nil, err
+	}
+	if blob, err := acquireBlob(contentHash); err == nil {
+		return blob, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	newKey := blobKey(contentHash, ext)
+	blob := &models.MediaBlob{
+		Hash:          contentHash,
+		StorageKey:    newKey,
+		Size:          item.FileSize,
+		MimeType:      item.MimeType,
+		PosterURL:     "/icons/placeholder-poster.jpg",
+		Duration:      item.Duration,
+		MediaMetadata: item.MediaMetadata,
+	}
+	workPoster := ""
+	if storage.IsStoredURL(item.PosterURL) {
+		workPoster = filepath.Join(workDir, stem+"_poster.jpg")
+		defer os.Remove(workPoster)
+		if err := copyStoredObject(ctx, storage.KeyFromPath(item.PosterURL), workPoster); err != nil {
+			log.Printf("⚠️ adoptLegacyTempMedia: Failed to read poster %s: %v", item.PosterURL, err)
+			workPoster = ""
+		}
+	}
+
+	return putBlob(ctx, blob, func() error {
+		if workPoster != "" {
+			posterKey := strings.TrimSuffix(newKey, ext) + "_poster.jpg"
+			if variantKeys, err := storePoster(ctx, workPoster, posterKey); err != nil {
+				log.Printf("⚠️ adoptLegacyTempMedia: Failed to store poster: %v", err)
+			} else {
+				blob.PosterURL, blob.PosterVariantKeys = storage.PublicURL(posterKey), variantKeys
+			}
+		}
+		if err := MediaStore.PutFile(ctx, newKey, workPath, item.MimeType); err != nil {
+			removeStoredMedia(ctx, "", blob.PosterURL)
+			return err
+		}
+		return nil
+	})
+}
+
+// PromoteTemporaryMediaHandler handles POST /api/rooms/:id/temporary-media/:item_id/promote (host only).
+// It turns a session's temporary upload into a permanent MediaItem at the end of the room's
+// library order, keeping its file, poster and metadata. It works while the session is live;
+// the row lock on the temporary item makes it atomic with session-end cleanup, so the item
+// is either promoted or cleaned up, never both.
+func PromoteTemporaryMediaHandler(c *gin.Context) {
+	userID, room, ok := requireRoomAccess(c)
+	if !ok {
+		return
+	}
+	if room.HostID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the room host can add media to the library"})
+		return
+	}
+
+	itemID, err := strconv.ParseUint(c.Param("item_id"), 10, 64)
+	if err != nil || itemID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid media item ID"})
+		return
+	}
+
+	var peek models.TemporaryMediaItem
+	if err := DB.Where("id = ? AND room_id = ?", itemID, room.ID).First(&peek).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Temporary media item not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	// The room's quota now covers the item (the uploader's quota already did)
+	if quotas := storageQuotas(); quotas.RoomBytes > 0 {
+		used, err := roomStorageUsage(room.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check storage quota"})
+			return
+		}
+		if used+peek.FileSize > quotas.RoomBytes {
+			respondQuotaError(c, &QuotaError{Scope: "room", Limit: quotas.RoomBytes, Used: used, Requested: peek.FileSize})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+
+	// Rows from before deduplication own their file; copy it into a blob first (slow, so outside the transaction)
+	var adopted *models.MediaBlob
+	if peek.BlobHash == "" {
+		adopted, err = adoptLegacyTempMedia(ctx, &peek)
+		if err != nil {
+			log.Printf("PromoteTemporaryMediaHandler: Failed to copy legacy item %d: %v", peek.ID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to copy media file"})
+			return
+		}
+	}
+
+	var item models.TemporaryMediaItem
+	var promoted models.MediaItem
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		// Room lock serialises promotions so OrderIndex stays unique at the end
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Room{}, room.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND room_id = ?", itemID, room.ID).First(&item).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errTempItemGone
+			}
+			return err
+		}
+
+		var maxOrder *int
+		if err := tx.Model(&models.MediaItem{}).Where("room_id = ?", room.ID).
+			Select("MAX(order_index)").Scan(&maxOrder).Error; err != nil {
+			return err
+		}
+		nextOrder := 0
+		if maxOrder != nil {
+			nextOrder = *maxOrder + 1
+		}
+
+		promoted = models.MediaItem{
+			FileName:          item.FileName,
+			OriginalName:      item.OriginalName,
+			MimeType:          item.MimeType,
+			FileSize:          item.FileSize,
+			FilePath:          item.FilePath,
+			BlobHash:          item.BlobHash,
+			PosterURL:         item.PosterURL,
+			PosterVariantKeys: item.PosterVariantKeys,
+			RoomID:            room.ID,
+			UploaderID:        item.UploaderID,
+			Duration:          item.Duration,
+			OrderIndex:        nextOrder,
+			MediaMetadata:     item.MediaMetadata,
+		}
+		if adopted != nil {
+			promoted.FileName = path.Base(adopted.StorageKey)
+			promoted.FilePath = adopted.StorageKey
+			promoted.BlobHash = adopted.Hash
+			promoted.PosterURL = adopted.PosterURL
+			promoted.PosterVariantKeys = adopted.PosterVariantKeys
+		}
+
+		var sibling models.MediaItem
+		if err := tx.Select("storyboard_key").Where("blob_hash = ? AND storyboard_key <> ''", promoted.BlobHash).First(&sibling).Error; err == nil {
+			promoted.StoryboardKey = sibling.StoryboardKey
+		}
+		if err := tx.Create(&promoted).Error; err != nil {
+			return err
+		}
+		// The temporary row's blob reference now belongs to the library item
+		return tx.Delete(&item).Error
+	})
+	if err != nil {
+		if adopted != nil {
+			releaseBlob(ctx, adopted.Hash)
+		}
+		if errors.Is(err, errTempItemGone) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Temporary media item no longer exists (the session may have ended)"})
+			return
+		}
+		log.Printf("PromoteTemporaryMediaHandler: Failed to promote temporary item %d: %v", itemID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add media to the library"})
+		return
+	}
+
+	if adopted != nil {
+		// The legacy temp file and poster were copied into the blob
+		removeStoredMedia(ctx, item.FilePath, item.PosterURL)
+	}
+
+	var blob models.MediaBlob
+	if err := DB.Where("hash = ?", promoted.BlobHash).First(&blob).Error; err == nil {
+		importEmbeddedChapters(promoted.ID, blob.Chapters)
+	}
+	if promoted.StoryboardKey == "" && promoted.MediaKind == models.MediaKindVideo {
+		go generateMediaStoryboard(promoted.ID)
+	}
+	if detectMarkersOnUpload() {
+		startMarkerDetection(&promoted, false)
+	}
+	log.Printf("📚 PromoteTemporaryMediaHandler: Temporary item %d promoted to media item %d (order %d) in room %d by host %d", item.ID, promoted.ID, promoted.OrderIndex, room.ID, userID)
+
+	signMediaItemURLs(&promoted, userID)
+	if msg, err := json.Marshal(map[string]interface{}{
+		"type": "temporary_media_promoted",
+		"data": map[string]interface{}{
+			"temporary_item_id": item.ID,
+			"session_id":        item.SessionID,
+			"media_item":        promoted,
+		},
+	}); err == nil {
+		hub.BroadcastToRoom(room.ID, OutgoingMessage{Data: msg, IsBinary: false}, nil)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":           "Media item added to the room library",
+		"temporary_item_id": item.ID,
+		"media_item":        promoted,
+	})
+}
@@ -0,0 +1,494 @@
+// WeWatch/backend/internal/handlers/media_slides.go
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"wewatch-backend/internal/mediatools"
+	"wewatch-backend/internal/models"
+	"wewatch-backend/internal/storage"
+)
+
+const (
+	defaultSlideDuration = 5.0   // seconds per slide unless the uploader picks another
+	maxSlideDuration     = 600.0 // 10 minutes
+	maxSlidesPerShow     = 500
+	maxSlideCaption      = 255
+)
+
+// parseSlideDuration validates a per-slide duration in seconds (1s - 10min).
+func parseSlideDuration(raw string) (float64, bool) {
+	d, err := strconv.ParseFloat(raw, 64)
+	if err != nil || d < 1 || d > maxSlideDuration {
+		return 0, false
+	}
+	return d, true
+}
+
+// canEditSlideshow reports whether userID may change a slideshow: the room host or its uploader.
+func canEditSlideshow(room *models.Room, item *models.MediaItem, userID uint) bool {
+	return room.HostID == userID || item.UploaderID == userID
+}
+
+// refreshSlideshow renumbers a slideshow's slides (0..n-1) and recomputes the item's
+// size and duration (slides x SlideDuration), so playback sync treats it like a video
+// of that length. It leaves the slides, in order, in item.Slides.
+func refreshSlideshow(tx *gorm.DB, item *models.MediaItem) error {
+	var slides []models.MediaSlide
+	if err := tx.Where("media_item_id = ?", item.ID).Order("position, id").Find(&slides).Error; err != nil {
+		return err
+	}
+	var size int64
+	for i := range slides {
+		if slides[i].Position != i {
+			if err := tx.Model(&slides[i]).Update("position", i).Error; err != nil {
+				return err
+			}
+			slides[i].Position = i
+		}
+		size += slides[i].Size
+	}
+
+	seconds := float64(len(slides)) * item.SlideDuration
+	if err := tx.Model(item).Updates(map[string]interface{}{
+		"file_size":        size,
+		"slide_duration":   item.SlideDuration,
+		"duration_seconds": seconds,
+		"duration":         mediatools.FormatDuration(seconds),
+	}).Error; err != nil {
+		return err
+	}
+	item.FileSize = size
+	item.DurationSeconds = seconds
+	item.Duration = mediatools.FormatDuration(seconds)
+	item.Slides = slides
+	return nil
+}
+
+// signSlideURLs fills ImageURL of each slide for userID.
+func signSlideURLs(slides []models.MediaSlide, userID, roomID uint) {
+	for i := range slides {
+		slides[i].ImageURL, _ = signStoredURL(storage.PublicURL(slides[i].ImageKey), userID, roomID)
+	}
+}
+
+// attachMediaItemSlides fills in the Slides (with signed image URLs) of each slideshow item.
+func attachMediaItemSlides(items []models.MediaItem, userID uint) {
+	var ids []uint
+	for _, item := range items {
+		if item.MediaKind == models.MediaKindSlideshow {
+			ids = append(ids, item.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	var slides []models.MediaSlide
+	if err := DB.Where("media_item_id IN ?", ids).Order("media_item_id, position").Find(&slides).Error; err != nil {
+		log.Printf("⚠️ attachMediaItemSlides: Failed to load slides: %v", err)
+		return
+	}
+	byItem := map[uint][]models.MediaSlide{}
+	for _, s := range slides {
+		byItem[s.MediaItemID] = append(byItem[s.MediaItemID], s)
+	}
+	for i := range items {
+		if s, ok := byItem[items[i].ID]; ok {
+			signSlideURLs(s, userID, items[i].RoomID)
+			items[i].Slides = s
+		}
+	}
+}
+
+// deleteSlides deletes a slideshow's slide rows in tx and returns them.
+func deleteSlides(tx *gorm.DB, itemID uint) ([]models.MediaSlide, error) {
+	var slides []models.MediaSlide
+	if err := tx.Where("media_item_id = ?", itemID).Find(&slides).Error; err != nil {
+		return nil, err
+	}
+	if len(slides) == 0 {
+		return nil, nil
+	}
+	return slides, tx.Where("media_item_id = ?", itemID).Delete(&models.MediaSlide{}).Error
+}
+
+// releaseSlidesTx deletes a slideshow's slides in tx and drops their image references;
+// the freed images are added to rel.
+func releaseSlidesTx(tx *gorm.DB, itemID uint, rel *mediaRelease) error {
+	slides, err := deleteSlides(tx, itemID)
+	if err != nil {
+		return err
+	}
+	for _, s := range slides {
+		if _, err := releaseBlobTx(tx, s.BlobHash, rel); err != nil {
+			return fmt.Errorf("release slide image %s: %w", s.ImageKey, err)
+		}
+	}
+	return nil
+}
+
+// broadcastSlidesUpdated tells the room a slideshow's slides or timing changed.
+func broadcastSlidesUpdated(item *models.MediaItem, userID uint) {
+	signMediaItemURLs(item, userID)
+	signSlideURLs(item.Slides, userID, item.RoomID)
+	if msg, err := json.Marshal(map[string]interface{}{
+		"type": "media_slides_updated",
+		"data": map[string]interface{}{
+			"media_item": item,
+		},
+	}); err == nil {
+		hub.BroadcastToRoom(item.RoomID, OutgoingMessage{Data: msg, IsBinary: false}, nil)
+	}
+}
+
+// finishSlideUpload adds an uploaded image (a blob the caller holds a reference on) to a
+// slideshow. With ?slideshow_id=N it is appended to that slideshow; otherwise a new
+// slideshow item is created with it as the first slide (?title= and ?slide_duration=
+// optional). The slide takes over the caller's reference.
+func finishSlideUpload(c *gin.Context, room *models.Room, userID uint, originalName string, blob *models.MediaBlob) {
+	ctx := c.Request.Context()
+	fail := func(status int, msg string) {
+		releaseBlob(ctx, blob.Hash)
+		c.JSON(status, gin.H{"error": msg})
+	}
+
+	slideDuration := defaultSlideDuration
+	if raw := c.Query("slide_duration"); raw != "" {
+		d, ok := parseSlideDuration(raw)
+		if !ok {
+			fail(http.StatusBadRequest, "slide_duration must be between 1 and 600 seconds")
+			return
+		}
+		slideDuration = d
+	}
+
+	slide := models.MediaSlide{
+		BlobHash: blob.Hash,
+		ImageKey: blob.StorageKey,
+		MimeType: blob.MimeType,
+		Size:     blob.Size,
+		Width:    blob.Width,
+		Height:   blob.Height,
+	}
+
+	var item models.MediaItem
+	created := false
+	if raw := c.Query("slideshow_id"); raw != "" {
+		showID, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || showID == 0 {
+			fail(http.StatusBadRequest, "Invalid slideshow_id")
+			return
+		}
+		err = DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id = ? AND room_id = ? AND media_kind = ?", showID, room.ID, models.MediaKindSlideshow).
+				First(&item).Error; err != nil {
+				return err
+			}
+			if !canEditSlideshow(room, &item, userID) {
+				return errForbiddenSlideshow
+			}
+			var count int64
+			if err := tx.Model(&models.MediaSlide{}).Where("media_item_id = ?", item.ID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count >= maxSlidesPerShow {
+				return errSlideshowFull
+			}
+			slide.MediaItemID = item.ID
+			slide.Position = int(count)
+			if err := tx.Create(&slide).Error; err != nil {
+				return err
+			}
+			if c.Query("slide_duration") != "" {
+				item.SlideDuration = slideDuration
+			}
+			return refreshSlideshow(tx, &item)
+		})
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			fail(http.StatusNotFound, "Slideshow not found")
+			return
+		case errors.Is(err, errForbiddenSlideshow):
+			fail(http.StatusForbidden, "Only the room host or the slideshow's uploader can add slides")
+			return
+		case errors.Is(err, errSlideshowFull):
+			fail(http.StatusBadRequest, "Slideshow already has the maximum number of slides")
+			return
+		case err != nil:
+			log.Printf("finishSlideUpload: Failed to add slide to slideshow %d: %v", showID, err)
+			fail(http.StatusInternalServerError, "Failed to add slide")
+			return
+		}
+	} else {
+		// The slideshow item keeps its own reference to its first picture (FilePath/poster),
+		// independent of the slide, so removing that slide later leaves the item intact
+		if _, err := acquireBlob(blob.Hash); err != nil {
+			fail(http.StatusInternalServerError, "Failed to save slide")
+			return
+		}
+		metadata := blob.MediaMetadata
+		metadata.MediaKind = models.MediaKindSlideshow
+		title := strings.TrimSpace(c.Query("title"))
+		title = truncateRunes(title, 255)
+		item = models.MediaItem{
+			FileName:          path.Base(blob.StorageKey),
+			OriginalName:      originalName,
+			Title:             title,
+			MimeType:          blob.MimeType,
+			FilePath:          blob.StorageKey,
+			BlobHash:          blob.Hash,
+			PosterURL:         blob.PosterURL,
+			PosterVariantKeys: blob.PosterVariantKeys,
+			RoomID:            room.ID,
+			UploaderID:        userID,
+			OrderIndex:        0,
+			MediaMetadata:     metadata,
+			SlideDuration:     slideDuration,
+		}
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&item).Error; err != nil {
+				return err
+			}
+			slide.MediaItemID = item.ID
+			if err := tx.Create(&slide).Error; err != nil {
+				return err
+			}
+			return refreshSlideshow(tx, &item)
+		})
+		if err != nil {
+			log.Printf("finishSlideUpload: Failed to create slideshow: %v", err)
+			releaseBlob(ctx, blob.Hash) // the item's reference; fail drops the slide's
+			fail(http.StatusInternalServerError, "File uploaded but failed to save slideshow")
+			return
+		}
+		created = true
+	}
+
+	log.Printf("🖼️ finishSlideUpload: Slide %d added to slideshow %d (%d slides) in room %d by user %d", slide.ID, item.ID, len(item.Slides), room.ID, userID)
+	broadcastSlidesUpdated(&item, userID)
+
+	status := http.StatusOK
+	message := "Slide added to slideshow"
+	if created {
+		status = http.StatusCreated
+		message = "Slideshow created"
+	}
+	c.JSON(status, gin.H{
+		"message":      message,
+		"media_item":   item,
+		"slide":        item.Slides[slide.Position],
+		"is_temporary": false,
+	})
+}
+
+var (
+	errForbiddenSlideshow = errors.New("not allowed to edit this slideshow")
+	errSlideshowFull      = errors.New("slideshow is full")
+)
+
+// loadRoomSlideshow is loadRoomMediaItem restricted to slideshows.
+func loadRoomSlideshow(c *gin.Context, room *models.Room) (*models.MediaItem, bool) {
+	item, ok := loadRoomMediaItem(c, room)
+	if !ok {
+		return nil, false
+	}
+	if item.MediaKind != models.MediaKindSlideshow {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Media item is not a slideshow"})
+		return nil, false
+	}
+	return item, true
+}
+
+// GetMediaSlidesHandler handles GET /api/rooms/:id/media/:media_id/slides.
+// It lists a slideshow's slides in order with signed image URLs.
+func GetMediaSlidesHandler(c *gin.Context) {
+	userID, room, ok := requireRoomAccess(c)
+	if !ok {
+		return
+	}
+	item, ok := loadRoomSlideshow(c, room)
+	if !ok {
+		return
+	}
+
+	var slides []models.MediaSlide
+	if err := DB.Where("media_item_id = ?", item.ID).Order("position").Find(&slides).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load slides"})
+		return
+	}
+	signSlideURLs(slides, userID, room.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"media_item_id":  item.ID,
+		"slide_duration": item.SlideDuration,
+		"slides":         slides,
+	})
+}
+
+// UpdateMediaSlidesHandler handles PUT /api/rooms/:id/media/:media_id/slides (host or uploader).
+// Body fields are optional: slide_duration (seconds), slide_ids (the full new order) and
+// captions (slide id -> caption).
+func UpdateMediaSlidesHandler(c *gin.Context) {
+	userID, room, ok := requireRoomAccess(c)
+	if !ok {
+		return
+	}
+	item, ok := loadRoomSlideshow(c, room)
+	if !ok {
+		return
+	}
+	if !canEditSlideshow(room, item, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the room host or the slideshow's uploader can edit slides"})
+		return
+	}
+
+	var input struct {
+		SlideDuration *float64        `json:"slide_duration"`
+		SlideIDs      []uint          `json:"slide_ids"`
+		Captions      map[uint]string `json:"captions"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid slideshow data"})
+		return
+	}
+	if input.SlideDuration != nil {
+		if *input.SlideDuration < 1 || *input.SlideDuration > maxSlideDuration {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "slide_duration must be between 1 and 600 seconds"})
+			return
+		}
+		item.SlideDuration = *input.SlideDuration
+	}
+	for _, caption := range input.Captions {
+		if utf8.RuneCountInString(caption) > maxSlideCaption {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Captions are limited to 255 characters"})
+			return
+		}
+	}
+
+	errBadOrder := errors.New("slide_ids must list every slide exactly once")
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var slides []models.MediaSlide
+		if err := tx.Where("media_item_id = ?", item.ID).Find(&slides).Error; err != nil {
+			return err
+		}
+		known := map[uint]bool{}
+		for _, s := range slides {
+			known[s.ID] = true
+		}
+
+		if input.SlideIDs != nil {
+			if len(input.SlideIDs) != len(slides) {
+				return errBadOrder
+			}
+			seen := map[uint]bool{}
+			for i, id := range input.SlideIDs {
+				if !known[id] || seen[id] {
+					return errBadOrder
+				}
+				seen[id] = true
+				if err := tx.Model(&models.MediaSlide{}).Where("id = ?", id).Update("position", i).Error; err != nil {
+					return err
+				}
+			}
+		}
+		for id, caption := range input.Captions {
+			if !known[id] {
+				continue
+			}
+			if err := tx.Model(&models.MediaSlide{}).Where("id = ?", id).Update("caption", strings.TrimSpace(caption)).Error; err != nil {
+				return err
+			}
+		}
+		return refreshSlideshow(tx, item)
+	})
+	if errors.Is(err, errBadOrder) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("UpdateMediaSlidesHandler: Failed to update slideshow %d: %v", item.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update slideshow"})
+		return
+	}
+
+	broadcastSlidesUpdated(item, userID)
+	c.JSON(http.StatusOK, gin.H{"message": "Slideshow updated", "media_item": item})
+}
+
+// DeleteMediaSlideHandler handles DELETE /api/rooms/:id/media/:media_id/slides/:slide_id
+// (host or uploader). The last slide can't be removed - delete the slideshow instead.
+func DeleteMediaSlideHandler(c *gin.Context) {
+	userID, room, ok := requireRoomAccess(c)
+	if !ok {
+		return
+	}
+	item, ok := loadRoomSlideshow(c, room)
+	if !ok {
+		return
+	}
+	if !canEditSlideshow(room, item, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the room host or the slideshow's uploader can remove slides"})
+		return
+	}
+	slideID, err := strconv.ParseUint(c.Param("slide_id"), 10, 64)
+	if err != nil || slideID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid slide ID"})
+		return
+	}
+
+	errLastSlide := errors.New("last slide")
+	var slide models.MediaSlide
+	var rel mediaRelease
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.MediaItem{}, item.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id = ? AND media_item_id = ?", slideID, item.ID).First(&slide).Error; err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&models.MediaSlide{}).Where("media_item_id = ?", item.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count <= 1 {
+			return errLastSlide
+		}
+		if err := tx.Delete(&slide).Error; err != nil {
+			return err
+		}
+		if _, err := releaseBlobTx(tx, slide.BlobHash, &rel); err != nil {
+			return err
+		}
+		return refreshSlideshow(tx, item)
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Slide not found"})
+		return
+	case errors.Is(err, errLastSlide):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A slideshow needs at least one slide; delete the slideshow instead"})
+		return
+	case err != nil:
+		log.Printf("DeleteMediaSlideHandler: Failed to delete slide %d: %v", slideID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete slide"})
+		return
+	}
+
+	if err := rel.run(c.Request.Context()); err != nil {
+		log.Printf("⚠️ DeleteMediaSlideHandler: Failed to delete %s: %v", slide.ImageKey, err)
+	}
+	broadcastSlidesUpdated(item, userID)
+	c.JSON(http.StatusOK, gin.H{"message": "Slide removed", "media_item": item})
+}
@@ -0,0 +1,82 @@
+// WeWatch/backend/internal/handlers/media_storage.go
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"wewatch-backend/internal/storage"
+)
+
+// MediaStore is where uploaded media, posters and temp uploads are kept.
+// It is set from main.go (local disk or an S3-compatible bucket).
+var MediaStore storage.Storage
+
+// uploadWorkDir is scratch space for uploads while ffmpeg processes them,
+// before they are handed to MediaStore.
+func uploadWorkDir() (string, error) {
+	dir := os.Getenv("UPLOAD_WORK_DIR")
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "wewatch-uploads")
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// removeStoredMedia deletes a media file and its poster (with its resized renditions) from MediaStore.
+// filePath may be a legacy "./uploads/..." path or a storage key; posterURL is only
+// deleted when it points into storage (not the placeholder icon).
+func removeStoredMedia(ctx context.Context, filePath, posterURL string) error {
+	var firstErr error
+	if filePath != "" {
+		key := storage.KeyFromPath(filePath)
+		if err := MediaStore.Delete(ctx, key); err != nil {
+			log.Printf("⚠️ removeStoredMedia: Failed to delete %s: %v", key, err)
+			firstErr = err
+		} else {
+			log.Printf("✅ Deleted file: %s", key)
+		}
+	}
+	if storage.IsStoredURL(posterURL) {
+		key := storage.KeyFromPath(posterURL)
+		if err := MediaStore.Delete(ctx, key); err != nil {
+			log.Printf("⚠️ removeStoredMedia: Failed to delete poster %s: %v", key, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		for _, variant := range posterVariantKeysFor(key) {
+			if err := MediaStore.Delete(ctx, variant); err != nil {
+				log.Printf("⚠️ removeStoredMedia: Failed to delete poster rendition %s: %v", variant, err)
+			}
+		}
+	}
+	return firstErr
+}
+
+// ServeUploadsHandler handles GET /uploads/*filepath, serving objects out of MediaStore
+// with Range support (video seeking), or redirecting to the driver's presigned URL. Access is checked by authorizeMediaRequest;
+// CORS headers come from the global cors middleware.
+func ServeUploadsHandler(c *gin.Context) {
+	urlPath := c.Param("filepath")
+	if strings.Contains(urlPath, "..") {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+	key := storage.KeyFromPath(urlPath)
+
+	if !authorizeMediaRequest(c, key) {
+		return
+	}
+
+	c.Header("Accept-Ranges", "bytes")
+	c.Header("Content-Type", getMimeType(strings.ToLower(filepath.Ext(key))))
+	storage.Serve(c.Writer, c.Request, MediaStore, key)
+}
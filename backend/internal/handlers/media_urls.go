@@ -0,0 +1,250 @@
+// WeWatch/backend/internal/handlers/media_urls.go
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"wewatch-backend/internal/models"
+	"wewatch-backend/internal/storage"
+	"wewatch-backend/internal/utils"
+)
+
+// signStoredURL signs a /uploads URL for userID in roomID. Anything that isn't served
+// from storage (e.g. the placeholder poster) is returned untouched.
+func signStoredURL(u string, userID, roomID uint) (string, time.Time) {
+	if !storage.IsStoredURL(u) {
+		return u, time.Time{}
+	}
+	return utils.SignMediaURL(storage.PublicURL(storage.KeyFromPath(u)), userID, roomID, utils.MediaURLTTL())
+}
+
+// signMediaItemURLs fills FileURL and signs PosterURL for the requesting user.
+func signMediaItemURLs(item *models.MediaItem, userID uint) {
+	fileURL, expiresAt := signStoredURL(storage.PublicURL(storage.KeyFromPath(item.FilePath)), userID, item.RoomID)
+	item.FileURL = fileURL
+	item.URLExpiresAt = expiresAt.Unix()
+	item.PosterURL, _ = signStoredURL(item.PosterURL, userID, item.RoomID)
+	item.PosterVariants = signPosterVariants(item.PosterVariantKeys, userID, item.RoomID)
+	item.StoryboardURL = storyboardURL(item)
+}
+
+// signTemporaryMediaItemURLs is signMediaItemURLs for session uploads.
+func signTemporaryMediaItemURLs(item *models.TemporaryMediaItem, userID uint) {
+	fileURL, expiresAt := signStoredURL(storage.PublicURL(storage.KeyFromPath(item.FilePath)), userID, item.RoomID)
+	item.FileURL = fileURL
+	item.URLExpiresAt = expiresAt.Unix()
+	item.PosterURL, _ = signStoredURL(item.PosterURL, userID, item.RoomID)
+	item.PosterVariants = signPosterVariants(item.PosterVariantKeys, userID, item.RoomID)
+}
+
+// mediaKeyRoomIDs returns the rooms whose media references a stored object, by matching it
+// against media item files, posters (and renditions), storyboards and slideshow slides (permanent
+// and temporary).
+// Deduplicated blobs can belong to several rooms at once.
+func mediaKeyRoomIDs(key string) ([]uint, error) {
+	var roomIDs []uint
+
+	// Storyboard sheets belong to whichever items own the track next to them
+	if strings.HasPrefix(key, "storyboards/") {
+		err := DB.Model(&models.MediaItem{}).Distinct("room_id").
+			Where("storyboard_key = ?", path.Dir(key)+"/"+storyboardVTTName).Pluck("room_id", &roomIDs).Error
+		return roomIDs, err
+	}
+
+	// Exact matches only, so the file_path/poster_url indexes serve every lookup.
+	// Poster renditions belong to whoever owns the poster they were rendered from.
+	paths := []string{key, "uploads/" + key, "./uploads/" + key}
+	posterURLs := []string{storage.PublicURL(key)}
+	if posterKey, ok := posterKeyForVariant(key); ok {
+		posterURLs = append(posterURLs, storage.PublicURL(posterKey))
+	}
+
+	if err := DB.Model(&models.MediaItem{}).Distinct("room_id").
+		Where("file_path IN ? OR poster_url IN ?", paths, posterURLs).
+		Pluck("room_id", &roomIDs).Error; err != nil {
+		return nil, err
+	}
+	var tempRoomIDs []uint
+	if err := DB.Model(&models.TemporaryMediaItem{}).Distinct("room_id").
+		Where("file_path IN ? OR poster_url IN ?", paths, posterURLs).
+		Pluck("room_id", &tempRoomIDs).Error; err != nil {
+		return nil, err
+	}
+	// Slideshow pictures belong to the rooms of the slideshows showing them
+	var slideRoomIDs []uint
+	if err := DB.Model(&models.MediaSlide{}).
+		Joins("JOIN media_items ON media_items.id = media_slides.media_item_id AND media_items.deleted_at IS NULL").
+		Where("media_slides.image_key = ?", key).
+		Distinct("media_items.room_id").Pluck("media_items.room_id", &slideRoomIDs).Error; err != nil {
+		return nil, err
+	}
+	return append(append(roomIDs, tempRoomIDs...), slideRoomIDs...), nil
+}
+
+// authorizeMediaRequest decides whether a GET /uploads/<key> request may be served.
+// Avatars are public. Media in public rooms may be fetched unsigned; media in
+// private rooms needs a valid, unexpired signature issued for a room that uses it,
+// to a user who still has access to that room.
+// On refusal it writes the error response and returns false.
+func authorizeMediaRequest(c *gin.Context, key string) bool {
+	if strings.HasPrefix(key, "avatars/") {
+		return true
+	}
+
+	roomIDs, err := mediaKeyRoomIDs(key)
+	if err != nil {
+		log.Printf("authorizeMediaRequest: DB error looking up owner of %s: %v", key, err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return false
+	}
+	if len(roomIDs) == 0 {
+		c.AbortWithStatus(http.StatusNotFound)
+		return false
+	}
+
+	signedUserID, signedRoomID, err := utils.VerifyMediaURL(storage.PublicURL(key), c.Request.URL.Query())
+	if err == nil {
+		err = utils.ErrMediaURLInvalid
+		for _, id := range roomIDs {
+			if id != signedRoomID {
+				continue
+			}
+			// The link was issued to one user: it dies with their access to the room,
+			// not only when it expires
+			var room models.Room
+			if DB.First(&room, signedRoomID).Error == nil && userHasRoomAccess(signedUserID, &room) {
+				c.Header("Cache-Control", "private, max-age=300")
+				return true
+			}
+			break
+		}
+	}
+
+	if errors.Is(err, utils.ErrMediaURLUnsigned) {
+		var publicRooms int64
+		DB.Model(&models.Room{}).Where("id IN ? AND is_public = ?", roomIDs, true).Count(&publicRooms)
+		if publicRooms > 0 {
+			c.Header("Cache-Control", "public, max-age=3600")
+			return true
+		}
+	}
+
+	log.Printf("authorizeMediaRequest: Refusing %s (rooms %v): %v", key, roomIDs, err)
+	if errors.Is(err, utils.ErrMediaURLExpired) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "media_url_expired", "message": "This media link has expired, please refresh it"})
+	} else {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Media access denied"})
+	}
+	c.Abort()
+	return false
+}
+
+// GetMediaSignedURLHandler handles GET /api/rooms/:id/media/:media_id/signed-url.
+// Clients call it to refresh playback and poster URLs before url_expires_at.
+func GetMediaSignedURLHandler(c *gin.Context) {
+	userID, room, ok := requireRoomAccess(c)
+	if !ok {
+		return
+	}
+
+	mediaID, err := strconv.ParseUint(c.Param("media_id"), 10, 64)
+	if err != nil || mediaID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid media item ID"})
+		return
+	}
+
+	var item models.MediaItem
+	if err := DB.Where("id = ? AND room_id = ?", mediaID, room.ID).First(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Media item not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	signMediaItemURLs(&item, userID)
+	c.JSON(http.StatusOK, gin.H{
+		"media_item_id":  item.ID,
+		"file_url":       item.FileURL,
+		"poster_url":     item.PosterURL,
+		"url_expires_at": item.URLExpiresAt,
+	})
+}
+
+// GetTemporaryMediaSignedURLHandler handles GET /api/rooms/:id/temporary-media/:item_id/signed-url.
+func GetTemporaryMediaSignedURLHandler(c *gin.Context) {
+	userID, room, ok := requireRoomAccess(c)
+	if !ok {
+		return
+	}
+
+	itemID, err := strconv.ParseUint(c.Param("item_id"), 10, 64)
+	if err != nil || itemID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid media item ID"})
+		return
+	}
+
+	var item models.TemporaryMediaItem
+	if err := DB.Where("id = ? AND room_id = ?", itemID, room.ID).First(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Media item not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	signTemporaryMediaItemURLs(&item, userID)
+	c.JSON(http.StatusOK, gin.H{
+		"media_item_id":  item.ID,
+		"file_url":       item.FileURL,
+		"poster_url":     item.PosterURL,
+		"url_expires_at": item.URLExpiresAt,
+	})
+}
+
+// requireRoomAccess loads the :id room and checks the authenticated user may see it.
+// On failure it writes the error response and returns ok=false.
+func requireRoomAccess(c *gin.Context) (uint, *models.Room, bool) {
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return 0, nil, false
+	}
+	userID, ok := userIDValue.(uint)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return 0, nil, false
+	}
+
+	roomID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || roomID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid room ID"})
+		return 0, nil, false
+	}
+
+	var room models.Room
+	if err := DB.First(&room, roomID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return 0, nil, false
+	}
+
+	if !userHasRoomAccess(userID, &room) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have access to this room"})
+		return 0, nil, false
+	}
+	return userID, &room, true
+}
@@ -0,0 +1,69 @@
+// WeWatch/backend/internal/handlers/media_waveforms.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"wewatch-backend/internal/models"
+)
+
+// respondWaveform writes the waveform peaks stored on an audio item's blob.
+func respondWaveform(c *gin.Context, itemID uint, kind, blobHash string) {
+	if kind != models.MediaKindAudio {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Media item is not audio"})
+		return
+	}
+	var blob models.MediaBlob
+	if blobHash == "" || DB.Select("waveform", "duration_seconds").Where("hash = ?", blobHash).First(&blob).Error != nil || len(blob.Waveform) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No waveform for this media item"})
+		return
+	}
+	c.Header("Cache-Control", "private, max-age=3600")
+	c.JSON(http.StatusOK, gin.H{
+		"media_item_id":    itemID,
+		"duration_seconds": blob.DurationSeconds,
+		"peaks":            blob.Waveform,
+	})
+}
+
+// GetMediaWaveformHandler handles GET /api/rooms/:id/media/:media_id/waveform.
+// It returns peak amplitudes (0-1, evenly spaced over the track) for drawing an audio seek bar.
+func GetMediaWaveformHandler(c *gin.Context) {
+	_, room, ok := requireRoomAccess(c)
+	if !ok {
+		return
+	}
+	item, ok := loadRoomMediaItem(c, room)
+	if !ok {
+		return
+	}
+	respondWaveform(c, item.ID, item.MediaKind, item.BlobHash)
+}
+
+// GetTemporaryMediaWaveformHandler handles GET /api/rooms/:id/temporary-media/:item_id/waveform.
+func GetTemporaryMediaWaveformHandler(c *gin.Context) {
+	_, room, ok := requireRoomAccess(c)
+	if !ok {
+		return
+	}
+	itemID, err := strconv.ParseUint(c.Param("item_id"), 10, 64)
+	if err != nil || itemID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid media item ID"})
+		return
+	}
+
+	var item models.TemporaryMediaItem
+	if err := DB.Where("id = ? AND room_id = ?", itemID, room.ID).First(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Media item not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return
+	}
+	respondWaveform(c, item.ID, item.MediaKind, item.BlobHash)
+}
@@ -0,0 +1,262 @@
+// WeWatch/backend/internal/handlers/playlist_advance.go
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"wewatch-backend/internal/models"
+)
+
+// Server-side playlist advance. Clients move to the next item themselves when a video ends;
+// rooms with AdvanceAtCredits set let the server do it instead as soon as the current item's
+// credits start (CreditsStart), so nobody sits through them. The server follows the host's
+// playback_control messages to know what is playing and from where.
+
+// roomPlayback is what the server knows about a room's current library item.
+type roomPlayback struct {
+	itemID   uint
+	playing  bool
+	position float64   // seconds into the item...
+	at       time.Time // ...as of this moment
+	timer    *time.Timer
+}
+
+var (
+	playbackMu    sync.Mutex
+	roomPlaybacks = map[uint]*roomPlayback{}
+)
+
+// isPlaybackHost reports whether userID controls playback in the room: the room host or
+// the host of its active watch session.
+func isPlaybackHost(room *models.Room, userID uint) bool {
+	if room.HostID == userID {
+		return true
+	}
+	var count int64
+	DB.Model(&models.WatchSession{}).Where("room_id = ? AND ended_at IS NULL AND host_id = ?", room.ID, userID).Count(&count)
+	return count > 0
+}
+
+// itemEnd is where playback of an item counts as finished for the server: the start of the
+// credits when the room advances at credits, otherwise 0 (the server doesn't advance).
+func itemEnd(room *models.Room, item *models.MediaItem) float64 {
+	if !room.AdvanceAtCredits || item.CreditsStart <= 0 {
+		return 0
+	}
+	return item.CreditsStart
+}
+
+// trackPlayback records that item is playing (or paused) at position in the room and
+// (re)arms the advance timer.
+func trackPlayback(room *models.Room, item *models.MediaItem, position float64, playing bool) {
+	playbackMu.Lock()
+	defer playbackMu.Unlock()
+
+	if p, ok := roomPlaybacks[room.ID]; ok && p.timer != nil {
+		p.timer.Stop()
+	}
+	p := &roomPlayback{itemID: item.ID, playing: playing, position: position, at: time.Now()}
+	roomPlaybacks[room.ID] = p
+
+	end := itemEnd(room, item)
+	if !playing || end <= position {
+		return
+	}
+	roomID, itemID := room.ID, item.ID
+	p.timer = time.AfterFunc(time.Duration((end-position)*float64(time.Second)), func() {
+		playbackMu.Lock()
+		current, ok := roomPlaybacks[roomID]
+		stale := !ok || current != p
+		playbackMu.Unlock()
+		if !stale {
+			advancePlaylist(roomID, itemID, "credits")
+		}
+	})
+}
+
+// rearmPlayback recomputes a room's advance timer after its settings or the current
+// item's markers changed.
+func rearmPlayback(roomID uint) {
+	playbackMu.Lock()
+	p, ok := roomPlaybacks[roomID]
+	playbackMu.Unlock()
+	if !ok {
+		return
+	}
+	var room models.Room
+	var item models.MediaItem
+	if DB.First(&room, roomID).Error != nil || DB.First(&item, p.itemID).Error != nil {
+		forgetPlayback(roomID)
+		return
+	}
+	position := p.position
+	if p.playing {
+		position += time.Since(p.at).Seconds()
+	}
+	trackPlayback(&room, &item, position, p.playing)
+}
+
+// forgetPlayback stops tracking a room (advance disabled, room gone, playlist finished).
+func forgetPlayback(roomID uint) {
+	playbackMu.Lock()
+	if p, ok := roomPlaybacks[roomID]; ok {
+		if p.timer != nil {
+			p.timer.Stop()
+		}
+		delete(roomPlaybacks, roomID)
+	}
+	playbackMu.Unlock()
+}
+
+// observePlaybackControl follows a host's playback_control message (which is still
+// relayed to the room as before) so the advance timer matches what everyone sees.
+func (client *Client) observePlaybackControl(message []byte) {
+	var control struct {
+		Command     string   `json:"command"`
+		MediaItemID uint     `json:"media_item_id"`
+		FilePath    string   `json:"file_path"`
+		SeekTime    *float64 `json:"seek_time"`
+	}
+	if err := json.Unmarshal(message, &control); err != nil {
+		return
+	}
+
+	var room models.Room
+	if err := DB.First(&room, client.roomID).Error; err != nil || !room.AdvanceAtCredits {
+		return
+	}
+	if !isPlaybackHost(&room, client.userID) {
+		return
+	}
+
+	playbackMu.Lock()
+	current := roomPlaybacks[room.ID]
+	playbackMu.Unlock()
+
+	itemID := control.MediaItemID
+	playing := control.Command == "play" || control.Command == "resume"
+	switch control.Command {
+	case "play", "resume":
+	case "seek":
+		if current == nil {
+			return
+		}
+		playing = current.playing
+		if itemID == 0 {
+			itemID = current.itemID
+		}
+	case "pause":
+		if itemID == 0 && current != nil {
+			itemID = current.itemID
+		}
+	default:
+		forgetPlayback(room.ID)
+		return
+	}
+
+	var item models.MediaItem
+	if itemID == 0 || DB.Where("id = ? AND room_id = ?", itemID, room.ID).First(&item).Error != nil {
+		forgetPlayback(room.ID) // temporary uploads and screen shares have no markers
+		return
+	}
+	if control.FilePath != "" && control.FilePath != item.FilePath {
+		forgetPlayback(room.ID) // a temporary item that happens to share the ID
+		return
+	}
+	position := 0.0
+	if control.SeekTime != nil {
+		position = *control.SeekTime
+	}
+	trackPlayback(&room, &item, position, playing)
+}
+
+// broadcastPlayItem tells the whole room (host included - there is no sender_id) to play
+// item from position, in the same shape clients send playback_control, and tracks it.
+func broadcastPlayItem(room *models.Room, item *models.MediaItem, signerID uint, position float64, extra map[string]interface{}) {
+	signMediaItemURLs(item, signerID)
+	control := map[string]interface{}{
+		"type":          "playback_control",
+		"command":       "play",
+		"media_item_id": item.ID,
+		"file_path":     item.FilePath,
+		"file_url":      item.FileURL,
+		"original_name": item.OriginalName,
+		"media_kind":    item.MediaKind,
+		"seek_time":     position,
+		"timestamp":     time.Now().UnixMilli(),
+	}
+	for k, v := range extra {
+		control[k] = v
+	}
+	if msg, err := json.Marshal(control); err == nil {
+		hub.BroadcastToRoom(room.ID, OutgoingMessage{Data: msg, IsBinary: false}, nil)
+		recordTimelinePlayback(runningSessionID(room.ID), room.ID, 0, msg)
+	}
+	trackPlayback(room, item, position, true)
+	recordMediaPlay(room.ID, item.ID, item.FilePath, mediaItemTitle(item))
+}
+
+// nextPlaylistItem returns the library item after currentID in room order, wrapping
+// around in "playlist-infinite" loop mode. It returns nil at the end of the playlist.
+func nextPlaylistItem(room *models.Room, currentID uint) *models.MediaItem {
+	var items []models.MediaItem
+	if err := DB.Where("room_id = ?", room.ID).Order("order_index, id").Find(&items).Error; err != nil {
+		log.Printf("⚠️ nextPlaylistItem: Failed to load playlist of room %d: %v", room.ID, err)
+		return nil
+	}
+	for i, item := range items {
+		if item.ID != currentID {
+			continue
+		}
+		if i+1 < len(items) {
+			return &items[i+1]
+		}
+		if room.LoopMode == "playlist-infinite" {
+			return &items[0]
+		}
+		return nil
+	}
+	return nil
+}
+
+// advancePlaylist moves the room from currentID to the next library item. reason is sent
+// along ("credits" from the timer, "skip_credits" from the host). At the end of the
+// playlist the room gets "playlist_ended" instead.
+func advancePlaylist(roomID, currentID uint, reason string) {
+	var room models.Room
+	if err := DB.First(&room, roomID).Error; err != nil {
+		forgetPlayback(roomID)
+		return
+	}
+	if len(hub.GetAllUserIDsInRoom(roomID)) == 0 {
+		forgetPlayback(roomID) // nobody is watching any more
+		return
+	}
+
+	next := nextPlaylistItem(&room, currentID)
+	if next == nil {
+		forgetPlayback(roomID)
+		if msg, err := json.Marshal(map[string]interface{}{
+			"type": "playlist_ended",
+			"data": map[string]interface{}{
+				"room_id":       roomID,
+				"media_item_id": currentID,
+				"reason":        reason,
+			},
+		}); err == nil {
+			hub.BroadcastToRoom(roomID, OutgoingMessage{Data: msg, IsBinary: false}, nil)
+		}
+		log.Printf("⏹️ advancePlaylist: Playlist of room %d finished after media item %d (%s)", roomID, currentID, reason)
+		return
+	}
+
+	log.Printf("⏭️ advancePlaylist: Room %d advancing from media item %d to %d (%s)", roomID, currentID, next.ID, reason)
+	broadcastPlayItem(&room, next, room.HostID, 0, map[string]interface{}{
+		"auto_advance":           true,
+		"advance_reason":         reason,
+		"previous_media_item_id": currentID,
+	})
+}
@@ -0,0 +1,249 @@
+// WeWatch/backend/internal/handlers/posters.go
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"wewatch-backend/internal/mediatools"
+	"wewatch-backend/internal/models"
+	"wewatch-backend/internal/storage"
+)
+
+// maxPosterUploadSize caps custom poster images.
+const maxPosterUploadSize = 10 << 20 // 10 MB
+
+// storePoster hands a locally rendered poster (JPEG) to MediaStore under posterKey, together
+// with its resized JPEG/WebP renditions. It returns the renditions' storage keys.
+// Renditions are best effort: if they fail the poster itself is still stored.
+func storePoster(ctx context.Context, localPoster, posterKey string) ([]string, error) {
+	dir := ""
+	if i := strings.LastIndex(posterKey, "/"); i >= 0 {
+		dir = posterKey[:i+1]
+	}
+	base := strings.TrimSuffix(path.Base(posterKey), path.Ext(posterKey))
+
+	var variantKeys []string
+	outDir, err := os.MkdirTemp(filepath.Dir(localPoster), "poster-*")
+	if err == nil {
+		defer os.RemoveAll(outDir)
+		outputs, err := mediatools.Default().RenderPosterVariants(ctx, localPoster, outDir, base)
+		if err != nil {
+			log.Printf("⚠️ storePoster: Failed to render poster sizes for %s: %v", posterKey, err)
+		}
+		for _, out := range outputs {
+			key := dir + filepath.Base(out)
+			if err := MediaStore.PutFile(ctx, key, out, getMimeType(filepath.Ext(out))); err != nil {
+				log.Printf("⚠️ storePoster: Failed to store %s: %v", key, err)
+				continue
+			}
+			variantKeys = append(variantKeys, key)
+		}
+	}
+
+	if err := MediaStore.PutFile(ctx, posterKey, localPoster, "image/jpeg"); err != nil {
+		for _, key := range variantKeys {
+			MediaStore.Delete(ctx, key)
+		}
+		return nil, err
+	}
+	return variantKeys, nil
+}
+
+// posterVariantKeysFor lists every rendition key storePoster could have written for a poster.
+// Used on delete, where rows from before renditions existed have no keys recorded.
+func posterVariantKeysFor(posterKey string) []string {
+	stem := strings.TrimSuffix(posterKey, path.Ext(posterKey))
+	var keys []string
+	for _, width := range mediatools.PosterWidths {
+		for _, format := range mediatools.PosterFormats {
+			keys = append(keys, mediatools.PosterVariantName(stem, width, format))
+		}
+	}
+	return keys
+}
+
+// posterKeyForVariant is the inverse of posterVariantKeysFor: it returns the poster a
+// rendition was rendered from (posters are always stored as JPEG), or false if key isn't a rendition.
+func posterKeyForVariant(key string) (string, bool) {
+	stem := strings.TrimSuffix(key, path.Ext(key))
+	i := strings.LastIndex(stem, "_")
+	if i <= 0 {
+		return "", false
+	}
+	for _, width := range mediatools.PosterWidths {
+		for _, format := range mediatools.PosterFormats {
+			if mediatools.PosterVariantName(stem[:i], width, format) == key {
+				return stem[:i] + ".jpg", true
+			}
+		}
+	}
+	return "", false
+}
+
+// signPosterVariants turns stored rendition keys into signed URLs for userID.
+func signPosterVariants(keys []string, userID, roomID uint) []models.PosterVariant {
+	variants := make([]models.PosterVariant, 0, len(keys))
+	for _, key := range keys {
+		ext := path.Ext(key)
+		stem := strings.TrimSuffix(key, ext)
+		width, _ := strconv.Atoi(stem[strings.LastIndex(stem, "_")+1:])
+		url, _ := signStoredURL(storage.PublicURL(key), userID, roomID)
+		variants = append(variants, models.PosterVariant{
+			URL:    url,
+			Width:  width,
+			Format: strings.TrimPrefix(ext, "."),
+		})
+	}
+	return variants
+}
+
+type setPosterRequest struct {
+	Timestamp *float64 `json:"timestamp" form:"timestamp"` // seconds into the video
+}
+
+// SetMediaPosterHandler handles PUT /api/rooms/:id/media/:media_id/poster (host only).
+// The host either uploads an image (multipart field "poster": JPEG, PNG or WebP) or
+// picks a frame with {"timestamp": <seconds>}. The old poster and its renditions are removed.
+func SetMediaPosterHandler(c *gin.Context) {
+	userID, room, ok := requireRoomAccess(c)
+	if !ok {
+		return
+	}
+	if room.HostID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the room host can change posters"})
+		return
+	}
+
+	mediaID, err := strconv.ParseUint(c.Param("media_id"), 10, 64)
+	if err != nil || mediaID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid media item ID"})
+		return
+	}
+
+	var item models.MediaItem
+	if err := DB.Where("id = ? AND room_id = ?", mediaID, room.ID).First(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Media item not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	workDir, err := uploadWorkDir()
+	if err != nil {
+		log.Printf("SetMediaPosterHandler: Failed to prepare work directory: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare storage"})
+		return
+	}
+
+	// A fresh key per change, so browsers and caches never show the old poster
+	fileKey := storage.KeyFromPath(item.FilePath)
+	posterKey := fmt.Sprintf("%s_poster_%d.jpg", strings.TrimSuffix(fileKey, path.Ext(fileKey)), time.Now().Unix())
+	workPoster := filepath.Join(workDir, filepath.Base(posterKey))
+	defer os.Remove(workPoster)
+
+	var req setPosterRequest
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxPosterUploadSize+(1<<20))
+		c.ShouldBind(&req)
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Send a poster image or a timestamp"})
+		return
+	}
+
+	if formFile, err := c.FormFile("poster"); err == nil {
+		if formFile.Size > maxPosterUploadSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Poster image too large. Maximum size is 10 MB."})
+			return
+		}
+		upload, err := formFile.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read poster image"})
+			return
+		}
+		sniff := make([]byte, 512)
+		n, _ := upload.Read(sniff)
+		upload.Close()
+		contentType := http.DetectContentType(sniff[:n])
+		if contentType != "image/jpeg" && contentType != "image/png" && contentType != "image/webp" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Poster must be a JPEG, PNG or WebP image"})
+			return
+		}
+
+		uploadPath := workPoster + ".upload"
+		defer os.Remove(uploadPath)
+		if err := c.SaveUploadedFile(formFile, uploadPath); err != nil {
+			log.Printf("SetMediaPosterHandler: Failed to save poster upload: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save poster image"})
+			return
+		}
+		// Normalise whatever was uploaded to a JPEG like generated posters
+		if err := mediatools.Default().ExtractFrameAt(c.Request.Context(), uploadPath, workPoster, 0); err != nil {
+			log.Printf("SetMediaPosterHandler: Failed to convert poster upload: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Poster image could not be read"})
+			return
+		}
+	} else if req.Timestamp != nil {
+		at := *req.Timestamp
+		if at < 0 || (item.DurationSeconds > 0 && at >= item.DurationSeconds) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("timestamp must be between 0 and %.1f seconds", item.DurationSeconds)})
+			return
+		}
+		localVideo, cleanup, err := storage.FetchToLocal(c.Request.Context(), MediaStore, fileKey)
+		if err != nil {
+			log.Printf("SetMediaPosterHandler: Failed to fetch %s: %v", fileKey, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read media file"})
+			return
+		}
+		defer cleanup()
+		if err := mediatools.Default().ExtractFrameAt(c.Request.Context(), localVideo, workPoster, at); err != nil {
+			log.Printf("SetMediaPosterHandler: Failed to extract frame at %.2fs: %v", at, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to extract poster frame"})
+			return
+		}
+	} else {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Send a poster image or a timestamp"})
+		return
+	}
+
+	variantKeys, err := storePoster(c.Request.Context(), workPoster, posterKey)
+	if err != nil {
+		log.Printf("SetMediaPosterHandler: Failed to store poster %s: %v", posterKey, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store poster"})
+		return
+	}
+
+	oldPosterURL := item.PosterURL
+	if err := DB.Model(&item).Select("poster_url", "poster_variant_keys").Updates(models.MediaItem{
+		PosterURL:         storage.PublicURL(posterKey),
+		PosterVariantKeys: variantKeys,
+	}).Error; err != nil {
+		log.Printf("SetMediaPosterHandler: Failed to update media item %d: %v", item.ID, err)
+		removeStoredMedia(c.Request.Context(), "", storage.PublicURL(posterKey))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save poster"})
+		return
+	}
+	item.PosterURL = storage.PublicURL(posterKey)
+	item.PosterVariantKeys = variantKeys
+	releasePoster(c.Request.Context(), item.BlobHash, oldPosterURL) // keeps the blob's shared poster
+
+	log.Printf("✅ SetMediaPosterHandler: Poster of media item %d replaced by host %d", item.ID, userID)
+	signMediaItemURLs(&item, userID)
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Poster updated successfully",
+		"media_item": item,
+	})
+}
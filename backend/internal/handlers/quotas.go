@@ -0,0 +1,279 @@
+// WeWatch/backend/internal/handlers/quotas.go
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"wewatch-backend/internal/models"
+)
+
+// Default quotas, overridable with QUOTA_USER_BYTES, QUOTA_ROOM_BYTES and
+// QUOTA_SESSION_TEMP_BYTES. A value of 0 disables that quota.
+const (
+	defaultUserQuotaBytes        int64 = 20 << 30 // 20 GB across everything a user uploaded
+	defaultRoomQuotaBytes        int64 = 50 << 30 // 50 GB of permanent media per room
+	defaultSessionTempQuotaBytes int64 = 10 << 30 // 10 GB of temporary media per watch session
+)
+
+// StorageQuotas holds the configured limits in bytes (0 = unlimited).
+type StorageQuotas struct {
+	UserBytes        int64 `json:"user_bytes"`
+	RoomBytes        int64 `json:"room_bytes"`
+	SessionTempBytes int64 `json:"session_temp_bytes"`
+}
+
+// QuotaError explains which quota an upload would exceed.
+type QuotaError struct {
+	Scope     string // "user", "room" or "session"
+	Limit     int64
+	Used      int64
+	Requested int64
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s storage quota exceeded: %d of %d bytes used, upload needs %d", e.Scope, e.Used, e.Limit, e.Requested)
+}
+
+func quotaFromEnv(name string, def int64) int64 {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		log.Printf("⚠️ Invalid %s=%q, using default %d", name, v, def)
+		return def
+	}
+	return n
+}
+
+// storageQuotas reads the current limits from the environment.
+func storageQuotas() StorageQuotas {
+	return StorageQuotas{
+		UserBytes:        quotaFromEnv("QUOTA_USER_BYTES", defaultUserQuotaBytes),
+		RoomBytes:        quotaFromEnv("QUOTA_ROOM_BYTES", defaultRoomQuotaBytes),
+		SessionTempBytes: quotaFromEnv("QUOTA_SESSION_TEMP_BYTES", defaultSessionTempQuotaBytes),
+	}
+}
+
+// userStorageUsage sums the sizes of everything a user has uploaded (permanent and temporary).
+func userStorageUsage(userID uint) (int64, error) {
+	return userStorageUsageIn(DB, userID)
+}
+
+func userStorageUsageIn(db *gorm.DB, userID uint) (int64, error) {
+	var permanent, temporary int64
+	if err := db.Model(&models.MediaItem{}).Where("uploader_id = ?", userID).
+		Select("COALESCE(SUM(file_size), 0)").Scan(&permanent).Error; err != nil {
+		return 0, err
+	}
+	if err := db.Model(&models.TemporaryMediaItem{}).Where("uploader_id = ?", userID).
+		Select("COALESCE(SUM(file_size), 0)").Scan(&temporary).Error; err != nil {
+		return 0, err
+	}
+	return permanent + temporary, nil
+}
+
+// roomStorageUsage sums the sizes of a room's permanent media.
+func roomStorageUsage(roomID uint) (int64, error) {
+	return roomStorageUsageIn(DB, roomID)
+}
+
+func roomStorageUsageIn(db *gorm.DB, roomID uint) (int64, error) {
+	var used int64
+	err := db.Model(&models.MediaItem{}).Where("room_id = ?", roomID).
+		Select("COALESCE(SUM(file_size), 0)").Scan(&used).Error
+	return used, err
+}
+
+// sessionTempStorageUsage sums the sizes of a watch session's temporary media.
+func sessionTempStorageUsage(sessionID string) (int64, error) {
+	return sessionTempStorageUsageIn(DB, sessionID)
+}
+
+func sessionTempStorageUsageIn(db *gorm.DB, sessionID string) (int64, error) {
+	var used int64
+	err := db.Model(&models.TemporaryMediaItem{}).Where("session_id = ?", sessionID).
+		Select("COALESCE(SUM(file_size), 0)").Scan(&used).Error
+	return used, err
+}
+
+// checkUploadQuota returns a *QuotaError if storing size more bytes would exceed the
+// uploader's quota, the room's quota (permanent uploads) or the session's quota (temporary uploads).
+func checkUploadQuota(userID, roomID uint, sessionID string, isTemporary bool, size int64) error {
+	return checkUploadQuotaIn(DB, userID, roomID, sessionID, isTemporary, size)
+}
+
+func checkUploadQuotaIn(db *gorm.DB, userID, roomID uint, sessionID string, isTemporary bool, size int64) error {
+	quotas := storageQuotas()
+
+	if quotas.UserBytes > 0 {
+		used, err := userStorageUsageIn(db, userID)
+		if err != nil {
+			return err
+		}
+		if used+size > quotas.UserBytes {
+			return &QuotaError{Scope: "user", Limit: quotas.UserBytes, Used: used, Requested: size}
+		}
+	}
+
+	if isTemporary {
+		if quotas.SessionTempBytes > 0 {
+			used, err := sessionTempStorageUsageIn(db, sessionID)
+			if err != nil {
+				return err
+			}
+			if used+size > quotas.SessionTempBytes {
+				return &QuotaError{Scope: "session", Limit: quotas.SessionTempBytes, Used: used, Requested: size}
+			}
+		}
+	} else if quotas.RoomBytes > 0 {
+		used, err := roomStorageUsageIn(db, roomID)
+		if err != nil {
+			return err
+		}
+		if used+size > quotas.RoomBytes {
+			return &QuotaError{Scope: "room", Limit: quotas.RoomBytes, Used: used, Requested: size}
+		}
+	}
+	return nil
+}
+
+// lockUploadQuota serializes, until tx ends, uploads charged to the user and to the
+// room (permanent uploads) or session (temporary uploads). The user lock is always
+// taken first, so two uploads can't wait on each other.
+func lockUploadQuota(tx *gorm.DB, userID, roomID uint, sessionID string, isTemporary bool) error {
+	keys := []string{fmt.Sprintf("quota:user:%d", userID)}
+	if isTemporary {
+		keys = append(keys, "quota:session:"+sessionID)
+	} else {
+		keys = append(keys, fmt.Sprintf("quota:room:%d", roomID))
+	}
+	for _, key := range keys {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", key).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createWithinQuota checks the quota again with the quota locks held and runs create in
+// the same transaction, so concurrent uploads can't all pass the early check and
+// together exceed it. It returns a *QuotaError if the upload no longer fits.
+func createWithinQuota(userID, roomID uint, sessionID string, isTemporary bool, size int64, create func(tx *gorm.DB) error) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockUploadQuota(tx, userID, roomID, sessionID, isTemporary); err != nil {
+			return err
+		}
+		if err := checkUploadQuotaIn(tx, userID, roomID, sessionID, isTemporary, size); err != nil {
+			return err
+		}
+		return create(tx)
+	})
+}
+
+// respondQuotaError writes the 413 response for an exceeded quota.
+func respondQuotaError(c *gin.Context, qe *QuotaError) {
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error":     "quota_exceeded",
+		"message":   fmt.Sprintf("This upload would exceed the %s storage quota", qe.Scope),
+		"scope":     qe.Scope,
+		"limit":     qe.Limit,
+		"used":      qe.Used,
+		"requested": qe.Requested,
+	})
+}
+
+// GetMyStorageUsageHandler handles GET /api/users/me/storage.
+// It reports how much the current user has uploaded against their quota.
+func GetMyStorageUsageHandler(c *gin.Context) {
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID, ok := userIDValue.(uint)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+
+	used, err := userStorageUsage(userID)
+	if err != nil {
+		log.Printf("GetMyStorageUsageHandler: Failed to sum usage for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate storage usage"})
+		return
+	}
+
+	var mediaCount, tempCount int64
+	DB.Model(&models.MediaItem{}).Where("uploader_id = ?", userID).Count(&mediaCount)
+	DB.Model(&models.TemporaryMediaItem{}).Where("uploader_id = ?", userID).Count(&tempCount)
+
+	quotas := storageQuotas()
+	c.JSON(http.StatusOK, gin.H{
+		"user_id":         userID,
+		"used_bytes":      used,
+		"limit_bytes":     quotas.UserBytes,
+		"media_items":     mediaCount,
+		"temporary_items": tempCount,
+		"quotas":          quotas,
+	})
+}
+
+// GetRoomStorageUsageHandler handles GET /api/rooms/:id/storage (host only).
+// It reports the room's permanent library usage, the active session's temporary usage,
+// and a per-uploader breakdown so the host can see who is filling the room.
+func GetRoomStorageUsageHandler(c *gin.Context) {
+	userID, room, ok := requireRoomAccess(c)
+	if !ok {
+		return
+	}
+	if room.HostID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the room host can view storage usage"})
+		return
+	}
+
+	used, err := roomStorageUsage(room.ID)
+	if err != nil {
+		log.Printf("GetRoomStorageUsageHandler: Failed to sum usage for room %d: %v", room.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate storage usage"})
+		return
+	}
+
+	type uploaderUsage struct {
+		UploaderID uint  `json:"uploader_id"`
+		UsedBytes  int64 `json:"used_bytes"`
+		Items      int64 `json:"items"`
+	}
+	var byUploader []uploaderUsage
+	DB.Model(&models.MediaItem{}).Where("room_id = ?", room.ID).
+		Select("uploader_id, COALESCE(SUM(file_size), 0) AS used_bytes, COUNT(*) AS items").
+		Group("uploader_id").Order("used_bytes DESC").Scan(&byUploader)
+
+	quotas := storageQuotas()
+	response := gin.H{
+		"room_id":     room.ID,
+		"used_bytes":  used,
+		"limit_bytes": quotas.RoomBytes,
+		"by_uploader": byUploader,
+		"quotas":      quotas,
+	}
+
+	var session models.WatchSession
+	if err := DB.Where("room_id = ? AND ended_at IS NULL", room.ID).Order("started_at DESC").First(&session).Error; err == nil {
+		tempUsed, _ := sessionTempStorageUsage(session.SessionID)
+		response["session"] = gin.H{
+			"session_id":  session.SessionID,
+			"used_bytes":  tempUsed,
+			"limit_bytes": quotas.SessionTempBytes,
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
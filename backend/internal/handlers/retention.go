@@ -0,0 +1,414 @@
+// WeWatch/backend/internal/handlers/retention.go
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"wewatch-backend/internal/models"
+)
+
+// Per-room retention. Room.ChatRetention decides what happens to a session's chat when the
+// session ends: "delete" (the default) deletes it right away, "keep" keeps it and
+// "archive" keeps it read-only. ChatRetentionDays purges kept chat that long after the
+// session ended, RoomMessageMaxAgeDays ages out room messages and TempMediaMaxHours
+// deletes temporary uploads before their session ends. The retention purge job enforces
+// the limits every RETENTION_PURGE_INTERVAL and writes a RetentionPurge per room and kind.
+const (
+	defaultRetentionPurgeEvery = time.Hour
+	retentionPurgeBatch        = 5000 // rows per kind and run; the rest go next run
+)
+
+// retentionPurgeEvery returns how often the purge job runs (0 disables it).
+func retentionPurgeEvery() time.Duration {
+	if v := os.Getenv("RETENTION_PURGE_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			return d
+		}
+		log.Printf("⚠️ Invalid RETENTION_PURGE_INTERVAL=%q, using default %s", v, defaultRetentionPurgeEvery)
+	}
+	return defaultRetentionPurgeEvery
+}
+
+// sessionChatArchived reports whether a session's chat is read-only: the session ended in
+// a room that archives its chat.
+func sessionChatArchived(sessionID string) bool {
+	if sessionID == "" {
+		return false
+	}
+	var count int64
+	DB.Table("watch_sessions AS ws").
+		Joins("JOIN rooms r ON r.id = ws.room_id").
+		Where("ws.session_id = ? AND ws.ended_at IS NOT NULL AND r.chat_retention = ?", sessionID, models.ChatRetentionArchive).
+		Count(&count)
+	return count > 0
+}
+
+// recordPurge writes what a purge deleted from a room.
+func recordPurge(roomID uint, kind string, ids []uint, sessionIDs []string, cutoff time.Time) {
+	purge := models.RetentionPurge{
+		RoomID:     roomID,
+		Kind:       kind,
+		Count:      len(ids),
+		ItemIDs:    ids,
+		SessionIDs: sessionIDs,
+		Cutoff:     cutoff,
+	}
+	if err := DB.Create(&purge).Error; err != nil {
+		log.Printf("⚠️ recordPurge: Failed to record %s purge of room %d: %v", kind, roomID, err)
+	}
+	log.Printf("🧹 Retention: Purged %d %s from room %d", len(ids), kind, roomID)
+}
+
+// purgeSessionChat deletes the chat (and its reactions) of sessions that ended more than
+// ChatRetentionDays ago, and chat left over from ended sessions in rooms that have since
+// switched to "delete".
+func purgeSessionChat(now time.Time) error {
+	var rows []struct {
+		ID        uint
+		RoomID    uint
+		SessionID string
+		Days      int
+	}
+	err := DB.Table("chat_messages AS cm").
+		Select("cm.id, cm.room_id, cm.session_id, r.chat_retention_days AS days").
+		Joins("JOIN watch_sessions ws ON ws.session_id = cm.session_id").
+		Joins("JOIN rooms r ON r.id = cm.room_id").
+		Where("cm.session_id <> '' AND ws.ended_at IS NOT NULL").
+		Where("(r.chat_retention_days > 0 AND ws.ended_at < ? - make_interval(days => r.chat_retention_days)) OR (r.chat_retention = ? AND cm.deleted_at IS NULL)",
+			now, models.ChatRetentionDelete).
+		Order("cm.id").
+		Limit(retentionPurgeBatch).
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	type roomPurge struct {
+		ids      []uint
+		sessions map[string]bool
+		days     int
+	}
+	byRoom := map[uint]*roomPurge{}
+	for _, r := range rows {
+		p, ok := byRoom[r.RoomID]
+		if !ok {
+			p = &roomPurge{sessions: map[string]bool{}, days: r.Days}
+			byRoom[r.RoomID] = p
+		}
+		p.ids = append(p.ids, r.ID)
+		p.sessions[r.SessionID] = true
+	}
+
+	for roomID, p := range byRoom {
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Unscoped().Where("message_id IN ?", p.ids).Delete(&models.Reaction{}).Error; err != nil {
+				return err
+			}
+			return tx.Unscoped().Where("id IN ?", p.ids).Delete(&models.ChatMessage{}).Error
+		})
+		if err != nil {
+			log.Printf("❌ purgeSessionChat: Failed to purge room %d: %v", roomID, err)
+			continue
+		}
+		sessionIDs := make([]string, 0, len(p.sessions))
+		for id := range p.sessions {
+			sessionIDs = append(sessionIDs, id)
+		}
+		recordPurge(roomID, models.RetentionPurgeSessionChat, p.ids, sessionIDs, now.AddDate(0, 0, -p.days))
+	}
+	return nil
+}
+
+// purgeRoomMessages deletes room messages older than their room's RoomMessageMaxAgeDays.
+func purgeRoomMessages(now time.Time) error {
+	var rows []struct {
+		ID     uint
+		RoomID uint
+		Days   int
+	}
+	err := DB.Table("room_messages AS rm").
+		Select("rm.id, rm.room_id, r.room_message_max_age_days AS days").
+		Joins("JOIN rooms r ON r.id = rm.room_id").
+		Where("r.room_message_max_age_days > 0 AND rm.created_at < ? - make_interval(days => r.room_message_max_age_days)", now).
+		Order("rm.id").
+		Limit(retentionPurgeBatch).
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	ids, days := map[uint][]uint{}, map[uint]int{}
+	for _, r := range rows {
+		ids[r.RoomID] = append(ids[r.RoomID], r.ID)
+		days[r.RoomID] = r.Days
+	}
+	for roomID, roomIDs := range ids {
+		if err := DB.Where("id IN ?", roomIDs).Delete(&models.RoomMessage{}).Error; err != nil {
+			log.Printf("❌ purgeRoomMessages: Failed to purge room %d: %v", roomID, err)
+			continue
+		}
+		recordPurge(roomID, models.RetentionPurgeRoomMessages, roomIDs, nil, now.AddDate(0, 0, -days[roomID]))
+	}
+	return nil
+}
+
+// purgeTemporaryMedia deletes temporary uploads older than their room's TempMediaMaxHours,
+// releases their storage and tells the room.
+func purgeTemporaryMedia(ctx context.Context, now time.Time) error {
+	var expired []struct {
+		ID     uint
+		RoomID uint
+		Hours  int
+	}
+	err := DB.Table("temporary_media_items AS t").
+		Select("t.id, t.room_id, r.temp_media_max_hours AS hours").
+		Joins("JOIN rooms r ON r.id = t.room_id").
+		Where("t.deleted_at IS NULL AND r.temp_media_max_hours > 0 AND t.created_at < ? - make_interval(hours => r.temp_media_max_hours)", now).
+		Order("t.id").
+		Limit(retentionPurgeBatch).
+		Scan(&expired).Error
+	if err != nil {
+		return err
+	}
+
+	ids, hours := map[uint][]uint{}, map[uint]int{}
+	for _, e := range expired {
+		ids[e.RoomID] = append(ids[e.RoomID], e.ID)
+		hours[e.RoomID] = e.Hours
+	}
+	for roomID, itemIDs := range ids {
+		// Locked like the session-end cleanup, so a concurrent promotion can't race it
+		var items []models.TemporaryMediaItem
+		var rel mediaRelease
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", itemIDs).Find(&items).Error; err != nil {
+				return err
+			}
+			if len(items) == 0 {
+				return nil
+			}
+			if err := tx.Delete(&items).Error; err != nil {
+				return err
+			}
+			for _, item := range items {
+				if err := releaseMediaTx(tx, item.FilePath, item.PosterURL, item.BlobHash, &rel); err != nil {
+					return fmt.Errorf("release temporary media %d: %w", item.ID, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("❌ purgeTemporaryMedia: Failed to purge room %d: %v", roomID, err)
+			continue
+		}
+		if len(items) == 0 {
+			continue
+		}
+
+		if err := rel.run(ctx); err != nil {
+			log.Printf("⚠️ purgeTemporaryMedia: Failed to delete files of room %d: %v", roomID, err)
+		}
+		deleted := make([]uint, len(items))
+		for i, item := range items {
+			deleted[i] = item.ID
+			if hub != nil {
+				if msg, err := json.Marshal(map[string]interface{}{
+					"type": "temporary_media_item_deleted",
+					"data": map[string]interface{}{
+						"id":         item.ID,
+						"session_id": item.SessionID,
+						"reason":     "expired",
+					},
+				}); err == nil {
+					hub.BroadcastToRoom(roomID, OutgoingMessage{Data: msg, IsBinary: false}, nil)
+				}
+			}
+		}
+		recordPurge(roomID, models.RetentionPurgeTemporaryMedia, deleted, nil, now.Add(-time.Duration(hours[roomID])*time.Hour))
+	}
+	return nil
+}
+
+// PurgeRetention enforces every room's retention settings once.
+func PurgeRetention(ctx context.Context) {
+	now := time.Now()
+	if err := purgeSessionChat(now); err != nil {
+		log.Printf("❌ PurgeRetention: Session chat: %v", err)
+	}
+	if err := purgeRoomMessages(now); err != nil {
+		log.Printf("❌ PurgeRetention: Room messages: %v", err)
+	}
+	if err := purgeTemporaryMedia(ctx, now); err != nil {
+		log.Printf("❌ PurgeRetention: Temporary media: %v", err)
+	}
+}
+
+// startRetentionPurge runs PurgeRetention every RETENTION_PURGE_INTERVAL.
+func startRetentionPurge() {
+	every := retentionPurgeEvery()
+	if every == 0 {
+		log.Println("⏸️ Retention purge disabled (RETENTION_PURGE_INTERVAL=0)")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+		for range ticker.C {
+			PurgeRetention(context.Background())
+		}
+	}()
+	log.Printf("✅ Retention purge started (every %s)", every)
+}
+
+// loadRetentionRoom loads the room of a retention request and checks the user hosts it.
+func loadRetentionRoom(c *gin.Context) (*models.Room, bool) {
+	userID, room, ok := requireRoomAccess(c)
+	if !ok {
+		return nil, false
+	}
+	if room.HostID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the room host can manage retention"})
+		return nil, false
+	}
+	return room, true
+}
+
+// retentionSettings is the retention part of a room as the API returns it.
+func retentionSettings(room *models.Room) gin.H {
+	mode := room.ChatRetention
+	if mode == "" {
+		mode = models.ChatRetentionDelete
+	}
+	return gin.H{
+		"room_id":                   room.ID,
+		"chat_retention":            mode,
+		"chat_retention_days":       room.ChatRetentionDays,
+		"room_message_max_age_days": room.RoomMessageMaxAgeDays,
+		"temp_media_max_hours":      room.TempMediaMaxHours,
+	}
+}
+
+// GetRoomRetentionHandler handles GET /api/rooms/:id/retention
+func GetRoomRetentionHandler(c *gin.Context) {
+	room, ok := loadRetentionRoom(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, retentionSettings(room))
+}
+
+// UpdateRoomRetentionHandler handles PUT /api/rooms/:id/retention
+// Any of chat_retention, chat_retention_days, room_message_max_age_days and
+// temp_media_max_hours; omitted fields keep their value.
+func UpdateRoomRetentionHandler(c *gin.Context) {
+	room, ok := loadRetentionRoom(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		ChatRetention         *string `json:"chat_retention"`
+		ChatRetentionDays     *int    `json:"chat_retention_days"`
+		RoomMessageMaxAgeDays *int    `json:"room_message_max_age_days"`
+		TempMediaMaxHours     *int    `json:"temp_media_max_hours"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid retention data"})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if input.ChatRetention != nil {
+		switch *input.ChatRetention {
+		case models.ChatRetentionDelete, models.ChatRetentionKeep, models.ChatRetentionArchive:
+			updates["chat_retention"] = *input.ChatRetention
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "chat_retention must be delete, keep or archive"})
+			return
+		}
+	}
+	for field, value := range map[string]*int{
+		"chat_retention_days":       input.ChatRetentionDays,
+		"room_message_max_age_days": input.RoomMessageMaxAgeDays,
+		"temp_media_max_hours":      input.TempMediaMaxHours,
+	} {
+		if value == nil {
+			continue
+		}
+		if *value < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": field + " must be 0 or more"})
+			return
+		}
+		updates[field] = *value
+	}
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to update"})
+		return
+	}
+
+	if err := DB.Model(room).Updates(updates).Error; err != nil {
+		log.Printf("UpdateRoomRetentionHandler: Failed to update room %d: %v", room.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update retention"})
+		return
+	}
+	c.JSON(http.StatusOK, retentionSettings(room))
+}
+
+// GetRoomRetentionPurgesHandler handles GET /api/rooms/:id/retention/purges
+// Returns what the purge job deleted from the room, newest first, paginated.
+func GetRoomRetentionPurgesHandler(c *gin.Context) {
+	room, ok := loadRetentionRoom(c)
+	if !ok {
+		return
+	}
+
+	query := DB.Model(&models.RetentionPurge{}).Where("room_id = ?", room.ID)
+	if kind := c.Query("kind"); kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load purges"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultLibraryPage)))
+	if pageSize < 1 {
+		pageSize = defaultLibraryPage
+	}
+	if pageSize > maxLibraryPage {
+		pageSize = maxLibraryPage
+	}
+
+	var purges []models.RetentionPurge
+	if err := query.Order("created_at DESC, id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&purges).Error; err != nil {
+		log.Printf("GetRoomRetentionPurgesHandler: Query failed for room %d: %v", room.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load purges"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"purges":      purges,
+		"count":       len(purges),
+		"total":       total,
+		"page":        page,
+		"page_size":   pageSize,
+		"total_pages": int(math.Ceil(float64(total) / float64(pageSize))),
+		"room_id":     room.ID,
+	})
+}
@@ -26,6 +26,7 @@ func CreateWatchSessionWithType(roomID uint, hostID uint, watchType string) (*mo
 		HostID:    hostID,
 		WatchType: watchType,
 		StartedAt: time.Now(),
+		State:     models.SessionStateCreated,
 	}
 
 	if err := DB.Create(&session).Error; err != nil {
@@ -329,3 +329,14 @@ func CheckUserRoomAccessHandler(c *gin.Context) {
 		"reason":     "private_room_no_invite",
 	})
 }
+
+// userHasRoomAccess applies the same rules as CheckUserRoomAccessHandler:
+// public rooms are open to everyone, private rooms to the host and members.
+func userHasRoomAccess(userID uint, room *models.Room) bool {
+	if room.IsPublic || room.HostID == userID {
+		return true
+	}
+	var count int64
+	DB.Model(&models.UserRoom{}).Where("user_id = ? AND room_id = ?", userID, room.ID).Count(&count)
+	return count > 0
+}
@@ -138,7 +138,9 @@ func CreateRoomTVContent(c *gin.Context) {
 	}
 	if msgBytes, err := json.Marshal(broadcastMsg); err == nil {
 		hub.BroadcastToRoom(uint(roomID), OutgoingMessage{Data: msgBytes, IsBinary: false}, nil)
+		broadcastToLobby(uint(roomID), msgBytes) // the waitlist still watches RoomTV
 	}
+	recordTimelineTVContent(&content, userID.(uint), "created")
 
 	c.JSON(http.StatusCreated, content)
 }
@@ -192,7 +194,9 @@ func DeleteRoomTVContent(c *gin.Context) {
 	}
 	if msgBytes, err := json.Marshal(broadcastMsg); err == nil {
 		hub.BroadcastToRoom(uint(roomID), OutgoingMessage{Data: msgBytes, IsBinary: false}, nil)
+		broadcastToLobby(uint(roomID), msgBytes) // the waitlist still watches RoomTV
 	}
+	recordTimelineTVContent(&models.RoomTVContent{ID: uint(contentID), RoomID: uint(roomID)}, userID.(uint), "removed")
 
 	c.JSON(http.StatusOK, gin.H{"message": "TV content dismissed"})
 }
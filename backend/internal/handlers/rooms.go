@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -183,7 +185,7 @@ func UpdateRoomHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, room)
 }
 
-// Handle Ending the watch session
+// EndWatchSessionHandler handles POST /api/rooms/watch-sessions/:session_id/end (room host only).
 func EndWatchSessionHandler(c *gin.Context) {
 	// ✅ FIX: Use "session_id" to match route :session_id
 	sessionID := c.Param("session_id")
@@ -223,486 +225,19 @@ func EndWatchSessionHandler(c *gin.Context) {
 	}
 
 	log.Printf("✅ EndWatchSessionHandler: User %d (room host) ending session %s", userID, sessionID)
-	
-	// ✅ Clear host disconnect timer if host manually ends session
-	if hub != nil {
-		hub.hostDisconnectMutex.Lock()
-		if _, exists := hub.hostDisconnectTimes[sessionID]; exists {
-			delete(hub.hostDisconnectTimes, sessionID)
-			log.Printf("✅ Cleared host disconnect timer for session %s (manually ended by host)", sessionID)
-		}
-		hub.hostDisconnectMutex.Unlock()
-	}
-
-	// ✅ Check if this is an instant watch (temporary room) - reuse room variable from above
-	isInstantWatch := room.IsTemporary
-
-	// 🔁 Use transaction for data consistency
-	tx := DB.Begin()
-	if tx.Error != nil {
-		log.Printf("EndWatchSessionHandler: Failed to start transaction: %v", tx.Error)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to end session"})
-		return
-	}
-
-	// Declare now for use in marking members as inactive
-	now := time.Now()
-
-	// ✅ For regular rooms: Mark session as ended
-	// ✅ For instant watch: We'll delete the session later (skip marking as ended)
-	if !isInstantWatch {
-		session.EndedAt = &now
-		if err := tx.Save(&session).Error; err != nil {
-			tx.Rollback()
-			log.Printf("EndWatchSessionHandler: Failed to update session: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to end session"})
+	if _, err := EndSession(c.Request.Context(), sessionID, models.SessionEndHost, userID); err != nil {
+		if errors.Is(err, ErrSessionEnded) {
+			c.JSON(http.StatusOK, gin.H{"message": "Session already ended"})
 			return
 		}
-		log.Printf("✅ Marked regular room session %s as ended", sessionID)
-	} else {
-		log.Printf("🔄 Instant watch session %s will be fully deleted", sessionID)
-	}
-
-	// Delete temporary media files and records
-	var tempItems []models.TemporaryMediaItem
-	if err := tx.Where("session_id = ?", sessionID).Find(&tempItems).Error; err != nil {
-		tx.Rollback()
-		log.Printf("EndWatchSessionHandler: Failed to fetch temp media: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clean up media"})
-		return
-	}
-
-	log.Printf("🗑️ EndWatchSessionHandler: Found %d temporary media items to delete for session %s", len(tempItems), sessionID)
-	for _, item := range tempItems {
-		log.Printf("🗑️ Deleting temporary media: ID=%d, File=%s, SessionID=%s", item.ID, item.FileName, item.SessionID)
-		if err := os.Remove(item.FilePath); err != nil && !os.IsNotExist(err) {
-			log.Printf("⚠️ EndWatchSessionHandler: Failed to delete file %s: %v", item.FilePath, err)
-		} else {
-			log.Printf("✅ Deleted file: %s", item.FilePath)
-		}
-		if err := tx.Delete(&item).Error; err != nil {
-			log.Printf("⚠️ EndWatchSessionHandler: Failed to delete DB record for %s: %v", item.FilePath, err)
-			// Continue cleanup even if one delete fails
-		} else {
-			log.Printf("✅ Deleted DB record: ID=%d", item.ID)
-		}
-	}
-
-	// ✅ Mark all session members as inactive
-	result := tx.Model(&models.WatchSessionMember{}).
-		Where("watch_session_id = ? AND is_active = ?", session.ID, true).
-		Updates(map[string]interface{}{
-			"is_active": false,
-			"left_at":   now,
-		})
-	if result.Error != nil {
-		log.Printf("⚠️ EndWatchSessionHandler: Failed to mark members as inactive: %v", result.Error)
-	} else {
-		log.Printf("✅ EndWatchSessionHandler: Marked %d members as inactive for session %s", result.RowsAffected, sessionID)
-	}
-
-	// ✅ Delete session chat messages and reactions
-	var chatMessages []models.ChatMessage
-	if err := tx.Where("session_id = ?", sessionID).Find(&chatMessages).Error; err != nil {
-		log.Printf("⚠️ EndWatchSessionHandler: Failed to fetch chat messages: %v", err)
-	} else {
-		log.Printf("🗑️ EndWatchSessionHandler: Found %d chat messages to delete for session %s", len(chatMessages), sessionID)
-		
-		// Delete reactions for each message
-		messageIDs := make([]uint, len(chatMessages))
-		for i, msg := range chatMessages {
-			messageIDs[i] = msg.ID
-		}
-		
-		if len(messageIDs) > 0 {
-			var reactions []models.Reaction
-			if err := tx.Where("message_id IN ?", messageIDs).Find(&reactions).Error; err != nil {
-				log.Printf("⚠️ EndWatchSessionHandler: Failed to fetch reactions: %v", err)
-			} else {
-				log.Printf("🗑️ EndWatchSessionHandler: Found %d reactions to delete", len(reactions))
-				if err := tx.Where("message_id IN ?", messageIDs).Delete(&models.Reaction{}).Error; err != nil {
-					log.Printf("⚠️ EndWatchSessionHandler: Failed to delete reactions: %v", err)
-				} else {
-					log.Printf("✅ Deleted %d reactions", len(reactions))
-				}
-			}
-		}
-		
-		// Delete chat messages
-		if err := tx.Where("session_id = ?", sessionID).Delete(&models.ChatMessage{}).Error; err != nil {
-			log.Printf("⚠️ EndWatchSessionHandler: Failed to delete chat messages: %v", err)
-		} else {
-			log.Printf("✅ Deleted %d chat messages", len(chatMessages))
-		}
-	}
-
-	// ✅ Delete room if it's temporary (instant watch)
-	if isInstantWatch {
-		// Delete all related data for temporary room
-		// Delete UserRoom memberships
-		if err := tx.Where("room_id = ?", room.ID).Delete(&models.UserRoom{}).Error; err != nil {
-			log.Printf("⚠️ Failed to delete UserRoom memberships for room %d: %v", room.ID, err)
-		}
-		
-		// Delete room invitations (if any)
-		if err := tx.Where("room_id = ?", room.ID).Delete(&models.RoomInvitation{}).Error; err != nil {
-			log.Printf("⚠️ Failed to delete room invitations for room %d: %v", room.ID, err)
-		}
-		
-		// Delete media items
-		if err := tx.Where("room_id = ?", room.ID).Delete(&models.MediaItem{}).Error; err != nil {
-			log.Printf("⚠️ Failed to delete media items for room %d: %v", room.ID, err)
-		}
-		
-		// Delete scheduled events
-		if err := tx.Where("room_id = ?", room.ID).Delete(&models.ScheduledEvent{}).Error; err != nil {
-			log.Printf("⚠️ Failed to delete scheduled events for room %d: %v", room.ID, err)
-		}
-		
-		// Delete RoomTV content
-		if err := tx.Where("room_id = ?", room.ID).Delete(&models.RoomTVContent{}).Error; err != nil {
-			log.Printf("⚠️ Failed to delete RoomTV content for room %d: %v", room.ID, err)
-		}
-		
-		// ✅ DELETE THE SESSION ITSELF for instant watch (before deleting room)
-		if err := tx.Delete(&models.WatchSession{}, session.ID).Error; err != nil {
-			log.Printf("⚠️ Failed to delete session %s: %v", sessionID, err)
-		} else {
-			log.Printf("🗑️ Deleted instant watch session: %s", sessionID)
-		}
-		
-		// Finally, delete the room itself
-		if err := tx.Delete(&models.Room{}, room.ID).Error; err != nil {
-			log.Printf("⚠️ EndWatchSessionHandler: Failed to delete temporary room %d: %v", room.ID, err)
-			// Still commit — session cleanup is more important
-		} else {
-			log.Printf("🗑️ Deleted temporary room %d and all related data after session end", room.ID)
-		}
-	}
-	if err := tx.Commit().Error; err != nil {
-		log.Printf("EndWatchSessionHandler: Transaction commit failed: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Session ended but cleanup incomplete"})
+		log.Printf("EndWatchSessionHandler: Failed to end session %s: %v", sessionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to end session"})
 		return
 	}
 
-	// ✅ DELETE LIVEKIT ROOM (after successful DB commit)
-	livekitRoomName := fmt.Sprintf("room-%d", session.RoomID)
-	if err := utils.DeleteLiveKitRoom(livekitRoomName); err != nil {
-		log.Printf("⚠️ EndWatchSessionHandler: Failed to delete LiveKit room %s: %v", livekitRoomName, err)
-		// Don't fail the entire operation - session is already ended in DB
-	}
-
-	// ✅ BROADCAST SESSION_ENDED TO ALL PARTICIPANTS
-	broadcastMsg := OutgoingMessage{
-		Data:     []byte(fmt.Sprintf(`{"type":"session_ended","data":{"session_id":"%s","room_id":%d}}`, sessionID, session.RoomID)),
-		IsBinary: false,
-	}
-	hub.BroadcastToRoom(session.RoomID, broadcastMsg, nil)
-	log.Printf("📡 Broadcast session_ended to room %d", session.RoomID)
-
-	// ✅ DISCONNECT ALL WEBSOCKET CLIENTS IN THIS ROOM
-	// Give clients a moment to receive the session_ended message before disconnecting
-	time.Sleep(500 * time.Millisecond)
-	hub.DisconnectRoomClients(session.RoomID)
-
-	log.Printf("✅ Session %s ended successfully by host %d", sessionID, userID)
 	c.JSON(http.StatusOK, gin.H{"message": "Session ended"})
 }
 
-// ✅ AutoEndSession ends a session automatically (e.g., when host is gone > 10 minutes)
-// This is the internal version without HTTP context
-func AutoEndSession(sessionID string) error {
-	log.Printf("🤖 AutoEndSession called for session %s", sessionID)
-	
-	// Fetch session
-	var session models.WatchSession
-	if err := DB.Where("session_id = ?", sessionID).First(&session).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			log.Printf("AutoEndSession: Session %s not found (may have been manually ended)", sessionID)
-			return nil // Not an error - session already gone
-		}
-		return fmt.Errorf("database error: %v", err)
-	}
-	
-	// Check if already ended
-	if session.EndedAt != nil {
-		log.Printf("AutoEndSession: Session %s already ended at %v", sessionID, session.EndedAt)
-		return nil
-	}
-	
-	log.Printf("✅ AutoEndSession: Auto-ending session %s (room %d)", sessionID, session.RoomID)
-	
-	// ✅ Check if this is an instant watch (temporary room) FIRST
-	var room models.Room
-	if err := DB.First(&room, session.RoomID).Error; err != nil {
-		log.Printf("AutoEndSession: Failed to fetch room %d: %v", session.RoomID, err)
-		return fmt.Errorf("failed to fetch room: %v", err)
-	}
-	
-	isInstantWatch := room.IsTemporary
-	
-	// 🔁 Use transaction for data consistency
-	tx := DB.Begin()
-	if tx.Error != nil {
-		return fmt.Errorf("failed to start transaction: %v", tx.Error)
-	}
-	
-	// Declare now for use in marking members as inactive
-	now := time.Now()
-
-	// ✅ For regular rooms: Mark session as ended
-	// ✅ For instant watch: We'll delete the session later (skip marking as ended)
-	if !isInstantWatch {
-		session.EndedAt = &now
-		if err := tx.Save(&session).Error; err != nil {
-			tx.Rollback()
-			return fmt.Errorf("failed to update session: %v", err)
-		}
-		log.Printf("⏰ Marked regular session %s as ended", sessionID)
-	} else {
-		log.Printf("🗑️ Instant watch session %s - will be deleted entirely", sessionID)
-	}
-	
-	// ✅ Delete temporary media files and records
-	var tempItems []models.TemporaryMediaItem
-	if err := tx.Where("session_id = ?", sessionID).Find(&tempItems).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to fetch temp media: %v", err)
-	}
-	
-	log.Printf("🗑️ AutoEndSession: Found %d temporary media items to delete for session %s", len(tempItems), sessionID)
-	for _, item := range tempItems {
-		log.Printf("🗑️ Deleting temporary media: ID=%d, File=%s, SessionID=%s", item.ID, item.FileName, item.SessionID)
-		if err := os.Remove(item.FilePath); err != nil && !os.IsNotExist(err) {
-			log.Printf("⚠️ AutoEndSession: Failed to delete file %s: %v", item.FilePath, err)
-		} else {
-			log.Printf("✅ Deleted file: %s", item.FilePath)
-		}
-		if err := tx.Delete(&item).Error; err != nil {
-			log.Printf("⚠️ AutoEndSession: Failed to delete DB record for %s: %v", item.FilePath, err)
-		} else {
-			log.Printf("✅ Deleted DB record: ID=%d", item.ID)
-		}
-	}
-	
-	// ✅ Mark all session members as inactive
-	result := tx.Model(&models.WatchSessionMember{}).
-		Where("watch_session_id = ? AND is_active = ?", session.ID, true).
-		Updates(map[string]interface{}{
-			"is_active": false,
-			"left_at":   now,
-		})
-	if result.Error != nil {
-		log.Printf("⚠️ AutoEndSession: Failed to mark members as inactive: %v", result.Error)
-	} else {
-		log.Printf("✅ AutoEndSession: Marked %d members as inactive for session %s", result.RowsAffected, sessionID)
-	}
-	
-	// ✅ Delete session chat messages and reactions
-	var chatMessages []models.ChatMessage
-	if err := tx.Where("session_id = ?", sessionID).Find(&chatMessages).Error; err != nil {
-		log.Printf("⚠️ AutoEndSession: Failed to fetch chat messages: %v", err)
-	} else {
-		log.Printf("🗑️ AutoEndSession: Found %d chat messages to delete for session %s", len(chatMessages), sessionID)
-		
-		messageIDs := make([]uint, len(chatMessages))
-		for i, msg := range chatMessages {
-			messageIDs[i] = msg.ID
-		}
-		
-		if len(messageIDs) > 0 {
-			var reactions []models.Reaction
-			if err := tx.Where("message_id IN ?", messageIDs).Find(&reactions).Error; err != nil {
-				log.Printf("⚠️ AutoEndSession: Failed to fetch reactions: %v", err)
-			} else {
-				log.Printf("🗑️ AutoEndSession: Found %d reactions to delete", len(reactions))
-				if err := tx.Where("message_id IN ?", messageIDs).Delete(&models.Reaction{}).Error; err != nil {
-					log.Printf("⚠️ AutoEndSession: Failed to delete reactions: %v", err)
-				} else {
-					log.Printf("✅ Deleted %d reactions", len(reactions))
-				}
-			}
-		}
-		
-		if err := tx.Where("session_id = ?", sessionID).Delete(&models.ChatMessage{}).Error; err != nil {
-			log.Printf("⚠️ AutoEndSession: Failed to delete chat messages: %v", err)
-		} else {
-			log.Printf("✅ Deleted %d chat messages", len(chatMessages))
-		}
-	}
-	
-	// ✅ Delete room if it's temporary (instant watch)
-	if isInstantWatch {
-		// Delete all related data for temporary room
-		// Delete UserRoom memberships
-		if err := tx.Where("room_id = ?", room.ID).Delete(&models.UserRoom{}).Error; err != nil {
-			log.Printf("⚠️ AutoEndSession: Failed to delete UserRoom memberships for room %d: %v", room.ID, err)
-		}
-		
-		// Delete room invitations (if any)
-		if err := tx.Where("room_id = ?", room.ID).Delete(&models.RoomInvitation{}).Error; err != nil {
-			log.Printf("⚠️ AutoEndSession: Failed to delete room invitations for room %d: %v", room.ID, err)
-		}
-		
-		// Delete media items
-		if err := tx.Where("room_id = ?", room.ID).Delete(&models.MediaItem{}).Error; err != nil {
-			log.Printf("⚠️ AutoEndSession: Failed to delete media items for room %d: %v", room.ID, err)
-		}
-		
-		// Delete scheduled events
-		if err := tx.Where("room_id = ?", room.ID).Delete(&models.ScheduledEvent{}).Error; err != nil {
-			log.Printf("⚠️ AutoEndSession: Failed to delete scheduled events for room %d: %v", room.ID, err)
-		}
-		
-		// Delete RoomTV content
-		if err := tx.Where("room_id = ?", room.ID).Delete(&models.RoomTVContent{}).Error; err != nil {
-			log.Printf("⚠️ AutoEndSession: Failed to delete RoomTV content for room %d: %v", room.ID, err)
-		}
-		
-		// ✅ DELETE THE SESSION ITSELF for instant watch (before deleting room)
-		if err := tx.Delete(&models.WatchSession{}, session.ID).Error; err != nil {
-			log.Printf("⚠️ AutoEndSession: Failed to delete session %s: %v", sessionID, err)
-		} else {
-			log.Printf("🗑️ Deleted instant watch session: %s", sessionID)
-		}
-		
-		// Finally, delete the room itself
-		if err := tx.Delete(&models.Room{}, room.ID).Error; err != nil {
-			log.Printf("⚠️ AutoEndSession: Failed to delete temporary room %d: %v", room.ID, err)
-		} else {
-			log.Printf("🗑️ Deleted temporary room %d and all related data after session auto-end", room.ID)
-		}
-	}
-	
-	if err := tx.Commit().Error; err != nil {
-		return fmt.Errorf("transaction commit failed: %v", err)
-	}
-	
-	// ✅ DELETE LIVEKIT ROOM (after successful DB commit)
-	livekitRoomName := fmt.Sprintf("room-%d", session.RoomID)
-	if err := utils.DeleteLiveKitRoom(livekitRoomName); err != nil {
-		log.Printf("⚠️ AutoEndSession: Failed to delete LiveKit room %s: %v", livekitRoomName, err)
-	}
-	
-	// ✅ BROADCAST SESSION_ENDED TO ALL PARTICIPANTS
-	if hub != nil {
-		broadcastMsg := OutgoingMessage{
-			Data:     []byte(fmt.Sprintf(`{"type":"session_ended","data":{"session_id":"%s","room_id":%d,"reason":"host_timeout"}}`, sessionID, session.RoomID)),
-			IsBinary: false,
-		}
-		hub.BroadcastToRoom(session.RoomID, broadcastMsg, nil)
-		log.Printf("📡 Broadcast session_ended (host timeout) to room %d", session.RoomID)
-		
-		// Disconnect all WebSocket clients
-		time.Sleep(500 * time.Millisecond)
-		hub.DisconnectRoomClients(session.RoomID)
-	}
-	
-	log.Printf("✅ Session %s auto-ended successfully after host timeout", sessionID)
-	return nil
-}
-
-// Cleanup Session
-// Cleanup Session (called on host disconnect)
-func cleanupSession(sessionID string, roomID uint) {
-	if sessionID == "" {
-		log.Println("cleanupSession: Called with empty sessionID — skipping")
-		return
-	}
-
-	log.Printf("🧹 Starting cleanup for session: %s", sessionID)
-
-    // 🔒 Only clean up if room is temporary
-    var room models.Room
-    if DB.First(&room, roomID).Error != nil || !room.IsTemporary {
-        log.Printf("cleanupSession: Skipping cleanup for non-temporary room %d", roomID)
-        return
-    }
-
-    // ✅ ONLY clean up temporary rooms
-    if !room.IsTemporary {
-        log.Printf("cleanupSession: Skipping cleanup for REGULAR room %d (session %s) — not temporary", roomID, sessionID)
-        return
-    }
-
-    log.Printf("🧹 CLEANING UP TEMPORARY session: %s (room %d)", sessionID, roomID)
-
-
-	tx := DB.Begin()
-	if tx.Error != nil {
-		log.Printf("cleanupSession: Failed to start transaction: %v", tx.Error)
-		return
-	}
-
-	// Delete temporary media
-	var tempItems []models.TemporaryMediaItem
-	tx.Where("session_id = ?", sessionID).Find(&tempItems)
-	for _, item := range tempItems {
-		if err := os.Remove(item.FilePath); err != nil && !os.IsNotExist(err) {
-			log.Printf("⚠️ cleanupSession: Failed to delete temp file: %s", item.FilePath)
-		}
-		tx.Delete(&item)
-	}
-
-	// Mark session as ended
-	var session models.WatchSession
-	if tx.Where("session_id = ?", sessionID).First(&session).Error == nil {
-		now := time.Now()
-		session.EndedAt = &now
-		tx.Save(&session)
-	}
-
-	
-
-	if err := tx.Commit().Error; err != nil {
-		log.Printf("cleanupSession: Transaction commit failed: %v", err)
-	}
-}
-
-// CleanupExpiredSessions removes watch sessions and temp media older than 5 minutes.
-// Reduced grace period for tighter memory management.
-func CleanupExpiredSessions() {
-	var sessions []models.WatchSession
-	cutoff := time.Now().Add(-5 * time.Minute) // ✅ Reduced from 30 to 5 minutes
-
-	DB.Joins("JOIN rooms ON watch_sessions.room_id = rooms.id").
-        Where("watch_sessions.ended_at IS NULL AND watch_sessions.started_at < ? AND rooms.is_temporary = ?", cutoff, true).
-        Find(&sessions)
-
-	for _, s := range sessions {
-		log.Printf("🧹 Cleaning up expired session: %s", s.SessionID)
-
-		tx := DB.Begin()
-		if tx.Error != nil {
-			log.Printf("CleanupExpiredSessions: Failed to start transaction for session %s", s.SessionID)
-			continue
-		}
-		// Delete temp media
-		var items []models.TemporaryMediaItem
-		tx.Where("session_id = ?", s.SessionID).Find(&items)
-		for _, item := range items {
-			if err := os.Remove(item.FilePath); err != nil && !os.IsNotExist(err) {
-				log.Printf("⚠️ CleanupExpiredSessions: Failed to delete file: %s", item.FilePath)
-			}
-			tx.Delete(&item)
-		}
-		// Mark session as ended
-		now := time.Now()
-		s.EndedAt = &now
-		tx.Save(&s)
-
-		// Delete room if temporary
-		var room models.Room
-		if tx.First(&room, s.RoomID).Error == nil && room.IsTemporary {
-			tx.Delete(&models.Room{}, s.RoomID)
-			log.Printf("🗑️ Deleted temporary room %d", s.RoomID)
-		}
-		if err := tx.Commit().Error; err != nil {
-			log.Printf("CleanupExpiredSessions: Failed to commit cleanup for session %s: %v", s.SessionID, err)
-		}
-	}
-}
-
 // GenerateLiveKitTokenHandler returns a LiveKit access token for the room
 func GenerateLiveKitTokenHandler(c *gin.Context) {
 	log.Printf("🎫 [LiveKit] GenerateLiveKitTokenHandler called for room %s", c.Param("id"))
@@ -746,6 +281,20 @@ func GenerateLiveKitTokenHandler(c *gin.Context) {
 	}
 
 	isHost := room.HostID == userID
+
+	// Viewers still knocking at (or turned away from) a session that requires approval
+	// stay out of its call, and so do viewers waiting for a place in a full one
+	var running models.WatchSession
+	if err := DB.Where("room_id = ? AND ended_at IS NULL", room.ID).First(&running).Error; err == nil {
+		if needsJoinApproval(&running, userID) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "join_pending", "message": "The host has not let you into the session"})
+			return
+		}
+		if sessionIsFull(&running, userID) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "waitlisted", "message": "The session is full, wait for a place"})
+			return
+		}
+	}
 	
 	// ✅ Use tab_id from query params to make identity unique per browser tab
 	tabID := c.Query("tab_id")
@@ -757,6 +306,27 @@ func GenerateLiveKitTokenHandler(c *gin.Context) {
 	
 	roomName := "room-" + roomIDStr
 
+	// A breakout group's members (and the hosts) get the group's own LiveKit room
+	if v := c.Query("breakout_group_id"); v != "" {
+		groupID, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid breakout_group_id"})
+			return
+		}
+		var group models.BreakoutGroup
+		var session models.WatchSession
+		if err := DB.Where("id = ? AND room_id = ? AND closed_at IS NULL", groupID, room.ID).First(&group).Error; err != nil ||
+			DB.Where("session_id = ?", group.SessionID).First(&session).Error != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Breakout group not found"})
+			return
+		}
+		if !canJoinBreakoutGroup(&session, &group, userID) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You are not in this breakout group"})
+			return
+		}
+		roomName = group.LiveKitRoom
+	}
+
 	log.Printf("🎬 [LiveKit] Generating token: room=%s, identity=%s, isHost=%v", roomName, identity, isHost)
 
 	token, err := utils.GenerateLiveKitToken(roomName, identity, isHost)
@@ -791,7 +361,10 @@ func CreateInstantWatchHandler(c *gin.Context) {
 
 	// Parse watch_type from request body
 	var input struct {
-		WatchType string `json:"watch_type"` // "video" or "3d_cinema"
+		WatchType        string `json:"watch_type"`        // "video" or "3d_cinema"
+		RequiresApproval bool   `json:"requires_approval"` // new viewers knock and wait for the host
+		MaxViewers       int    `json:"max_viewers"`       // 0 = no limit; more viewers wait on the waitlist
+		MaxTheaters      int    `json:"max_theaters"`      // 3D cinema only, 0 = no limit
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		// Default to "video" if not specified
@@ -849,6 +422,10 @@ func CreateInstantWatchHandler(c *gin.Context) {
 		HostID:    userID,
 		WatchType: input.WatchType,
 		StartedAt: time.Now(),
+		State:     models.SessionStateCreated,
+		RequiresApproval: input.RequiresApproval,
+		MaxViewers:       max(input.MaxViewers, 0),
+		MaxTheaters:      max(input.MaxTheaters, 0),
 	}
 
 	if err := tx.Create(&watchSession).Error; err != nil {
@@ -1160,9 +737,10 @@ func UpdateRoomLoopModeHandler(c *gin.Context) {
         return
     }
     
-    // Parse the loop mode
+    // Parse the loop mode (and/or whether the server advances at the credits)
     var loopData struct {
-        LoopMode string `json:"loop_mode"`
+        LoopMode         string `json:"loop_mode"`
+        AdvanceAtCredits *bool  `json:"advance_at_credits"`
     }
     
     if err := c.ShouldBindJSON(&loopData); err != nil {
@@ -1175,22 +753,39 @@ func UpdateRoomLoopModeHandler(c *gin.Context) {
         "none": true, "playlist-once": true, "playlist-infinite": true,
     }
     
-    if !validModes[loopData.LoopMode] {
+    if loopData.LoopMode == "" && loopData.AdvanceAtCredits == nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid loop mode"})
+        return
+    }
+    if loopData.LoopMode != "" && !validModes[loopData.LoopMode] {
         c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid loop mode"})
         return
     }
     
     // Update room loop mode
-    room.LoopMode = loopData.LoopMode
+    if loopData.LoopMode != "" {
+        room.LoopMode = loopData.LoopMode
+    }
+    if loopData.AdvanceAtCredits != nil {
+        room.AdvanceAtCredits = *loopData.AdvanceAtCredits
+    }
     result = DB.Save(&room)
     if result.Error != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update loop mode"})
         return
     }
     
+    // Re-time (or drop) the pending advance of whatever is playing now
+    if room.AdvanceAtCredits {
+        rearmPlayback(room.ID)
+    } else {
+        forgetPlayback(room.ID)
+    }
+    
     c.JSON(http.StatusOK, gin.H{
         "message": "Loop mode updated successfully",
         "loop_mode": room.LoopMode,
+        "advance_at_credits": room.AdvanceAtCredits,
     })
 }
 
@@ -1246,16 +841,53 @@ func DeleteRoomHandler(c *gin.Context) {
         return
     }
     
+    // End the running session first so its members, temporary media and LiveKit room are
+    // cleaned up like any other session end
+    var activeSessions []models.WatchSession
+    if err := DB.Where("room_id = ? AND ended_at IS NULL", room.ID).Find(&activeSessions).Error; err == nil {
+        for _, session := range activeSessions {
+            if _, err := EndSession(c.Request.Context(), session.SessionID, models.SessionEndRoomDeleted, userID); err != nil && !errors.Is(err, ErrSessionEnded) {
+                log.Printf("DeleteRoomHandler: Failed to end session %s: %v", session.SessionID, err)
+                c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to end the room's watch session"})
+                return
+            }
+        }
+    }
+    
     // Cascade delete all related records in a transaction
+    var mediaItems []models.MediaItem
+    var rel mediaRelease
     err = DB.Transaction(func(tx *gorm.DB) error {
         roomIDUint := uint(roomID)
         
-        // 1. Delete media items
+        // 1. Delete media items, dropping their storage references
+        if err := tx.Where("room_id = ?", roomIDUint).Find(&mediaItems).Error; err != nil {
+            return err
+        }
+        for _, item := range mediaItems {
+            if err := releaseMediaTx(tx, item.FilePath, item.PosterURL, item.BlobHash, &rel); err != nil {
+                return err
+            }
+            if item.MediaKind == models.MediaKindSlideshow {
+                if err := releaseSlidesTx(tx, item.ID, &rel); err != nil {
+                    return err
+                }
+            }
+        }
         if err := tx.Where("room_id = ?", roomIDUint).Delete(&models.MediaItem{}).Error; err != nil {
             return err
         }
         
         // 2. Delete temporary media items
+        var tempItems []models.TemporaryMediaItem
+        if err := tx.Where("room_id = ?", roomIDUint).Find(&tempItems).Error; err != nil {
+            return err
+        }
+        for _, item := range tempItems {
+            if err := releaseMediaTx(tx, item.FilePath, item.PosterURL, item.BlobHash, &rel); err != nil {
+                return err
+            }
+        }
         if err := tx.Where("room_id = ?", roomIDUint).Delete(&models.TemporaryMediaItem{}).Error; err != nil {
             return err
         }
@@ -1311,32 +943,14 @@ func DeleteRoomHandler(c *gin.Context) {
     
     // Queue file deletion in background (non-blocking)
     go func() {
-        roomIDUint := uint(roomID)
-        
-        // Delete uploaded media files
-        var mediaItems []models.MediaItem
-        if err := DB.Unscoped().Where("room_id = ?", roomIDUint).Find(&mediaItems).Error; err == nil {
-            for _, item := range mediaItems {
-                if item.FilePath != "" {
-                    os.Remove(item.FilePath)
-                    log.Printf("Deleted file: %s", item.FilePath)
-                }
-                // Also delete poster if exists
-                if item.PosterURL != "" {
-                    os.Remove(item.PosterURL)
-                    log.Printf("Deleted poster: %s", item.PosterURL)
-                }
-            }
+        // Delete the media files (and posters) no other item shares
+        if err := rel.run(context.Background()); err != nil {
+            log.Printf("DeleteRoomHandler: Failed to delete some files of room %d: %v", roomID, err)
         }
-        
-        // Delete temporary media files
-        var tempItems []models.TemporaryMediaItem
-        if err := DB.Unscoped().Where("room_id = ?", roomIDUint).Find(&tempItems).Error; err == nil {
-            for _, item := range tempItems {
-                if item.FilePath != "" {
-                    os.Remove(item.FilePath)
-                    log.Printf("Deleted temp file: %s", item.FilePath)
-                }
+        for _, item := range mediaItems {
+            if item.BlobHash == "" {
+                // Blob-backed storyboards go with the blob's last reference
+                removeStoryboard(context.Background(), item.StoryboardKey)
             }
         }
         
@@ -1593,7 +1207,10 @@ func CreateWatchSessionForRoomHandler(c *gin.Context) {
 
 	// Parse watch_type from request body
 	var input struct {
-		WatchType string `json:"watch_type"` // "video" or "3d_cinema"
+		WatchType        string `json:"watch_type"`        // "video" or "3d_cinema"
+		RequiresApproval bool   `json:"requires_approval"` // new viewers knock and wait for the host
+		MaxViewers       int    `json:"max_viewers"`       // 0 = no limit; more viewers wait on the waitlist
+		MaxTheaters      int    `json:"max_theaters"`      // 3D cinema only, 0 = no limit
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		// Default to "video" if not specified
@@ -1646,6 +1263,10 @@ func CreateWatchSessionForRoomHandler(c *gin.Context) {
 		HostID:    userID,
 		WatchType: input.WatchType,
 		StartedAt: time.Now(),
+		State:     models.SessionStateCreated,
+		RequiresApproval: input.RequiresApproval,
+		MaxViewers:       max(input.MaxViewers, 0),
+		MaxTheaters:      max(input.MaxTheaters, 0),
 	}
 	if err := DB.Create(&session).Error; err != nil {
 		log.Printf("❌ Failed to create watch session: %v", err)
@@ -1660,6 +1281,9 @@ func CreateWatchSessionForRoomHandler(c *gin.Context) {
 		"is_existing":  false,
 		"started_at":   session.StartedAt,
 		"member_count": 0,
+		"requires_approval": session.RequiresApproval,
+		"max_viewers":       session.MaxViewers,
+		"max_theaters":      session.MaxTheaters,
 	})
 }
 
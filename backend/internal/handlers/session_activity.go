@@ -0,0 +1,170 @@
+// WeWatch/backend/internal/handlers/session_activity.go
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"wewatch-backend/internal/models"
+)
+
+// Idle expiry of instant-watch sessions. Every session keeps a LastActivityAt heartbeat
+// that members' WebSocket messages (playback_report included) bump. A temporary room's
+// session that stays idle for SESSION_IDLE_TIMEOUT is ended; SESSION_IDLE_WARNING before
+// that its members get "session_expiring", so anyone still there can keep it alive.
+const (
+	defaultSessionIdleTimeout = 15 * time.Minute
+	defaultSessionIdleWarning = 2 * time.Minute
+	sessionActivityWriteEvery = 30 * time.Second // heartbeat writes are throttled per session
+	sessionExpiryCheckEvery   = 30 * time.Second
+)
+
+var (
+	activityMu      sync.Mutex
+	activityWritten = map[string]time.Time{} // session_id → last heartbeat write
+)
+
+// sessionIdleLimits returns the idle timeout and how long before it members are warned.
+func sessionIdleLimits() (timeout, warning time.Duration) {
+	timeout, warning = defaultSessionIdleTimeout, defaultSessionIdleWarning
+	if v := os.Getenv("SESSION_IDLE_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			timeout = d
+		} else {
+			log.Printf("⚠️ Invalid SESSION_IDLE_TIMEOUT=%q, using default %s", v, defaultSessionIdleTimeout)
+		}
+	}
+	if v := os.Getenv("SESSION_IDLE_WARNING"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			warning = d
+		} else {
+			log.Printf("⚠️ Invalid SESSION_IDLE_WARNING=%q, using default %s", v, defaultSessionIdleWarning)
+		}
+	}
+	if warning >= timeout {
+		warning = timeout / 2
+	}
+	return timeout, warning
+}
+
+// touchSession records activity in a session. A pending expiry warning is withdrawn and
+// the room told so with "session_expiry_cancelled".
+func touchSession(sessionID string) {
+	if sessionID == "" {
+		return
+	}
+	now := time.Now()
+	activityMu.Lock()
+	if last, ok := activityWritten[sessionID]; ok && now.Sub(last) < sessionActivityWriteEvery {
+		activityMu.Unlock()
+		return
+	}
+	activityWritten[sessionID] = now
+	activityMu.Unlock()
+
+	var session models.WatchSession
+	if err := DB.Where("session_id = ? AND ended_at IS NULL AND state <> ?", sessionID, models.SessionStateEnding).First(&session).Error; err != nil {
+		return
+	}
+	updates := map[string]interface{}{"last_activity_at": now}
+	if session.ExpiryWarnedAt != nil {
+		updates["expiry_warned_at"] = nil
+	}
+	if err := DB.Model(&session).Updates(updates).Error; err != nil {
+		log.Printf("⚠️ touchSession: Failed to record activity in session %s: %v", sessionID, err)
+		return
+	}
+	if session.ExpiryWarnedAt != nil {
+		log.Printf("⏱️ touchSession: Session %s is active again, expiry cancelled", sessionID)
+		broadcastSessionExpiry(&session, "session_expiry_cancelled", nil)
+	}
+}
+
+// forgetSessionActivity drops the heartbeat throttle entry of an ended session.
+func forgetSessionActivity(sessionID string) {
+	activityMu.Lock()
+	delete(activityWritten, sessionID)
+	activityMu.Unlock()
+}
+
+// broadcastSessionExpiry sends a session_expiring / session_expiry_cancelled event.
+func broadcastSessionExpiry(session *models.WatchSession, eventType string, expiresAt *time.Time) {
+	if hub == nil {
+		return
+	}
+	data := map[string]interface{}{
+		"session_id":       session.SessionID,
+		"room_id":          session.RoomID,
+		"last_activity_at": session.LastActivityAt,
+	}
+	if expiresAt != nil {
+		data["expires_at"] = *expiresAt
+		data["seconds_left"] = int(time.Until(*expiresAt).Seconds())
+	}
+	if msg, err := json.Marshal(map[string]interface{}{"type": eventType, "data": data}); err == nil {
+		hub.BroadcastToRoom(session.RoomID, OutgoingMessage{Data: msg, IsBinary: false}, nil)
+	}
+}
+
+// idleTemporarySessions starts a query for running instant-watch sessions idle since
+// before idleSince.
+func idleTemporarySessions(idleSince time.Time) *gorm.DB {
+	return DB.Joins("JOIN rooms ON watch_sessions.room_id = rooms.id").
+		Where("watch_sessions.ended_at IS NULL AND watch_sessions.state <> ? AND rooms.is_temporary = ?", models.SessionStateEnding, true).
+		Where("watch_sessions.last_activity_at < ?", idleSince)
+}
+
+// CleanupExpiredSessions warns the members of instant-watch sessions that are about to go
+// idle and ends the ones that stayed idle through the warning. Their temporary rooms go
+// with them.
+func CleanupExpiredSessions() {
+	timeout, warning := sessionIdleLimits()
+	now := time.Now()
+
+	var expiring []models.WatchSession
+	if err := idleTemporarySessions(now.Add(-(timeout - warning))).
+		Where("watch_sessions.expiry_warned_at IS NULL").
+		Find(&expiring).Error; err != nil {
+		log.Printf("❌ CleanupExpiredSessions: Failed to query idle sessions: %v", err)
+		return
+	}
+	for i := range expiring {
+		session := &expiring[i]
+		res := DB.Model(&models.WatchSession{}).
+			Where("id = ? AND expiry_warned_at IS NULL", session.ID).
+			Update("expiry_warned_at", now)
+		if res.Error != nil || res.RowsAffected == 0 {
+			continue
+		}
+		expiresAt := now.Add(warning)
+		log.Printf("⏳ CleanupExpiredSessions: Session %s idle since %s, ending at %s unless someone is active", session.SessionID, session.LastActivityAt.Format(time.RFC3339), expiresAt.Format(time.RFC3339))
+		broadcastSessionExpiry(session, "session_expiring", &expiresAt)
+	}
+
+	var expired []models.WatchSession
+	if err := idleTemporarySessions(now.Add(-timeout)).
+		Where("watch_sessions.expiry_warned_at IS NOT NULL AND watch_sessions.expiry_warned_at <= ?", now.Add(-warning)).
+		Find(&expired).Error; err != nil {
+		log.Printf("❌ CleanupExpiredSessions: Failed to query expired sessions: %v", err)
+		return
+	}
+	endSessions(expired, models.SessionEndExpired, "CleanupExpiredSessions")
+}
+
+// startSessionExpiry runs CleanupExpiredSessions often enough for the warning to go out on
+// time.
+func startSessionExpiry() {
+	go func() {
+		ticker := time.NewTicker(sessionExpiryCheckEvery)
+		defer ticker.Stop()
+		for range ticker.C {
+			CleanupExpiredSessions()
+		}
+	}()
+	timeout, warning := sessionIdleLimits()
+	log.Printf("✅ Instant-watch idle expiry started (idle timeout %s, warning %s before)", timeout, warning)
+}
@@ -0,0 +1,571 @@
+// WeWatch/backend/internal/handlers/session_breakouts.go
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"wewatch-backend/internal/models"
+	"wewatch-backend/internal/utils"
+)
+
+// Breakout groups. During an intermission the host splits a session into N groups, each
+// with its own chat (ChatMessage.BreakoutGroupID) and LiveKit room; members stay connected
+// to the room's socket, so the host's broadcasts and the recall reach everyone. Recalling
+// starts a countdown, after which the groups close and everyone is back in the main
+// session. One split is open at a time.
+const (
+	maxBreakoutGroups      = 20
+	defaultRecallCountdown = 60 * time.Second
+	maxRecallCountdown     = 10 * time.Minute
+)
+
+var (
+	breakoutMu      sync.Mutex
+	breakoutRecalls = map[string]*time.Timer{} // session_id → pending recall
+)
+
+// BreakoutGroupEntry is an open breakout group as the endpoints and broadcasts return it.
+type BreakoutGroupEntry struct {
+	models.BreakoutGroup
+	Members []BreakoutMemberEntry `json:"members"`
+}
+
+// BreakoutMemberEntry is one member of a breakout group.
+type BreakoutMemberEntry struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+}
+
+// openBreakoutGroups returns the session's open groups, by number, with their members.
+func openBreakoutGroups(sessionID string) ([]BreakoutGroupEntry, error) {
+	var groups []models.BreakoutGroup
+	if err := DB.Where("session_id = ? AND closed_at IS NULL", sessionID).Order("number ASC").Find(&groups).Error; err != nil {
+		return nil, err
+	}
+	entries := make([]BreakoutGroupEntry, len(groups))
+	if len(groups) == 0 {
+		return entries, nil
+	}
+	ids := make([]uint, len(groups))
+	index := make(map[uint]int, len(groups))
+	for i, g := range groups {
+		ids[i] = g.ID
+		index[g.ID] = i
+		entries[i] = BreakoutGroupEntry{BreakoutGroup: g, Members: []BreakoutMemberEntry{}}
+	}
+
+	var members []struct {
+		GroupID  uint
+		UserID   uint
+		Username string
+	}
+	if err := DB.Table("breakout_group_members AS m").
+		Select("m.group_id, m.user_id, u.username").
+		Joins("LEFT JOIN users u ON u.id = m.user_id").
+		Where("m.group_id IN ?", ids).
+		Order("m.assigned_at ASC, m.id ASC").
+		Scan(&members).Error; err != nil {
+		return nil, err
+	}
+	for _, m := range members {
+		i := index[m.GroupID]
+		entries[i].Members = append(entries[i].Members, BreakoutMemberEntry{UserID: m.UserID, Username: m.Username})
+	}
+	return entries, nil
+}
+
+// openBreakoutGroup loads an open group of the session.
+func openBreakoutGroup(sessionID string, groupID uint) (*models.BreakoutGroup, error) {
+	var group models.BreakoutGroup
+	if err := DB.Where("id = ? AND session_id = ? AND closed_at IS NULL", groupID, sessionID).First(&group).Error; err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// inBreakoutGroup reports whether userID is assigned to the group.
+func inBreakoutGroup(groupID, userID uint) bool {
+	var count int64
+	DB.Model(&models.BreakoutGroupMember{}).Where("group_id = ? AND user_id = ?", groupID, userID).Count(&count)
+	return count > 0
+}
+
+// canJoinBreakoutGroup reports whether userID may use the group's chat and LiveKit room:
+// its members, and the hosts, who can drop in on any group.
+func canJoinBreakoutGroup(session *models.WatchSession, group *models.BreakoutGroup, userID uint) bool {
+	return inBreakoutGroup(group.ID, userID) || canDecideJoin(session, userID)
+}
+
+// assignBreakoutMember moves userID into the group, out of any other open group of the
+// session. groupID 0 sends them back to the main session.
+func assignBreakoutMember(tx *gorm.DB, sessionID string, userID, groupID uint) error {
+	if err := tx.Where("session_id = ? AND user_id = ? AND group_id IN (?)", sessionID, userID,
+		tx.Model(&models.BreakoutGroup{}).Select("id").Where("session_id = ? AND closed_at IS NULL", sessionID)).
+		Delete(&models.BreakoutGroupMember{}).Error; err != nil {
+		return err
+	}
+	if groupID == 0 {
+		return nil
+	}
+	return tx.Create(&models.BreakoutGroupMember{
+		GroupID:    groupID,
+		SessionID:  sessionID,
+		UserID:     userID,
+		AssignedAt: time.Now(),
+	}).Error
+}
+
+// broadcastBreakout sends a breakout message to everyone in the session's room.
+func broadcastBreakout(roomID uint, msgType string, data map[string]interface{}) {
+	if msgBytes, err := json.Marshal(map[string]interface{}{"type": msgType, "data": data}); err == nil {
+		hub.BroadcastToRoom(roomID, OutgoingMessage{Data: msgBytes, IsBinary: false}, nil)
+	}
+}
+
+// closeBreakouts closes the session's open groups and their LiveKit rooms. announce tells
+// the room everyone is back.
+func closeBreakouts(sessionID string, announce bool) {
+	breakoutMu.Lock()
+	if timer, ok := breakoutRecalls[sessionID]; ok {
+		timer.Stop()
+		delete(breakoutRecalls, sessionID)
+	}
+	breakoutMu.Unlock()
+
+	var groups []models.BreakoutGroup
+	if err := DB.Where("session_id = ? AND closed_at IS NULL", sessionID).Find(&groups).Error; err != nil || len(groups) == 0 {
+		return
+	}
+	now := time.Now()
+	if err := DB.Model(&models.BreakoutGroup{}).
+		Where("session_id = ? AND closed_at IS NULL", sessionID).
+		Update("closed_at", now).Error; err != nil {
+		log.Printf("❌ closeBreakouts: Failed to close groups of session %s: %v", sessionID, err)
+		return
+	}
+	for _, g := range groups {
+		if err := utils.DeleteLiveKitRoom(g.LiveKitRoom); err != nil {
+			log.Printf("⚠️ closeBreakouts: Failed to delete LiveKit room %s: %v", g.LiveKitRoom, err)
+		}
+	}
+	log.Printf("🔔 closeBreakouts: Closed %d breakout groups of session %s", len(groups), sessionID)
+
+	if announce {
+		broadcastBreakout(groups[0].RoomID, "breakouts_closed", map[string]interface{}{
+			"session_id": sessionID,
+			"closed_at":  now,
+		})
+	}
+}
+
+// activeSessionUsers returns the users in the session other than its host, in join order.
+func activeSessionUsers(session *models.WatchSession) ([]uint, error) {
+	var rows []struct{ UserID uint }
+	if err := DB.Model(&models.WatchSessionMember{}).
+		Select("user_id").
+		Where("watch_session_id = ? AND is_active = ? AND user_id <> ?", session.ID, true, session.HostID).
+		Group("user_id").
+		Order("MIN(joined_at) ASC").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	userIDs := make([]uint, len(rows))
+	for i, r := range rows {
+		userIDs[i] = r.UserID
+	}
+	return userIDs, nil
+}
+
+// GetBreakoutsHandler handles GET /api/rooms/watch-sessions/:session_id/breakouts
+// Returns the session's open breakout groups with their members, and the pending recall.
+func GetBreakoutsHandler(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	var session models.WatchSession
+	if err := DB.Where("session_id = ? AND ended_at IS NULL", c.Param("session_id")).First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+	var room models.Room
+	if err := DB.First(&room, session.RoomID).Error; err != nil || !userHasRoomAccess(userID.(uint), &room) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have access to this room"})
+		return
+	}
+
+	groups, err := openBreakoutGroups(session.SessionID)
+	if err != nil {
+		log.Printf("GetBreakoutsHandler: Query failed for session %s: %v", session.SessionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load breakout groups"})
+		return
+	}
+	breakoutMu.Lock()
+	_, recalling := breakoutRecalls[session.SessionID]
+	breakoutMu.Unlock()
+	c.JSON(http.StatusOK, gin.H{
+		"session_id": session.SessionID,
+		"groups":     groups,
+		"count":      len(groups),
+		"recalling":  recalling,
+	})
+}
+
+// CreateBreakoutsHandler handles POST /api/rooms/watch-sessions/:session_id/breakouts
+// Splits the session into count groups. assignment "random" (the default) spreads the
+// session's members evenly; "manual" places those listed in assignments (group numbers
+// 1..count) and leaves everyone else in the main session.
+func CreateBreakoutsHandler(c *gin.Context) {
+	session, ok := loadHostedSession(c, "Only the host can start breakout groups")
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Count       int      `json:"count" binding:"required"`
+		Assignment  string   `json:"assignment"` // "random" or "manual"
+		Names       []string `json:"names"`      // optional, by group number
+		Assignments []struct {
+			UserID uint `json:"user_id"`
+			Group  int  `json:"group"`
+		} `json:"assignments"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Count < 1 || input.Count > maxBreakoutGroups {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("count must be between 1 and %d", maxBreakoutGroups)})
+		return
+	}
+	if input.Assignment == "" {
+		input.Assignment = "random"
+	}
+	if input.Assignment != "random" && input.Assignment != "manual" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "assignment must be random or manual"})
+		return
+	}
+
+	var open int64
+	DB.Model(&models.BreakoutGroup{}).Where("session_id = ? AND closed_at IS NULL", session.SessionID).Count(&open)
+	if open > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Breakout groups are already open; recall them first"})
+		return
+	}
+
+	users, err := activeSessionUsers(session)
+	if err != nil {
+		log.Printf("CreateBreakoutsHandler: Failed to load members of session %s: %v", session.SessionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load session members"})
+		return
+	}
+	groupOf := map[uint]int{} // user → group number
+	if input.Assignment == "random" {
+		rand.Shuffle(len(users), func(i, j int) { users[i], users[j] = users[j], users[i] })
+		for i, u := range users {
+			groupOf[u] = i%input.Count + 1
+		}
+	} else {
+		inSession := make(map[uint]bool, len(users))
+		for _, u := range users {
+			inSession[u] = true
+		}
+		for _, a := range input.Assignments {
+			if a.Group < 1 || a.Group > input.Count {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("group must be between 1 and %d", input.Count)})
+				return
+			}
+			if !inSession[a.UserID] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("User %d is not in the session", a.UserID)})
+				return
+			}
+			groupOf[a.UserID] = a.Group
+		}
+	}
+
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		groupIDs := make([]uint, input.Count+1)
+		for n := 1; n <= input.Count; n++ {
+			name := fmt.Sprintf("Group %d", n)
+			if n <= len(input.Names) && strings.TrimSpace(input.Names[n-1]) != "" {
+				name = strings.TrimSpace(input.Names[n-1])
+			}
+			group := models.BreakoutGroup{
+				SessionID:   session.SessionID,
+				RoomID:      session.RoomID,
+				Number:      n,
+				Name:        name,
+				LiveKitRoom: fmt.Sprintf("room-%d-breakout-pending-%d", session.RoomID, n),
+			}
+			if err := tx.Create(&group).Error; err != nil {
+				return err
+			}
+			// Named after the group's ID, so no two splits share a LiveKit room
+			if err := tx.Model(&group).Update("livekit_room", fmt.Sprintf("room-%d-breakout-%d", session.RoomID, group.ID)).Error; err != nil {
+				return err
+			}
+			groupIDs[n] = group.ID
+		}
+		for userID, n := range groupOf {
+			if err := assignBreakoutMember(tx, session.SessionID, userID, groupIDs[n]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("CreateBreakoutsHandler: Failed to create groups for session %s: %v", session.SessionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create breakout groups"})
+		return
+	}
+
+	groups, err := openBreakoutGroups(session.SessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load breakout groups"})
+		return
+	}
+	log.Printf("👥 CreateBreakoutsHandler: Session %s split into %d groups (%s)", session.SessionID, input.Count, input.Assignment)
+	broadcastBreakout(session.RoomID, "breakouts_started", map[string]interface{}{
+		"session_id": session.SessionID,
+		"groups":     groups,
+	})
+	c.JSON(http.StatusCreated, gin.H{
+		"session_id": session.SessionID,
+		"groups":     groups,
+		"count":      len(groups),
+	})
+}
+
+// AssignBreakoutMemberHandler handles PUT /api/rooms/watch-sessions/:session_id/breakouts/members/:user_id
+// Moves a member to another open group ({"group_id": N}), or back to the main session
+// ({"group_id": 0}).
+func AssignBreakoutMemberHandler(c *gin.Context) {
+	session, ok := loadHostedSession(c, "Only the host can assign breakout groups")
+	if !ok {
+		return
+	}
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	var input struct {
+		GroupID uint `json:"group_id"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var group *models.BreakoutGroup
+	if input.GroupID != 0 {
+		if group, err = openBreakoutGroup(session.SessionID, input.GroupID); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Breakout group not found"})
+			return
+		}
+		var count int64
+		DB.Model(&models.WatchSessionMember{}).
+			Where("watch_session_id = ? AND user_id = ?", session.ID, userID).
+			Count(&count)
+		if count == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User is not in the session"})
+			return
+		}
+	}
+
+	if err := assignBreakoutMember(DB, session.SessionID, uint(userID), input.GroupID); err != nil {
+		log.Printf("AssignBreakoutMemberHandler: Failed to assign user %d in session %s: %v", userID, session.SessionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign member"})
+		return
+	}
+
+	data := map[string]interface{}{
+		"session_id": session.SessionID,
+		"user_id":    uint(userID),
+		"group_id":   input.GroupID,
+	}
+	if group != nil {
+		data["livekit_room"] = group.LiveKitRoom
+	}
+	broadcastBreakout(session.RoomID, "breakout_assigned", data)
+	c.JSON(http.StatusOK, data)
+}
+
+// BroadcastToBreakoutsHandler handles POST /api/rooms/watch-sessions/:session_id/breakouts/broadcast
+// Sends the host's message to every group.
+func BroadcastToBreakoutsHandler(c *gin.Context) {
+	session, ok := loadHostedSession(c, "Only the host can broadcast to breakout groups")
+	if !ok {
+		return
+	}
+	var input struct {
+		Message string `json:"message" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var open int64
+	DB.Model(&models.BreakoutGroup{}).Where("session_id = ? AND closed_at IS NULL", session.SessionID).Count(&open)
+	if open == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "No breakout groups are open"})
+		return
+	}
+
+	userID := c.MustGet("user_id").(uint)
+	var username string
+	DB.Model(&models.User{}).Select("username").Where("id = ?", userID).Scan(&username)
+	data := map[string]interface{}{
+		"session_id": session.SessionID,
+		"user_id":    userID,
+		"username":   username,
+		"message":    input.Message,
+		"sent_at":    time.Now(),
+	}
+	broadcastBreakout(session.RoomID, "breakout_broadcast", data)
+	c.JSON(http.StatusOK, data)
+}
+
+// RecallBreakoutsHandler handles POST /api/rooms/watch-sessions/:session_id/breakouts/recall
+// Calls everyone back to the main session after countdown_seconds (default 60, 0 for
+// now). Recalling again restarts the countdown.
+func RecallBreakoutsHandler(c *gin.Context) {
+	session, ok := loadHostedSession(c, "Only the host can recall breakout groups")
+	if !ok {
+		return
+	}
+	var input struct {
+		CountdownSeconds *int `json:"countdown_seconds"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) { // the body is optional
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	countdown := defaultRecallCountdown
+	if input.CountdownSeconds != nil {
+		countdown = time.Duration(*input.CountdownSeconds) * time.Second
+		if countdown < 0 || countdown > maxRecallCountdown {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("countdown_seconds must be between 0 and %d", int(maxRecallCountdown.Seconds()))})
+			return
+		}
+	}
+	var open int64
+	DB.Model(&models.BreakoutGroup{}).Where("session_id = ? AND closed_at IS NULL", session.SessionID).Count(&open)
+	if open == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "No breakout groups are open"})
+		return
+	}
+
+	if countdown == 0 {
+		closeBreakouts(session.SessionID, true)
+		c.JSON(http.StatusOK, gin.H{"session_id": session.SessionID, "countdown_seconds": 0})
+		return
+	}
+
+	sessionID := session.SessionID
+	endsAt := time.Now().Add(countdown)
+	breakoutMu.Lock()
+	if timer, ok := breakoutRecalls[sessionID]; ok {
+		timer.Stop()
+	}
+	breakoutRecalls[sessionID] = time.AfterFunc(countdown, func() {
+		closeBreakouts(sessionID, true)
+	})
+	breakoutMu.Unlock()
+
+	data := map[string]interface{}{
+		"session_id":        sessionID,
+		"countdown_seconds": int(countdown.Seconds()),
+		"ends_at":           endsAt,
+	}
+	broadcastBreakout(session.RoomID, "breakout_recall", data)
+	c.JSON(http.StatusOK, data)
+}
+
+// handleBreakoutChat handles "breakout_chat_message" ({"group_id": N, "message": "..."}):
+// a message in a group's own chat. It is stored with the session's chat and reaches the
+// group's members and the hosts.
+func (client *Client) handleBreakoutChat(msg WebSocketMessage) {
+	var data struct {
+		GroupID uint   `json:"group_id"`
+		Message string `json:"message"`
+	}
+	raw, _ := json.Marshal(msg.Data)
+	if err := json.Unmarshal(raw, &data); err != nil || data.GroupID == 0 || strings.TrimSpace(data.Message) == "" {
+		log.Printf("[breakout_chat_message] ❌ Invalid data from user %d: %v", client.userID, err)
+		return
+	}
+
+	var session models.WatchSession
+	if err := DB.Where("room_id = ? AND ended_at IS NULL", client.roomID).First(&session).Error; err != nil {
+		log.Printf("[breakout_chat_message] ❌ No running session in room %d", client.roomID)
+		return
+	}
+	group, err := openBreakoutGroup(session.SessionID, data.GroupID)
+	if err != nil {
+		log.Printf("[breakout_chat_message] ❌ Group %d is not open in session %s", data.GroupID, session.SessionID)
+		return
+	}
+	if !canJoinBreakoutGroup(&session, group, client.userID) {
+		log.Printf("[breakout_chat_message] ❌ User %d is not in group %d", client.userID, group.ID)
+		return
+	}
+	if sessionChatArchived(session.SessionID) {
+		log.Printf("[breakout_chat_message] ❌ Chat of session %s is archived", session.SessionID)
+		return
+	}
+
+	var username string
+	DB.Model(&models.User{}).Select("username").Where("id = ?", client.userID).Scan(&username)
+	chatMessage := models.ChatMessage{
+		RoomID:          client.roomID,
+		SessionID:       session.SessionID,
+		UserID:          client.userID,
+		Username:        username,
+		Message:         data.Message,
+		BreakoutGroupID: &group.ID,
+	}
+	if err := DB.Create(&chatMessage).Error; err != nil {
+		log.Printf("[breakout_chat_message] ❌ Failed to save message: %v", err)
+		return
+	}
+
+	var members []uint
+	DB.Model(&models.BreakoutGroupMember{}).Where("group_id = ?", group.ID).Pluck("user_id", &members)
+	seen := map[uint]bool{}
+	var recipients []uint
+	for _, id := range append(members, joinDeciders(&session)...) {
+		if !seen[id] {
+			seen[id] = true
+			recipients = append(recipients, id)
+		}
+	}
+	if msgBytes, err := json.Marshal(map[string]interface{}{
+		"type": "breakout_chat_message",
+		"data": map[string]interface{}{
+			"ID":         chatMessage.ID,
+			"UserID":     chatMessage.UserID,
+			"Username":   chatMessage.Username,
+			"Message":    chatMessage.Message,
+			"session_id": chatMessage.SessionID,
+			"group_id":   group.ID,
+			"CreatedAt":  chatMessage.CreatedAt,
+			"reactions":  []interface{}{},
+		},
+	}); err == nil {
+		client.hub.BroadcastToUsers(recipients, OutgoingMessage{Data: msgBytes, IsBinary: false})
+	}
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
 	"wewatch-backend/internal/models"
@@ -63,54 +65,16 @@ func GetAllActiveSessionsHandler(c *gin.Context) {
 
 		// If this is a temporary instant-watch room with no active members,
 		// it's stale/orphaned — try to clean it up and skip returning it in the lobby.
-		if room.IsTemporary && activeMemberCount == 0 {
+		// Sessions the host hasn't joined yet are left to CleanupExpiredSessions.
+		if room.IsTemporary && activeMemberCount == 0 && session.State != models.SessionStateCreated {
 			log.Printf("  └─ 🧹 ORPHANED! Cleaning up instant-watch session %s (room %d)", session.SessionID, room.ID)
 
-			tx := DB.Begin()
-			if tx.Error == nil {
-				// Delete temporary media items linked to the session
-				if err := tx.Where("session_id = ?", session.SessionID).Delete(&models.TemporaryMediaItem{}).Error; err != nil {
-					log.Printf("⚠️ Cleanup: Failed to delete temporary media for session %s: %v", session.SessionID, err)
+			// Ended in the background so the lobby listing isn't held up
+			go func(sessionID string) {
+				if _, err := EndSession(context.Background(), sessionID, models.SessionEndExpired, 0); err != nil && !errors.Is(err, ErrSessionEnded) {
+					log.Printf("⚠️ Cleanup: Failed to end orphaned session %s: %v", sessionID, err)
 				}
-
-				// Delete watch session members
-				if err := tx.Where("watch_session_id = ?", session.ID).Delete(&models.WatchSessionMember{}).Error; err != nil {
-					log.Printf("⚠️ Cleanup: Failed to delete session members for session %s: %v", session.SessionID, err)
-				}
-
-				// Delete chat messages and reactions tied to this session
-				var chatMessages []models.ChatMessage
-				if err := tx.Where("session_id = ?", session.SessionID).Find(&chatMessages).Error; err == nil {
-					if len(chatMessages) > 0 {
-						ids := make([]uint, len(chatMessages))
-						for i, m := range chatMessages {
-							ids[i] = m.ID
-						}
-						if err := tx.Where("message_id IN ?", ids).Delete(&models.Reaction{}).Error; err != nil {
-							log.Printf("⚠️ Cleanup: Failed to delete reactions for session %s: %v", session.SessionID, err)
-						}
-					}
-				}
-				if err := tx.Where("session_id = ?", session.SessionID).Delete(&models.ChatMessage{}).Error; err != nil {
-					log.Printf("⚠️ Cleanup: Failed to delete chat messages for session %s: %v", session.SessionID, err)
-				}
-
-				// Delete the watch session record
-				if err := tx.Delete(&models.WatchSession{}, session.ID).Error; err != nil {
-					log.Printf("⚠️ Cleanup: Failed to delete watch session %s: %v", session.SessionID, err)
-					tx.Rollback()
-				} else {
-					// Attempt to delete the room itself (temporary rooms should be removed)
-					if err := tx.Delete(&models.Room{}, room.ID).Error; err != nil {
-						log.Printf("⚠️ Cleanup: Failed to delete temporary room %d: %v", room.ID, err)
-					} else {
-						log.Printf("🗑️ Cleanup: Deleted orphaned instant-watch room %d and session %s", room.ID, session.SessionID)
-					}
-					tx.Commit()
-				}
-			} else {
-				log.Printf("⚠️ Cleanup: Failed to begin transaction for session %s: %v", session.SessionID, tx.Error)
-			}
+			}(session.SessionID)
 
 			// Skip adding this session to the response
 			continue
@@ -0,0 +1,269 @@
+// WeWatch/backend/internal/handlers/session_history.go
+package handlers
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"wewatch-backend/internal/models"
+)
+
+// Session history. Past (and the running) watch sessions of a room, with who was in them
+// and when. Anyone who can see the room (requireRoomAccess: public rooms, or the host and
+// members of private ones) can browse its history.
+
+// SessionHistoryMember is one stay of a user in a session; a user who reconnected has
+// several.
+type SessionHistoryMember struct {
+	UserID   uint       `json:"user_id"`
+	Username string     `json:"username"`
+	UserRole string     `json:"user_role"`
+	IsCoHost bool       `json:"is_co_host"`
+	JoinedAt time.Time  `json:"joined_at"`
+	LeftAt   *time.Time `json:"left_at,omitempty"` // nil while still in the session
+}
+
+// SessionHistoryEntry is a watch session as the history endpoints return it.
+type SessionHistoryEntry struct {
+	SessionID       string                 `json:"session_id"`
+	RoomID          uint                   `json:"room_id"`
+	WatchType       string                 `json:"watch_type"`
+	HostID          uint                   `json:"host_id"`
+	HostUsername    string                 `json:"host_username"`
+	State           string                 `json:"state"`
+	StartedAt       time.Time              `json:"started_at"`
+	EndedAt         *time.Time             `json:"ended_at,omitempty"`
+	EndReason       string                 `json:"end_reason,omitempty"`
+	DurationSeconds float64                `json:"duration_seconds"` // so far, for the running session
+	MemberCount     int                    `json:"member_count"`     // distinct users
+	Members         []SessionHistoryMember `json:"members"`
+}
+
+// parseHistoryTime reads a from/to filter: RFC 3339, or a date. A date in "to" means the
+// whole day.
+func parseHistoryTime(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// sessionHistoryEntries builds the history entries of sessions, loading their members and
+// the usernames of members and hosts.
+func sessionHistoryEntries(sessions []models.WatchSession) ([]SessionHistoryEntry, error) {
+	entries := make([]SessionHistoryEntry, 0, len(sessions))
+	if len(sessions) == 0 {
+		return entries, nil
+	}
+
+	ids := make([]uint, len(sessions))
+	for i, s := range sessions {
+		ids[i] = s.ID
+	}
+	var members []models.WatchSessionMember
+	if err := DB.Where("watch_session_id IN ?", ids).Order("joined_at ASC, id ASC").Find(&members).Error; err != nil {
+		return nil, err
+	}
+
+	userIDs := map[uint]bool{}
+	for _, s := range sessions {
+		userIDs[s.HostID] = true
+	}
+	for _, m := range members {
+		userIDs[m.UserID] = true
+	}
+	idList := make([]uint, 0, len(userIDs))
+	for id := range userIDs {
+		idList = append(idList, id)
+	}
+	var users []models.User
+	if err := DB.Select("id", "username").Where("id IN ?", idList).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	usernames := make(map[uint]string, len(users))
+	for _, u := range users {
+		usernames[u.ID] = u.Username
+	}
+
+	membersBySession := map[uint][]SessionHistoryMember{}
+	for _, m := range members {
+		membersBySession[m.WatchSessionID] = append(membersBySession[m.WatchSessionID], SessionHistoryMember{
+			UserID:   m.UserID,
+			Username: usernames[m.UserID],
+			UserRole: m.UserRole,
+			IsCoHost: m.IsCoHost,
+			JoinedAt: m.JoinedAt,
+			LeftAt:   m.LeftAt,
+		})
+	}
+
+	now := time.Now()
+	for _, s := range sessions {
+		end := now
+		if s.EndedAt != nil {
+			end = *s.EndedAt
+		}
+		sessionMembers := membersBySession[s.ID]
+		if sessionMembers == nil {
+			sessionMembers = []SessionHistoryMember{}
+		}
+		distinct := map[uint]bool{}
+		for _, m := range sessionMembers {
+			distinct[m.UserID] = true
+		}
+		entries = append(entries, SessionHistoryEntry{
+			SessionID:       s.SessionID,
+			RoomID:          s.RoomID,
+			WatchType:       s.WatchType,
+			HostID:          s.HostID,
+			HostUsername:    usernames[s.HostID],
+			State:           s.State,
+			StartedAt:       s.StartedAt,
+			EndedAt:         s.EndedAt,
+			EndReason:       s.EndReason,
+			DurationSeconds: math.Round(end.Sub(s.StartedAt).Seconds()*1000) / 1000,
+			MemberCount:     len(distinct),
+			Members:         sessionMembers,
+		})
+	}
+	return entries, nil
+}
+
+// GetRoomSessionsHandler handles GET /api/rooms/:id/sessions
+// Returns the room's watch sessions, newest first, paginated. Filters: from, to (RFC 3339
+// or YYYY-MM-DD, on started_at), watch_type, host_id and status (active or ended).
+func GetRoomSessionsHandler(c *gin.Context) {
+	_, room, ok := requireRoomAccess(c)
+	if !ok {
+		return
+	}
+
+	query := DB.Model(&models.WatchSession{}).Where("room_id = ?", room.ID)
+	if v := c.Query("from"); v != "" {
+		from, err := parseHistoryTime(v, false)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be RFC 3339 or YYYY-MM-DD"})
+			return
+		}
+		query = query.Where("started_at >= ?", from)
+	}
+	if v := c.Query("to"); v != "" {
+		to, err := parseHistoryTime(v, true)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be RFC 3339 or YYYY-MM-DD"})
+			return
+		}
+		query = query.Where("started_at < ?", to)
+	}
+	if v := c.Query("watch_type"); v != "" {
+		query = query.Where("watch_type = ?", v)
+	}
+	if v := c.Query("host_id"); v != "" {
+		hostID, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid host_id"})
+			return
+		}
+		query = query.Where("host_id = ?", hostID)
+	}
+	switch c.Query("status") {
+	case "":
+	case "active":
+		query = query.Where("ended_at IS NULL")
+	case "ended":
+		query = query.Where("ended_at IS NOT NULL")
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be active or ended"})
+		return
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		log.Printf("GetRoomSessionsHandler: Count failed for room %d: %v", room.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load sessions"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultLibraryPage)))
+	if pageSize < 1 {
+		pageSize = defaultLibraryPage
+	}
+	if pageSize > maxLibraryPage {
+		pageSize = maxLibraryPage
+	}
+
+	var sessions []models.WatchSession
+	if err := query.Order("started_at DESC, id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&sessions).Error; err != nil {
+		log.Printf("GetRoomSessionsHandler: Query failed for room %d: %v", room.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load sessions"})
+		return
+	}
+	entries, err := sessionHistoryEntries(sessions)
+	if err != nil {
+		log.Printf("GetRoomSessionsHandler: Failed to load members for room %d: %v", room.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sessions":    entries,
+		"count":       len(entries),
+		"total":       total,
+		"page":        page,
+		"page_size":   pageSize,
+		"total_pages": int(math.Ceil(float64(total) / float64(pageSize))),
+		"room_id":     room.ID,
+	})
+}
+
+// GetRoomSessionHandler handles GET /api/rooms/:id/sessions/:session_id
+// Returns one session of the room with its members, and its recap once it has ended
+// (to the room host and the session's host only, like the session-summaries endpoints).
+func GetRoomSessionHandler(c *gin.Context) {
+	userID, room, ok := requireRoomAccess(c)
+	if !ok {
+		return
+	}
+
+	var session models.WatchSession
+	if err := DB.Where("room_id = ? AND session_id = ?", room.ID, c.Param("session_id")).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return
+	}
+	entries, err := sessionHistoryEntries([]models.WatchSession{session})
+	if err != nil {
+		log.Printf("GetRoomSessionHandler: Failed to load members of session %s: %v", session.SessionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load session"})
+		return
+	}
+
+	response := gin.H{"session": entries[0]}
+	if room.HostID == userID || session.HostID == userID {
+		var summary models.SessionSummary
+		if err := DB.Where("session_id = ?", session.SessionID).First(&summary).Error; err == nil {
+			response["summary"] = summary
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
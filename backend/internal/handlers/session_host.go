@@ -0,0 +1,198 @@
+// WeWatch/backend/internal/handlers/session_host.go
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"wewatch-backend/internal/models"
+)
+
+// Session host handoff. The session host (WatchSession.HostID) controls playback and
+// broadcast permissions. They can hand the session to another member with "transfer_host";
+// if they disconnect, the longest-present co-host or room admin takes over automatically
+// after SESSION_HOST_HANDOFF_AFTER, before the host timeout would end the session.
+const defaultHostHandoffAfter = 2 * time.Minute
+
+var errNotSessionMember = errors.New("user is not in the session")
+
+// hostHandoffAfter returns how long a session waits for its host before handing it off
+// (0 disables automatic handoff).
+func hostHandoffAfter() time.Duration {
+	if v := os.Getenv("SESSION_HOST_HANDOFF_AFTER"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			return d
+		}
+		log.Printf("⚠️ Invalid SESSION_HOST_HANDOFF_AFTER=%q, using default %s", v, defaultHostHandoffAfter)
+	}
+	return defaultHostHandoffAfter
+}
+
+// transferSessionHost makes newHostID the host of a running session and tells the room.
+// reason is "transfer" (the host handed it over) or "auto" (the host was away); by is the
+// user who asked, or 0.
+func transferSessionHost(session *models.WatchSession, newHostID uint, reason string, by uint) error {
+	var count int64
+	if err := DB.Model(&models.WatchSessionMember{}).
+		Where("watch_session_id = ? AND user_id = ? AND is_active = ?", session.ID, newHostID, true).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errNotSessionMember
+	}
+
+	previousHostID := session.HostID
+	res := DB.Model(&models.WatchSession{}).
+		Where("id = ? AND host_id = ? AND ended_at IS NULL", session.ID, previousHostID).
+		Update("host_id", newHostID)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("session %s changed meanwhile", session.SessionID)
+	}
+	session.HostID = newHostID
+
+	// The new host is connected (they are an active member), so the session is live again
+	if hub != nil {
+		hub.sessionMutex.Lock()
+		if cached, ok := hub.activeSessions[session.SessionID]; ok {
+			cached.HostID = newHostID
+		}
+		hub.sessionMutex.Unlock()
+		hub.sessionHostConnected(session.SessionID)
+
+		data := map[string]interface{}{
+			"session_id":       session.SessionID,
+			"room_id":          session.RoomID,
+			"host_id":          newHostID,
+			"previous_host_id": previousHostID,
+			"reason":           reason,
+		}
+		if by != 0 {
+			data["initiated_by"] = by
+		}
+		if msg, err := json.Marshal(map[string]interface{}{"type": "host_changed", "data": data}); err == nil {
+			hub.BroadcastToRoom(session.RoomID, OutgoingMessage{Data: msg, IsBinary: false}, nil)
+		}
+	}
+	log.Printf("👑 transferSessionHost: Session %s host %d → %d (%s)", session.SessionID, previousHostID, newHostID, reason)
+	return nil
+}
+
+// handoffCandidate returns the co-host or room admin who has been in the session longest,
+// or 0 when nobody qualifies.
+func handoffCandidate(session *models.WatchSession) uint {
+	var candidate struct{ UserID uint }
+	err := DB.Table("watch_session_members AS m").
+		Select("m.user_id").
+		Joins("LEFT JOIN user_rooms ur ON ur.room_id = ? AND ur.user_id = m.user_id AND ur.deleted_at IS NULL", session.RoomID).
+		Where("m.watch_session_id = ? AND m.is_active = ? AND m.deleted_at IS NULL AND m.user_id <> ?", session.ID, true, session.HostID).
+		Where("m.is_co_host = ? OR ur.user_role IN ?", true, []string{"admin", "host"}).
+		Order("m.joined_at ASC").
+		Limit(1).
+		Scan(&candidate).Error
+	if err != nil {
+		log.Printf("⚠️ handoffCandidate: Failed to query session %s: %v", session.SessionID, err)
+		return 0
+	}
+	return candidate.UserID
+}
+
+// autoHandoffHost hands a session whose host is away to the best candidate, if any.
+func autoHandoffHost(sessionID string) {
+	var session models.WatchSession
+	if err := DB.Where("session_id = ? AND ended_at IS NULL", sessionID).First(&session).Error; err != nil {
+		return
+	}
+	newHostID := handoffCandidate(&session)
+	if newHostID == 0 {
+		return // keep waiting for the host; the session ends at the host timeout
+	}
+	if err := transferSessionHost(&session, newHostID, "auto", 0); err != nil {
+		log.Printf("⚠️ autoHandoffHost: Failed to hand session %s to user %d: %v", sessionID, newHostID, err)
+	}
+}
+
+// loadHostCommand parses {"user_id": N} from a host command and loads the client's running
+// session, checking the sender is its host or the room host.
+func (client *Client) loadHostCommand(msg WebSocketMessage, command string) (*models.WatchSession, uint, bool) {
+	var data struct {
+		UserID uint `json:"user_id"`
+	}
+	raw, _ := json.Marshal(msg.Data)
+	if err := json.Unmarshal(raw, &data); err != nil || data.UserID == 0 {
+		log.Printf("[%s] ❌ Invalid data from user %d: %v", command, client.userID, err)
+		return nil, 0, false
+	}
+
+	var session models.WatchSession
+	if err := DB.Where("room_id = ? AND ended_at IS NULL", client.roomID).First(&session).Error; err != nil {
+		log.Printf("[%s] ❌ No running session in room %d", command, client.roomID)
+		return nil, 0, false
+	}
+	if session.HostID != client.userID {
+		var room models.Room
+		if err := DB.First(&room, session.RoomID).Error; err != nil || room.HostID != client.userID {
+			log.Printf("[%s] ❌ User %d is not the host of session %s", command, client.userID, session.SessionID)
+			return nil, 0, false
+		}
+	}
+	return &session, data.UserID, true
+}
+
+// handleTransferHost handles "transfer_host" ({"user_id": N}): the session host (or the
+// room host) hands the session to another member.
+func (client *Client) handleTransferHost(msg WebSocketMessage) {
+	session, newHostID, ok := client.loadHostCommand(msg, "transfer_host")
+	if !ok {
+		return
+	}
+	if newHostID == session.HostID {
+		return
+	}
+	if err := transferSessionHost(session, newHostID, "transfer", client.userID); err != nil {
+		log.Printf("[transfer_host] ❌ Failed to hand session %s to user %d: %v", session.SessionID, newHostID, err)
+	}
+}
+
+// handleSetCoHost handles "set_cohost" ({"user_id": N, "co_host": bool}): co-hosts are first
+// in line when the host is away.
+func (client *Client) handleSetCoHost(msg WebSocketMessage) {
+	session, userID, ok := client.loadHostCommand(msg, "set_cohost")
+	if !ok {
+		return
+	}
+	var data struct {
+		CoHost bool `json:"co_host"`
+	}
+	raw, _ := json.Marshal(msg.Data)
+	if err := json.Unmarshal(raw, &data); err != nil {
+		log.Printf("[set_cohost] ❌ Invalid data from user %d: %v", client.userID, err)
+		return
+	}
+
+	// Every membership row of the user, so the flag survives reconnects
+	res := DB.Model(&models.WatchSessionMember{}).
+		Where("watch_session_id = ? AND user_id = ?", session.ID, userID).
+		Update("is_co_host", data.CoHost)
+	if res.Error != nil || res.RowsAffected == 0 {
+		log.Printf("[set_cohost] ❌ User %d is not in session %s: %v", userID, session.SessionID, res.Error)
+		return
+	}
+	if msgBytes, err := json.Marshal(map[string]interface{}{
+		"type": "cohost_updated",
+		"data": map[string]interface{}{
+			"session_id": session.SessionID,
+			"user_id":    userID,
+			"co_host":    data.CoHost,
+		},
+	}); err == nil {
+		hub.BroadcastToRoom(session.RoomID, OutgoingMessage{Data: msgBytes, IsBinary: false}, nil)
+	}
+}
@@ -0,0 +1,371 @@
+// WeWatch/backend/internal/handlers/session_lifecycle.go
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"wewatch-backend/internal/models"
+	"wewatch-backend/internal/utils"
+)
+
+// Watch session lifecycle. Sessions move created → live ⇄ host_away → ending → ended, and
+// EndSession is the only way into ending: the host's request, the host timeout, the stale
+// and expired sweeps and room deletion all go through it. What ending a session cleans up
+// is a list of hooks, so every trigger does the same work and produces the same
+// session_ended event in the database, on LiveKit and on the hub.
+
+const (
+	// sessionEndingStaleAfter lets a later EndSession take over a session whose end never
+	// finished (e.g. the server restarted mid-way).
+	sessionEndingStaleAfter = 5 * time.Minute
+	// sessionEndDisconnectDelay gives clients time to receive session_ended before their
+	// sockets are closed.
+	sessionEndDisconnectDelay = 500 * time.Millisecond
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionEnded    = errors.New("session already ended")
+)
+
+// SessionEnd describes a session that is being ended. End hooks receive it.
+type SessionEnd struct {
+	Session *models.WatchSession
+	Room    *models.Room
+	Reason  string // models.SessionEnd*
+	EndedBy uint   // 0 when the server ended the session
+	EndedAt time.Time
+
+	tempMedia   []models.TemporaryMediaItem // deleted in the transaction
+	tempRelease mediaRelease                // their files, deleted after it
+	roomRelease mediaRelease                // files of a deleted temporary room's media
+}
+
+// SessionEndHook is one step of ending a session. Tx runs inside the end transaction and
+// an error aborts the end (the session stays as it was). After runs once the end is
+// committed; its errors are logged, the session stays ended.
+type SessionEndHook struct {
+	Name  string
+	Tx    func(tx *gorm.DB, end *SessionEnd) error
+	After func(ctx context.Context, end *SessionEnd) error
+}
+
+var sessionEndHooks = []SessionEndHook{
+	{Name: "members", Tx: endSessionMembers},
+	{Name: "summary", Tx: summarizeSession}, // before chat and temporary media are deleted
+	{Name: "temporary_media", Tx: deleteSessionTempMedia, After: releaseSessionTempMedia},
+	{Name: "chat", Tx: deleteSessionChat},
+	{Name: "temporary_room", Tx: deleteTemporaryRoom, After: releaseTemporaryRoomMedia},
+	{Name: "playback", After: func(ctx context.Context, end *SessionEnd) error {
+		forgetPlayback(end.Room.ID)
+		return nil
+	}},
+	{Name: "timeline", After: func(ctx context.Context, end *SessionEnd) error {
+		forgetTimelineClock(end.Session.SessionID)
+		return nil
+	}},
+	{Name: "lobby", After: func(ctx context.Context, end *SessionEnd) error {
+		closeSessionLobby(end.Session.SessionID)
+		return nil
+	}},
+	{Name: "breakouts", After: func(ctx context.Context, end *SessionEnd) error {
+		closeBreakouts(end.Session.SessionID, false)
+		return nil
+	}},
+}
+
+// RegisterSessionEndHook adds a hook that runs after the built-in ones. Call it during
+// startup, before sessions can end.
+func RegisterSessionEndHook(hook SessionEndHook) {
+	sessionEndHooks = append(sessionEndHooks, hook)
+}
+
+// setSessionState moves a running session to state if it is in one of from. It reports
+// whether the session changed.
+func setSessionState(sessionID, state string, from ...string) bool {
+	res := DB.Model(&models.WatchSession{}).
+		Where("session_id = ? AND ended_at IS NULL AND state IN ?", sessionID, from).
+		Update("state", state)
+	if res.Error != nil {
+		log.Printf("⚠️ setSessionState: Failed to move session %s to %s: %v", sessionID, state, res.Error)
+		return false
+	}
+	return res.RowsAffected > 0
+}
+
+// sessionHostConnected records that the room host (re)joined a session: the host-away
+// timer is cancelled and the session is live.
+func (h *Hub) sessionHostConnected(sessionID string) {
+	h.hostDisconnectMutex.Lock()
+	if disconnectTime, exists := h.hostDisconnectTimes[sessionID]; exists {
+		delete(h.hostDisconnectTimes, sessionID)
+		log.Printf("✅ Host reconnected to session %s after %.1f seconds - auto-end timer cancelled", sessionID, time.Since(disconnectTime).Seconds())
+	}
+	h.hostDisconnectMutex.Unlock()
+
+	setSessionState(sessionID, models.SessionStateLive, models.SessionStateCreated, models.SessionStateHostAway)
+}
+
+// sessionHostDisconnected starts the host-away grace period of a session.
+func (h *Hub) sessionHostDisconnected(sessionID string, at time.Time) {
+	h.hostDisconnectMutex.Lock()
+	h.hostDisconnectTimes[sessionID] = at
+	h.hostDisconnectMutex.Unlock()
+
+	setSessionState(sessionID, models.SessionStateHostAway, models.SessionStateCreated, models.SessionStateLive)
+}
+
+// forgetSession drops everything the hub tracks for an ended session.
+func (h *Hub) forgetSession(sessionID string) {
+	h.hostDisconnectMutex.Lock()
+	delete(h.hostDisconnectTimes, sessionID)
+	h.hostDisconnectMutex.Unlock()
+
+	h.sessionMutex.Lock()
+	delete(h.activeSessions, sessionID)
+	delete(h.sessionMembers, sessionID)
+	delete(h.orphanedSessions, sessionID)
+	h.sessionMutex.Unlock()
+
+	forgetSessionActivity(sessionID)
+}
+
+// EndSession ends a watch session: it runs the end hooks, records the end and announces
+// session_ended to LiveKit and the room. endedBy is the user who ended it, or 0. It returns
+// ErrSessionNotFound, or ErrSessionEnded when the session has ended or is being ended.
+func EndSession(ctx context.Context, sessionID, reason string, endedBy uint) (*SessionEnd, error) {
+	var session models.WatchSession
+	if err := DB.Where("session_id = ?", sessionID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("load session: %w", err)
+	}
+	if session.EndedAt != nil {
+		return nil, ErrSessionEnded
+	}
+
+	// Claim the session so concurrent triggers end it only once
+	previousState := session.State
+	res := DB.Model(&models.WatchSession{}).
+		Where("id = ? AND ended_at IS NULL AND (state <> ? OR updated_at < ?)", session.ID, models.SessionStateEnding, time.Now().Add(-sessionEndingStaleAfter)).
+		Update("state", models.SessionStateEnding)
+	if res.Error != nil {
+		return nil, fmt.Errorf("claim session: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrSessionEnded
+	}
+	restore := func() {
+		DB.Model(&models.WatchSession{}).Where("id = ?", session.ID).Update("state", previousState)
+	}
+
+	// The room may already be soft-deleted (room deletion ends its session first)
+	var room models.Room
+	if err := DB.Unscoped().First(&room, session.RoomID).Error; err != nil {
+		restore()
+		return nil, fmt.Errorf("load room %d: %w", session.RoomID, err)
+	}
+
+	end := &SessionEnd{Session: &session, Room: &room, Reason: reason, EndedBy: endedBy, EndedAt: time.Now()}
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"state":      models.SessionStateEnded,
+			"ended_at":   end.EndedAt,
+			"end_reason": reason,
+		}
+		if endedBy != 0 {
+			updates["ended_by"] = endedBy
+		}
+		if err := tx.Model(&session).Updates(updates).Error; err != nil {
+			return fmt.Errorf("mark ended: %w", err)
+		}
+		for _, hook := range sessionEndHooks {
+			if hook.Tx == nil {
+				continue
+			}
+			if err := hook.Tx(tx, end); err != nil {
+				return fmt.Errorf("%s: %w", hook.Name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		restore()
+		return nil, err
+	}
+	session.State = models.SessionStateEnded
+	session.EndedAt = &end.EndedAt
+	session.EndReason = reason
+
+	for _, hook := range sessionEndHooks {
+		if hook.After == nil {
+			continue
+		}
+		if err := hook.After(ctx, end); err != nil {
+			log.Printf("⚠️ EndSession: %s hook failed for session %s: %v", hook.Name, sessionID, err)
+		}
+	}
+
+	announceSessionEnded(end)
+	log.Printf("✅ EndSession: Session %s in room %d ended (%s)", sessionID, room.ID, reason)
+	return end, nil
+}
+
+// announceSessionEnded tells LiveKit and everyone connected to the room that the session
+// ended, then disconnects the room's sockets.
+func announceSessionEnded(end *SessionEnd) {
+	roomID := end.Session.RoomID
+
+	// The call room belongs to the session
+	livekitRoomName := fmt.Sprintf("room-%d", roomID)
+	if err := utils.DeleteLiveKitRoom(livekitRoomName); err != nil {
+		log.Printf("⚠️ announceSessionEnded: Failed to delete LiveKit room %s: %v", livekitRoomName, err)
+	}
+
+	if hub == nil {
+		return
+	}
+	hub.forgetSession(end.Session.SessionID)
+
+	data := map[string]interface{}{
+		"session_id": end.Session.SessionID,
+		"room_id":    roomID,
+		"reason":     end.Reason,
+		"ended_at":   end.EndedAt,
+	}
+	if end.EndedBy != 0 {
+		data["ended_by"] = end.EndedBy
+	}
+	if msg, err := json.Marshal(map[string]interface{}{"type": "session_ended", "data": data}); err == nil {
+		hub.BroadcastToRoom(roomID, OutgoingMessage{Data: msg, IsBinary: false}, nil)
+	}
+	time.AfterFunc(sessionEndDisconnectDelay, func() {
+		hub.DisconnectRoomClients(roomID)
+	})
+}
+
+// endSessionMembers marks everyone still in the session as having left.
+func endSessionMembers(tx *gorm.DB, end *SessionEnd) error {
+	return tx.Model(&models.WatchSessionMember{}).
+		Where("watch_session_id = ? AND is_active = ?", end.Session.ID, true).
+		Updates(map[string]interface{}{
+			"is_active": false,
+			"left_at":   end.EndedAt,
+		}).Error
+}
+
+// deleteSessionTempMedia deletes the session's temporary uploads. They are locked so a
+// concurrent promotion to the library can't race the cleanup.
+func deleteSessionTempMedia(tx *gorm.DB, end *SessionEnd) error {
+	items, err := lockSessionTempMedia(tx, end.Session.SessionID)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		if err := tx.Delete(&items[i]).Error; err != nil {
+			return fmt.Errorf("delete temporary media %d: %w", items[i].ID, err)
+		}
+		if err := releaseMediaTx(tx, items[i].FilePath, items[i].PosterURL, items[i].BlobHash, &end.tempRelease); err != nil {
+			return fmt.Errorf("release temporary media %d: %w", items[i].ID, err)
+		}
+	}
+	end.tempMedia = items
+	return nil
+}
+
+// releaseSessionTempMedia deletes the files the deleted temporary uploads no longer share.
+func releaseSessionTempMedia(ctx context.Context, end *SessionEnd) error {
+	if len(end.tempMedia) > 0 {
+		log.Printf("🗑️ EndSession: Released %d temporary media items of session %s", len(end.tempMedia), end.Session.SessionID)
+	}
+	return end.tempRelease.run(ctx)
+}
+
+// deleteSessionChat deletes the session's chat messages and their reactions, unless the
+// room keeps or archives session chat (the retention purge deletes it later, if at all).
+func deleteSessionChat(tx *gorm.DB, end *SessionEnd) error {
+	if end.Room.ChatRetention == models.ChatRetentionKeep || end.Room.ChatRetention == models.ChatRetentionArchive {
+		return nil
+	}
+	var messageIDs []uint
+	if err := tx.Model(&models.ChatMessage{}).Where("session_id = ?", end.Session.SessionID).Pluck("id", &messageIDs).Error; err != nil {
+		return err
+	}
+	if len(messageIDs) == 0 {
+		return nil
+	}
+	if err := tx.Where("message_id IN ?", messageIDs).Delete(&models.Reaction{}).Error; err != nil {
+		return fmt.Errorf("delete reactions: %w", err)
+	}
+	return tx.Where("session_id = ?", end.Session.SessionID).Delete(&models.ChatMessage{}).Error
+}
+
+// deleteTemporaryRoom removes an instant-watch room together with its session; regular
+// rooms outlive their sessions.
+func deleteTemporaryRoom(tx *gorm.DB, end *SessionEnd) error {
+	room := end.Room
+	if !room.IsTemporary || room.DeletedAt.Valid {
+		return nil
+	}
+	var media []models.MediaItem
+	if err := tx.Where("room_id = ?", room.ID).Find(&media).Error; err != nil {
+		return err
+	}
+	for _, item := range media {
+		if err := releaseMediaTx(tx, item.FilePath, item.PosterURL, item.BlobHash, &end.roomRelease); err != nil {
+			return fmt.Errorf("release media item %d: %w", item.ID, err)
+		}
+		if item.MediaKind == models.MediaKindSlideshow {
+			if err := releaseSlidesTx(tx, item.ID, &end.roomRelease); err != nil {
+				return fmt.Errorf("release slides of media item %d: %w", item.ID, err)
+			}
+		}
+	}
+	for _, related := range []interface{}{
+		&models.UserRoom{},
+		&models.RoomInvitation{},
+		&models.MediaItem{},
+		&models.ScheduledEvent{},
+		&models.RoomTVContent{},
+	} {
+		if err := tx.Where("room_id = ?", room.ID).Delete(related).Error; err != nil {
+			return fmt.Errorf("delete %T: %w", related, err)
+		}
+	}
+	if err := tx.Delete(&models.Room{}, room.ID).Error; err != nil {
+		return fmt.Errorf("delete room: %w", err)
+	}
+	log.Printf("🗑️ EndSession: Deleted temporary room %d with session %s", room.ID, end.Session.SessionID)
+	return nil
+}
+
+// releaseTemporaryRoomMedia deletes the files a deleted temporary room's library items no
+// longer share.
+func releaseTemporaryRoomMedia(ctx context.Context, end *SessionEnd) error {
+	return end.roomRelease.run(ctx)
+}
+
+// endSessions ends each session for reason, logging the ones that fail. It is what the
+// periodic sweeps use.
+func endSessions(sessions []models.WatchSession, reason, caller string) {
+	ended := 0
+	for _, session := range sessions {
+		if _, err := EndSession(context.Background(), session.SessionID, reason, 0); err != nil {
+			if !errors.Is(err, ErrSessionEnded) && !errors.Is(err, ErrSessionNotFound) {
+				log.Printf("❌ [%s] Failed to end session %s: %v", caller, session.SessionID, err)
+			}
+			continue
+		}
+		ended++
+	}
+	if ended > 0 {
+		log.Printf("✅ [%s] Ended %d sessions (%s)", caller, ended, reason)
+	}
+}
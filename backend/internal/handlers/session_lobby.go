@@ -0,0 +1,531 @@
+// WeWatch/backend/internal/handlers/session_lobby.go
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"wewatch-backend/internal/models"
+)
+
+// Knock to join. A session with RequiresApproval holds new viewers in a lobby: their socket
+// is open but not registered in the room, so they get nothing but lobby messages. The
+// request is pushed to the session host, the room host and room admins ("deciders"), and
+// the first of them to answer decides. Without an answer it expires after
+// JOIN_REQUEST_TIMEOUT. Users who were already in the session, or were approved once, come
+// back without knocking. Viewers on a full session's waitlist wait in the same lobby (see
+// session_waitlist.go).
+const (
+	defaultJoinRequestTimeout = 2 * time.Minute
+	// lobbyCloseDelay leaves time for join_denied / join_expired to reach the client
+	lobbyCloseDelay = 500 * time.Millisecond
+)
+
+// joinRequestTimeout returns how long a join request waits for a decision.
+func joinRequestTimeout() time.Duration {
+	if v := os.Getenv("JOIN_REQUEST_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+		log.Printf("⚠️ Invalid JOIN_REQUEST_TIMEOUT=%q, using default %s", v, defaultJoinRequestTimeout)
+	}
+	return defaultJoinRequestTimeout
+}
+
+// lobbyWait is a client waiting in a session's lobby, for a decision on its join request
+// or for its turn on the waitlist.
+type lobbyWait struct {
+	sessionID  string
+	requestID  uint
+	timer      *time.Timer // expires the join request
+	waitlistID uint        // set instead of requestID on the waitlist
+}
+
+var (
+	lobbyMu      sync.Mutex
+	lobbyClients = map[*Client]*lobbyWait{}
+)
+
+// Room sockets (RoomPage) can't knock or queue, so one whose user still needs approval, or
+// that found the session full, is held out of the running session instead: it stays in the
+// room but only gets the room's own broadcasts (roomLevelMessages) and may not send
+// anything, until the session ends.
+var heldOutClients = map[*Client]string{} // client → session it is held out of (lobbyMu)
+
+// roomLevelMessages are the room broadcasts that carry nothing of the session itself.
+var roomLevelMessages = map[string]bool{
+	"session_status":          true,
+	"session_started":         true,
+	"session_ended":           true,
+	"room_deleted":            true,
+	"room_chat":               true,
+	"room_message_edited":     true,
+	"room_message_deleted":    true,
+	"room_message_removed":    true,
+	"room_tv_content_created": true,
+	"room_tv_content_removed": true,
+	"scheduled_event_created": true,
+	"media_item_added":        true,
+	"media_item_updated":      true,
+	"media_item_deleted":      true,
+	"media_clip_created":      true,
+	"media_chapters_updated":  true,
+	"media_markers_updated":   true,
+	"media_slides_updated":    true,
+	"media_storyboard_ready":  true,
+}
+
+// roomLevelMessage reports whether a room broadcast may reach clients held out of the session.
+func roomLevelMessage(msg OutgoingMessage) bool {
+	if msg.IsBinary {
+		return false
+	}
+	var envelope struct {
+		Type string `json:"type"`
+	}
+	return json.Unmarshal(msg.Data, &envelope) == nil && roomLevelMessages[envelope.Type]
+}
+
+// holdOutOfSession keeps the client out of the session's traffic.
+func (client *Client) holdOutOfSession(sessionID string) {
+	lobbyMu.Lock()
+	heldOutClients[client] = sessionID
+	lobbyMu.Unlock()
+	log.Printf("🚧 holdOutOfSession: User %d's room socket is held out of session %s", client.userID, sessionID)
+}
+
+// heldOutOfSession reports whether the client is held out of the running session.
+func (client *Client) heldOutOfSession() bool {
+	lobbyMu.Lock()
+	defer lobbyMu.Unlock()
+	_, ok := heldOutClients[client]
+	return ok
+}
+
+// releaseHeldOut lets the room sockets held out of an ended session back to the room's traffic.
+func releaseHeldOut(sessionID string) {
+	lobbyMu.Lock()
+	defer lobbyMu.Unlock()
+	for c, sid := range heldOutClients {
+		if sid == sessionID {
+			delete(heldOutClients, c)
+		}
+	}
+}
+
+// inLobby reports whether the client is still waiting for a decision.
+func (client *Client) inLobby() bool {
+	lobbyMu.Lock()
+	defer lobbyMu.Unlock()
+	_, ok := lobbyClients[client]
+	return ok
+}
+
+// canDecideJoin reports whether userID may approve or deny join requests for the session.
+func canDecideJoin(session *models.WatchSession, userID uint) bool {
+	if session.HostID == userID {
+		return true
+	}
+	var room models.Room
+	if err := DB.Select("id", "host_id").First(&room, session.RoomID).Error; err == nil && room.HostID == userID {
+		return true
+	}
+	var count int64
+	DB.Model(&models.UserRoom{}).
+		Where("room_id = ? AND user_id = ? AND user_role IN ?", session.RoomID, userID, []string{"admin", "host"}).
+		Count(&count)
+	return count > 0
+}
+
+// needsJoinApproval reports whether userID has to knock before joining the session.
+func needsJoinApproval(session *models.WatchSession, userID uint) bool {
+	if !session.RequiresApproval || canDecideJoin(session, userID) {
+		return false
+	}
+	var count int64
+	DB.Model(&models.WatchSessionMember{}).
+		Where("watch_session_id = ? AND user_id = ?", session.ID, userID).
+		Count(&count)
+	if count > 0 {
+		return false
+	}
+	DB.Model(&models.SessionJoinRequest{}).
+		Where("session_id = ? AND user_id = ? AND status = ?", session.SessionID, userID, models.JoinRequestApproved).
+		Count(&count)
+	return count == 0
+}
+
+// joinDeciders returns the users who get a session's join requests.
+func joinDeciders(session *models.WatchSession) []uint {
+	userIDs := []uint{session.HostID}
+	var room models.Room
+	if err := DB.Select("id", "host_id").First(&room, session.RoomID).Error; err == nil && room.HostID != session.HostID {
+		userIDs = append(userIDs, room.HostID)
+	}
+	var admins []uint
+	DB.Model(&models.UserRoom{}).
+		Where("room_id = ? AND user_role IN ? AND user_id NOT IN ?", session.RoomID, []string{"admin", "host"}, userIDs).
+		Pluck("user_id", &admins)
+	return append(userIDs, admins...)
+}
+
+// sendToClient sends a message to one client, dropping it if the client is gone or stuck.
+func sendToClient(client *Client, msgType string, data interface{}) {
+	msgBytes, err := json.Marshal(map[string]interface{}{"type": msgType, "data": data})
+	if err != nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("⚠️ sendToClient: Client of user %d is gone (%s): %v", client.userID, msgType, r)
+		}
+	}()
+	select {
+	case client.send <- OutgoingMessage{Data: msgBytes, IsBinary: false}:
+	case <-time.After(100 * time.Millisecond):
+		log.Printf("⚠️ sendToClient: Timeout sending %s to user %d", msgType, client.userID)
+	}
+}
+
+// broadcastToDeciders sends a lobby message to everyone who can decide on the session's
+// join requests.
+func broadcastToDeciders(session *models.WatchSession, msgType string, data interface{}) {
+	if msgBytes, err := json.Marshal(map[string]interface{}{"type": msgType, "data": data}); err == nil {
+		hub.BroadcastToUsers(joinDeciders(session), OutgoingMessage{Data: msgBytes, IsBinary: false})
+	}
+}
+
+// knock puts the client in the session's lobby: it records a pending join request and
+// asks the deciders about it.
+func (client *Client) knock(session *models.WatchSession) {
+	now := time.Now()
+	request := models.SessionJoinRequest{
+		SessionID:   session.SessionID,
+		RoomID:      session.RoomID,
+		UserID:      client.userID,
+		Status:      models.JoinRequestPending,
+		RequestedAt: now,
+		ExpiresAt:   now.Add(joinRequestTimeout()),
+	}
+	if err := DB.Create(&request).Error; err != nil {
+		log.Printf("❌ knock: Failed to create join request for user %d in session %s: %v", client.userID, session.SessionID, err)
+		return
+	}
+
+	lobbyMu.Lock()
+	lobbyClients[client] = &lobbyWait{
+		sessionID: session.SessionID,
+		requestID: request.ID,
+		timer: time.AfterFunc(time.Until(request.ExpiresAt), func() {
+			closeJoinRequest(request.ID, models.JoinRequestExpired, 0)
+		}),
+	}
+	lobbyMu.Unlock()
+	log.Printf("🚪 knock: User %d is waiting to join session %s (request %d)", client.userID, session.SessionID, request.ID)
+
+	go sendToClient(client, "join_pending", map[string]interface{}{
+		"request_id": request.ID,
+		"session_id": session.SessionID,
+		"room_id":    session.RoomID,
+		"expires_at": request.ExpiresAt,
+	})
+
+	var username string
+	DB.Model(&models.User{}).Select("username").Where("id = ?", client.userID).Scan(&username)
+	broadcastToDeciders(session, "join_request", map[string]interface{}{
+		"request_id":   request.ID,
+		"session_id":   session.SessionID,
+		"user_id":      client.userID,
+		"username":     username,
+		"requested_at": request.RequestedAt,
+		"expires_at":   request.ExpiresAt,
+	})
+}
+
+// closeJoinRequest settles a pending join request and lets its client in, or sends it
+// away. by is the user who decided, or 0. It returns false when the request was no longer
+// pending.
+func closeJoinRequest(requestID uint, status string, by uint) bool {
+	now := time.Now()
+	updates := map[string]interface{}{"status": status, "decided_at": now}
+	if by != 0 {
+		updates["decided_by"] = by
+	}
+	res := DB.Model(&models.SessionJoinRequest{}).
+		Where("id = ? AND status = ?", requestID, models.JoinRequestPending).
+		Updates(updates)
+	if res.Error != nil {
+		log.Printf("❌ closeJoinRequest: Failed to close request %d: %v", requestID, res.Error)
+		return false
+	}
+	if res.RowsAffected == 0 {
+		return false
+	}
+
+	var request models.SessionJoinRequest
+	if err := DB.First(&request, requestID).Error; err != nil {
+		return false
+	}
+	var session models.WatchSession
+	DB.Where("session_id = ?", request.SessionID).First(&session)
+
+	var client *Client
+	lobbyMu.Lock()
+	for c, wait := range lobbyClients {
+		if wait.requestID != 0 && wait.requestID == requestID {
+			client = c
+			wait.timer.Stop()
+			delete(lobbyClients, c)
+			break
+		}
+	}
+	lobbyMu.Unlock()
+
+	if client != nil {
+		data := map[string]interface{}{
+			"request_id": requestID,
+			"session_id": request.SessionID,
+		}
+		switch status {
+		case models.JoinRequestApproved:
+			sendToClient(client, "join_approved", data)
+			joined, err := client.joinUnlessFull(&session)
+			if err != nil {
+				log.Printf("❌ closeJoinRequest: Failed to join user %d to session %s: %v", client.userID, session.SessionID, err)
+			}
+			if joined {
+				client.enterSession(&session)
+			} else {
+				client.joinWaitlist(&session)
+			}
+		case models.JoinRequestDenied, models.JoinRequestExpired:
+			msgType := "join_denied"
+			if status == models.JoinRequestExpired {
+				msgType = "join_expired"
+			}
+			sendToClient(client, msgType, data)
+			time.AfterFunc(lobbyCloseDelay, func() { client.conn.Close() })
+		}
+	}
+	log.Printf("🚪 closeJoinRequest: Request %d of user %d for session %s %s", requestID, request.UserID, request.SessionID, status)
+
+	if session.ID != 0 {
+		data := map[string]interface{}{
+			"request_id": requestID,
+			"session_id": request.SessionID,
+			"user_id":    request.UserID,
+			"status":     status,
+		}
+		if by != 0 {
+			data["decided_by"] = by
+		}
+		broadcastToDeciders(&session, "join_request_closed", data)
+	}
+	return true
+}
+
+// admitFromLobby joins an approved client to the session and the room.
+func (client *Client) admitFromLobby(session *models.WatchSession) {
+	if err := hub.JoinWatchSession(session.SessionID, client); err != nil {
+		log.Printf("❌ admitFromLobby: Failed to join user %d to session %s: %v", client.userID, session.SessionID, err)
+	}
+	client.enterSession(session)
+}
+
+// enterSession sends a client that just joined the session its status and enters the room.
+func (client *Client) enterSession(session *models.WatchSession) {
+	if status := sessionStatusMessage(client.roomID, session); status.Data != nil {
+		select {
+		case client.send <- status:
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	hub.enterRoom(client)
+}
+
+// leaveLobby cancels the client's join request, or drops it from the waitlist, when it
+// disconnects while waiting.
+func (client *Client) leaveLobby() {
+	lobbyMu.Lock()
+	delete(heldOutClients, client)
+	wait, ok := lobbyClients[client]
+	if ok && wait.waitlistID != 0 {
+		delete(lobbyClients, client)
+	}
+	lobbyMu.Unlock()
+	if !ok {
+		return
+	}
+	if wait.waitlistID != 0 {
+		leaveWaitlist(wait)
+	} else {
+		closeJoinRequest(wait.requestID, models.JoinRequestCancelled, 0)
+	}
+}
+
+// closeSessionLobby sends away everyone still waiting for an ended session and lets the
+// room sockets held out of it back in.
+func closeSessionLobby(sessionID string) {
+	releaseHeldOut(sessionID)
+	var pending []uint
+	DB.Model(&models.SessionJoinRequest{}).
+		Where("session_id = ? AND status = ?", sessionID, models.JoinRequestPending).
+		Pluck("id", &pending)
+	for _, id := range pending {
+		closeJoinRequest(id, models.JoinRequestExpired, 0)
+	}
+	closeSessionWaitlist(sessionID)
+}
+
+// handleLobbyMessage handles what a client in the lobby may send: "cancel_join" or
+// "leave_waitlist" to give up, and "lobby_chat".
+func (client *Client) handleLobbyMessage(msg WebSocketMessage) {
+	switch msg.Type {
+	case "cancel_join", "leave_waitlist":
+		client.leaveLobby()
+		client.conn.Close()
+	case "lobby_chat":
+		client.handleLobbyChat(msg)
+	default:
+		log.Printf("[lobby] Ignoring '%s' from user %d waiting in the lobby", msg.Type, client.userID)
+	}
+}
+
+// handleJoinDecision handles "approve_join" and "deny_join" ({"request_id": N}).
+func (client *Client) handleJoinDecision(msg WebSocketMessage) {
+	var data struct {
+		RequestID uint `json:"request_id"`
+	}
+	raw, _ := json.Marshal(msg.Data)
+	if err := json.Unmarshal(raw, &data); err != nil || data.RequestID == 0 {
+		log.Printf("[%s] ❌ Invalid data from user %d: %v", msg.Type, client.userID, err)
+		return
+	}
+	var request models.SessionJoinRequest
+	if err := DB.First(&request, data.RequestID).Error; err != nil {
+		log.Printf("[%s] ❌ Join request %d not found", msg.Type, data.RequestID)
+		return
+	}
+	var session models.WatchSession
+	if err := DB.Where("session_id = ? AND ended_at IS NULL", request.SessionID).First(&session).Error; err != nil {
+		log.Printf("[%s] ❌ Session %s is not running", msg.Type, request.SessionID)
+		return
+	}
+	if !canDecideJoin(&session, client.userID) {
+		log.Printf("[%s] ❌ User %d may not decide on requests for session %s", msg.Type, client.userID, session.SessionID)
+		return
+	}
+
+	status := models.JoinRequestApproved
+	if msg.Type == "deny_join" {
+		status = models.JoinRequestDenied
+	}
+	if !closeJoinRequest(request.ID, status, client.userID) {
+		log.Printf("[%s] ⚠️ Join request %d was already %s", msg.Type, request.ID, request.Status)
+	}
+}
+
+// handleSetJoinApproval handles "set_join_approval" ({"required": bool}) from the session
+// host or the room host. Turning approval off lets everyone waiting in.
+func (client *Client) handleSetJoinApproval(msg WebSocketMessage) {
+	var data struct {
+		Required bool `json:"required"`
+	}
+	raw, _ := json.Marshal(msg.Data)
+	if err := json.Unmarshal(raw, &data); err != nil {
+		log.Printf("[set_join_approval] ❌ Invalid data from user %d: %v", client.userID, err)
+		return
+	}
+	var session models.WatchSession
+	if err := DB.Where("room_id = ? AND ended_at IS NULL", client.roomID).First(&session).Error; err != nil {
+		log.Printf("[set_join_approval] ❌ No running session in room %d", client.roomID)
+		return
+	}
+	if session.HostID != client.userID {
+		var room models.Room
+		if err := DB.First(&room, session.RoomID).Error; err != nil || room.HostID != client.userID {
+			log.Printf("[set_join_approval] ❌ User %d is not the host of session %s", client.userID, session.SessionID)
+			return
+		}
+	}
+
+	if err := DB.Model(&models.WatchSession{}).Where("id = ?", session.ID).Update("requires_approval", data.Required).Error; err != nil {
+		log.Printf("[set_join_approval] ❌ Failed to update session %s: %v", session.SessionID, err)
+		return
+	}
+	hub.sessionMutex.Lock()
+	if cached, ok := hub.activeSessions[session.SessionID]; ok {
+		cached.RequiresApproval = data.Required
+	}
+	hub.sessionMutex.Unlock()
+
+	if !data.Required {
+		var pending []uint
+		DB.Model(&models.SessionJoinRequest{}).
+			Where("session_id = ? AND status = ?", session.SessionID, models.JoinRequestPending).
+			Pluck("id", &pending)
+		for _, id := range pending {
+			closeJoinRequest(id, models.JoinRequestApproved, client.userID)
+		}
+	}
+
+	if msgBytes, err := json.Marshal(map[string]interface{}{
+		"type": "join_approval_updated",
+		"data": map[string]interface{}{
+			"session_id": session.SessionID,
+			"required":   data.Required,
+		},
+	}); err == nil {
+		hub.BroadcastToRoom(session.RoomID, OutgoingMessage{Data: msgBytes, IsBinary: false}, nil)
+	}
+}
+
+// GetSessionJoinRequestsHandler handles GET /api/rooms/watch-sessions/:session_id/join-requests
+// Returns the session's pending join requests, for the users who can decide on them.
+func GetSessionJoinRequestsHandler(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var session models.WatchSession
+	if err := DB.Where("session_id = ? AND ended_at IS NULL", c.Param("session_id")).First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+	if !canDecideJoin(&session, userID.(uint)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the host can see join requests"})
+		return
+	}
+
+	type joinRequestEntry struct {
+		models.SessionJoinRequest
+		Username string `json:"username"`
+	}
+	var entries []joinRequestEntry
+	if err := DB.Table("session_join_requests AS r").
+		Select("r.*, u.username").
+		Joins("LEFT JOIN users u ON u.id = r.user_id").
+		Where("r.session_id = ? AND r.status = ?", session.SessionID, models.JoinRequestPending).
+		Order("r.requested_at ASC").
+		Scan(&entries).Error; err != nil {
+		log.Printf("GetSessionJoinRequestsHandler: Query failed for session %s: %v", session.SessionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load join requests"})
+		return
+	}
+	if entries == nil {
+		entries = []joinRequestEntry{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"requests":          entries,
+		"count":             len(entries),
+		"session_id":        session.SessionID,
+		"requires_approval": session.RequiresApproval,
+	})
+}
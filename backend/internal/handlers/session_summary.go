@@ -0,0 +1,353 @@
+// WeWatch/backend/internal/handlers/session_summary.go
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"wewatch-backend/internal/models"
+)
+
+// Session recaps. Ending a session writes a SessionSummary (the "summary" end hook runs
+// before the session's chat is deleted), so hosts can look back at who came, for how long
+// and what was watched. Items watched come from SessionMediaPlay rows recorded whenever a
+// "play" goes out in a running session.
+const topSessionReactions = 5
+
+// recordMediaPlay notes that an item started playing in the room's running session. A
+// repeated play of the item that is already playing (a re-sync) is not recorded again.
+func recordMediaPlay(roomID, mediaItemID uint, filePath, title string) {
+	var session models.WatchSession
+	if err := DB.Where("room_id = ? AND ended_at IS NULL", roomID).First(&session).Error; err != nil {
+		return
+	}
+	var last models.SessionMediaPlay
+	if err := DB.Where("session_id = ?", session.SessionID).Order("id DESC").First(&last).Error; err == nil &&
+		last.MediaItemID == mediaItemID && last.FilePath == filePath {
+		return
+	}
+	play := models.SessionMediaPlay{
+		SessionID:   session.SessionID,
+		MediaItemID: mediaItemID,
+		FilePath:    filePath,
+		Title:       title,
+		StartedAt:   time.Now(),
+	}
+	if err := DB.Create(&play).Error; err != nil {
+		log.Printf("⚠️ recordMediaPlay: Failed to record play in session %s: %v", session.SessionID, err)
+	}
+}
+
+// observeMediaPlay records the item a playback_control "play" starts. Library items are
+// matched by file path; anything else (temporary uploads) is kept by name only.
+func (client *Client) observeMediaPlay(message []byte) {
+	var control struct {
+		Command      string `json:"command"`
+		FilePath     string `json:"file_path"`
+		OriginalName string `json:"original_name"`
+	}
+	if err := json.Unmarshal(message, &control); err != nil || control.Command != "play" || control.FilePath == "" {
+		return
+	}
+	var item models.MediaItem
+	if err := DB.Where("room_id = ? AND file_path = ?", client.roomID, control.FilePath).First(&item).Error; err == nil {
+		recordMediaPlay(client.roomID, item.ID, item.FilePath, mediaItemTitle(&item))
+		return
+	}
+	recordMediaPlay(client.roomID, 0, control.FilePath, control.OriginalName)
+}
+
+// mediaItemTitle is the name a library item is shown under.
+func mediaItemTitle(item *models.MediaItem) string {
+	if item.Title != "" {
+		return item.Title
+	}
+	return item.OriginalName
+}
+
+// presence is a span of time a user was in the session.
+type presence struct {
+	from, to time.Time
+}
+
+// mergePresence merges overlapping spans (a user reconnecting gets a new member row while
+// the old one may not have been closed yet).
+func mergePresence(spans []presence) []presence {
+	sort.Slice(spans, func(i, j int) bool { return spans[i].from.Before(spans[j].from) })
+	var merged []presence
+	for _, s := range spans {
+		if n := len(merged); n > 0 && !s.from.After(merged[n-1].to) {
+			if s.to.After(merged[n-1].to) {
+				merged[n-1].to = s.to
+			}
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
+
+// concurrentViewers returns the peak and the time-weighted average number of users
+// present between start and end.
+func concurrentViewers(spans []presence, start, end time.Time) (int, float64) {
+	type edge struct {
+		at    time.Time
+		delta int
+	}
+	var edges []edge
+	for _, s := range spans {
+		edges = append(edges, edge{s.from, 1}, edge{s.to, -1})
+	}
+	// Leaving before joining at the same instant, so back-to-back spans don't count twice
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].at.Equal(edges[j].at) {
+			return edges[i].delta < edges[j].delta
+		}
+		return edges[i].at.Before(edges[j].at)
+	})
+
+	peak, current := 0, 0
+	var weighted float64
+	last := start
+	for _, e := range edges {
+		if e.at.After(last) {
+			weighted += float64(current) * e.at.Sub(last).Seconds()
+			last = e.at
+		}
+		current += e.delta
+		if current > peak {
+			peak = current
+		}
+	}
+	total := end.Sub(start).Seconds()
+	if total <= 0 {
+		return peak, float64(peak)
+	}
+	return peak, math.Round(weighted/total*100) / 100
+}
+
+// summarizeSession is the "summary" end hook: it writes the session's SessionSummary from
+// its members, plays, chat, reactions and theaters.
+func summarizeSession(tx *gorm.DB, end *SessionEnd) error {
+	session := end.Session
+	startedAt := session.StartedAt
+	if startedAt.IsZero() {
+		startedAt = session.CreatedAt
+	}
+	summary := models.SessionSummary{
+		SessionID:       session.SessionID,
+		RoomID:          session.RoomID,
+		HostID:          session.HostID,
+		WatchType:       session.WatchType,
+		StartedAt:       startedAt,
+		EndedAt:         end.EndedAt,
+		EndReason:       end.Reason,
+		DurationSeconds: math.Round(end.EndedAt.Sub(startedAt).Seconds()*1000) / 1000,
+	}
+
+	// Members and time present. endSessionMembers has run, so every row has a LeftAt.
+	var members []models.WatchSessionMember
+	if err := tx.Where("watch_session_id = ?", session.ID).Find(&members).Error; err != nil {
+		return err
+	}
+	spansByUser := map[uint][]presence{}
+	for _, m := range members {
+		to := end.EndedAt
+		if m.LeftAt != nil && m.LeftAt.Before(to) {
+			to = *m.LeftAt
+		}
+		from := m.JoinedAt
+		if from.Before(startedAt) {
+			from = startedAt
+		}
+		if to.Before(from) {
+			to = from
+		}
+		spansByUser[m.UserID] = append(spansByUser[m.UserID], presence{from, to})
+	}
+	userIDs := make([]uint, 0, len(spansByUser))
+	for userID := range spansByUser {
+		userIDs = append(userIDs, userID)
+	}
+	usernames := map[uint]string{}
+	if len(userIDs) > 0 {
+		var users []models.User
+		if err := tx.Select("id", "username").Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+			return err
+		}
+		for _, u := range users {
+			usernames[u.ID] = u.Username
+		}
+	}
+	var allSpans []presence
+	for userID, spans := range spansByUser {
+		merged := mergePresence(spans)
+		var seconds float64
+		for _, s := range merged {
+			seconds += s.to.Sub(s.from).Seconds()
+		}
+		summary.Members = append(summary.Members, models.SessionSummaryMember{
+			UserID:         userID,
+			Username:       usernames[userID],
+			FirstJoinedAt:  merged[0].from,
+			SecondsPresent: math.Round(seconds*1000) / 1000,
+		})
+		allSpans = append(allSpans, merged...)
+	}
+	sort.Slice(summary.Members, func(i, j int) bool {
+		return summary.Members[i].FirstJoinedAt.Before(summary.Members[j].FirstJoinedAt)
+	})
+	summary.PeakViewers, summary.AverageViewers = concurrentViewers(allSpans, startedAt, end.EndedAt)
+
+	// Items watched
+	var plays []models.SessionMediaPlay
+	if err := tx.Where("session_id = ?", session.SessionID).Order("started_at ASC, id ASC").Find(&plays).Error; err != nil {
+		return err
+	}
+	for _, p := range plays {
+		summary.ItemsWatched = append(summary.ItemsWatched, models.SessionSummaryItem{
+			MediaItemID: p.MediaItemID,
+			Title:       p.Title,
+			StartedAt:   p.StartedAt,
+		})
+	}
+
+	// Chat and reactions
+	var messageCount int64
+	if err := tx.Model(&models.ChatMessage{}).Where("session_id = ?", session.SessionID).Count(&messageCount).Error; err != nil {
+		return err
+	}
+	summary.MessageCount = int(messageCount)
+	if err := tx.Model(&models.Reaction{}).
+		Select("emoji, COUNT(*) AS count").
+		Where("session_id = ? OR message_id IN (?)", session.SessionID,
+			tx.Model(&models.ChatMessage{}).Select("id").Where("session_id = ?", session.SessionID)).
+		Group("emoji").
+		Order("count DESC, emoji ASC").
+		Limit(topSessionReactions).
+		Scan(&summary.TopReactions).Error; err != nil {
+		return err
+	}
+
+	if session.WatchType == "3d_cinema" {
+		var theaters int64
+		if err := tx.Model(&models.Theater{}).Where("watch_session_id = ?", session.ID).Count(&theaters).Error; err != nil {
+			return err
+		}
+		summary.TheaterCount = int(theaters)
+	}
+
+	if err := tx.Create(&summary).Error; err != nil {
+		return err
+	}
+	return tx.Where("session_id = ?", session.SessionID).Delete(&models.SessionMediaPlay{}).Error
+}
+
+// hostedSummaries starts a query for the summaries of sessions userID hosted or that ran
+// in a room they host.
+func hostedSummaries(userID uint) *gorm.DB {
+	return DB.Model(&models.SessionSummary{}).
+		Where("(host_id = ? OR room_id IN (?))", userID, DB.Unscoped().Model(&models.Room{}).Select("id").Where("host_id = ?", userID))
+}
+
+// listSessionSummaries writes one page of query's summaries, newest first.
+func listSessionSummaries(c *gin.Context, query *gorm.DB, caller string) {
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		log.Printf("%s: Count failed: %v", caller, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load session summaries"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultLibraryPage)))
+	if pageSize < 1 {
+		pageSize = defaultLibraryPage
+	}
+	if pageSize > maxLibraryPage {
+		pageSize = maxLibraryPage
+	}
+
+	var summaries []models.SessionSummary
+	if err := query.Order("ended_at DESC, id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&summaries).Error; err != nil {
+		log.Printf("%s: Query failed: %v", caller, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load session summaries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"summaries":   summaries,
+		"count":       len(summaries),
+		"total":       total,
+		"page":        page,
+		"page_size":   pageSize,
+		"total_pages": int(math.Ceil(float64(total) / float64(pageSize))),
+	})
+}
+
+// GetRoomSessionSummariesHandler handles GET /api/rooms/:id/session-summaries
+// Returns the recaps of the room's past sessions (room host only)
+func GetRoomSessionSummariesHandler(c *gin.Context) {
+	userID, room, ok := requireRoomAccess(c)
+	if !ok {
+		return
+	}
+	if room.HostID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the room host can view session summaries"})
+		return
+	}
+	listSessionSummaries(c, DB.Model(&models.SessionSummary{}).Where("room_id = ?", room.ID), "GetRoomSessionSummariesHandler")
+}
+
+// GetSessionSummariesHandler handles GET /api/sessions/summaries
+// Returns the recaps of sessions the user hosted, including instant watches whose
+// temporary room is gone
+func GetSessionSummariesHandler(c *gin.Context) {
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID, ok := userIDValue.(uint)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+	listSessionSummaries(c, hostedSummaries(userID), "GetSessionSummariesHandler")
+}
+
+// GetSessionSummaryHandler handles GET /api/sessions/summaries/:session_id
+func GetSessionSummaryHandler(c *gin.Context) {
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID, ok := userIDValue.(uint)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+
+	var summary models.SessionSummary
+	if err := hostedSummaries(userID).Where("session_id = ?", c.Param("session_id")).First(&summary).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session summary not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"summary": summary})
+}
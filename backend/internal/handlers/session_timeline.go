@@ -0,0 +1,360 @@
+// WeWatch/backend/internal/handlers/session_timeline.go
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"wewatch-backend/internal/models"
+)
+
+// Session timelines. As events flow through the hub (playback_control, chat, reactions,
+// members joining and leaving, RoomTV content) the server appends them to the session's
+// timeline, each stamped with the wall clock and the media position. The position comes
+// from a per-session media clock that follows the playback commands. Someone watching the
+// session later fetches the timeline and replays chat and reactions against the video.
+const (
+	defaultTimelinePage = 500
+	maxTimelinePage     = 2000
+)
+
+// mediaClock is where a session's media is, as far as the playback commands tell.
+type mediaClock struct {
+	mediaItemID uint
+	filePath    string
+	playing     bool
+	position    float64   // seconds into the media...
+	at          time.Time // ...as of this moment
+}
+
+var (
+	timelineMu     sync.Mutex
+	timelineClocks = map[string]*mediaClock{} // session_id → clock
+)
+
+// positionAt returns the media position at t.
+func (m *mediaClock) positionAt(t time.Time) float64 {
+	if !m.playing || t.Before(m.at) {
+		return m.position
+	}
+	return m.position + t.Sub(m.at).Seconds()
+}
+
+// runningSessionID returns the session_id of the room's running session, or "".
+func runningSessionID(roomID uint) string {
+	var session models.WatchSession
+	if err := DB.Select("session_id").Where("room_id = ? AND ended_at IS NULL", roomID).First(&session).Error; err != nil {
+		return ""
+	}
+	return session.SessionID
+}
+
+// recordTimelineEvent appends an event to its session's timeline, stamping it with the
+// current media position unless it carries its own.
+func recordTimelineEvent(event models.SessionTimelineEvent) {
+	if event.SessionID == "" {
+		return
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+	if event.MediaPosition == nil {
+		timelineMu.Lock()
+		if clock, ok := timelineClocks[event.SessionID]; ok {
+			position := clock.positionAt(event.OccurredAt)
+			event.MediaPosition = &position
+			event.MediaItemID = clock.mediaItemID
+			event.FilePath = clock.filePath
+			event.Playing = clock.playing
+		}
+		timelineMu.Unlock()
+	}
+	if err := DB.Create(&event).Error; err != nil {
+		log.Printf("⚠️ recordTimelineEvent: Failed to record %s in session %s: %v", event.Kind, event.SessionID, err)
+	}
+}
+
+// timelineSessionID is the session a client's events belong to: the one it joined, or the
+// room's running session when it connected before there was one.
+func (client *Client) timelineSessionID() string {
+	if client.streamID != "" {
+		return client.streamID
+	}
+	return runningSessionID(client.roomID)
+}
+
+// recordTimelinePlayback moves the session's media clock by a playback_control message and
+// records the command. Commands that don't name a position continue from the clock.
+func recordTimelinePlayback(sessionID string, roomID, userID uint, message []byte) {
+	var control struct {
+		Command     string   `json:"command"`
+		MediaItemID uint     `json:"media_item_id"`
+		FilePath    string   `json:"file_path"`
+		SeekTime    *float64 `json:"seek_time"`
+	}
+	if sessionID == "" || json.Unmarshal(message, &control) != nil || control.Command == "" {
+		return
+	}
+
+	now := time.Now()
+	timelineMu.Lock()
+	clock := &mediaClock{at: now}
+	if prev, ok := timelineClocks[sessionID]; ok {
+		clock = &mediaClock{mediaItemID: prev.mediaItemID, filePath: prev.filePath, playing: prev.playing, position: prev.positionAt(now), at: now}
+	}
+	if control.Command == "play" {
+		clock.mediaItemID, clock.filePath, clock.position = control.MediaItemID, control.FilePath, 0
+	}
+	if control.SeekTime != nil {
+		clock.position = *control.SeekTime
+	}
+	switch control.Command {
+	case "play", "resume":
+		clock.playing = true
+	case "pause", "stop":
+		clock.playing = false
+	}
+	timelineClocks[sessionID] = clock
+	event := models.SessionTimelineEvent{
+		SessionID:   sessionID,
+		RoomID:      roomID,
+		Kind:        models.TimelinePlayback,
+		UserID:      userID,
+		OccurredAt:  now,
+		MediaItemID: clock.mediaItemID,
+		FilePath:    clock.filePath,
+		Playing:     clock.playing,
+		Command:     control.Command,
+	}
+	position := clock.position
+	event.MediaPosition = &position
+	timelineMu.Unlock()
+
+	recordTimelineEvent(event)
+}
+
+// forgetTimelineClock drops the media clock of an ended session.
+func forgetTimelineClock(sessionID string) {
+	timelineMu.Lock()
+	delete(timelineClocks, sessionID)
+	timelineMu.Unlock()
+}
+
+// recordTimelineMember records a member joining or leaving a session.
+func recordTimelineMember(sessionID string, roomID, userID uint, kind string) {
+	recordTimelineEvent(models.SessionTimelineEvent{SessionID: sessionID, RoomID: roomID, Kind: kind, UserID: userID})
+}
+
+// recordTimelineTVContent records RoomTV content shown in (or removed from) the room's
+// running session.
+func recordTimelineTVContent(content *models.RoomTVContent, userID uint, action string) {
+	data := map[string]interface{}{
+		"action":     action,
+		"content_id": content.ID,
+	}
+	if action == "created" {
+		data["content_type"] = content.ContentType
+		data["title"] = content.Title
+		data["description"] = content.Description
+		data["content_url"] = content.ContentURL
+		data["thumbnail_url"] = content.ThumbnailURL
+		data["animation_type"] = content.AnimationType
+		data["ends_at"] = content.EndsAt
+	}
+	recordTimelineEvent(models.SessionTimelineEvent{
+		SessionID: runningSessionID(content.RoomID),
+		RoomID:    content.RoomID,
+		Kind:      models.TimelineTVContent,
+		UserID:    userID,
+		Data:      data,
+	})
+}
+
+// TimelineEntry is a timeline event as the API returns it: chat and reaction events carry
+// the message or reaction itself.
+type TimelineEntry struct {
+	models.SessionTimelineEvent
+	OffsetSeconds float64              `json:"offset_seconds"` // since the session started
+	Username      string               `json:"username,omitempty"`
+	Message       *TimelineChatMessage `json:"message,omitempty"`
+	Reaction      *TimelineReaction    `json:"reaction,omitempty"`
+}
+
+// TimelineChatMessage is the chat message of a chat event, as it is now (edits and host
+// deletions show).
+type TimelineChatMessage struct {
+	ID            uint      `json:"id"`
+	Message       string    `json:"message"`
+	DeletedByHost bool      `json:"deleted_by_host"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TimelineReaction is the reaction of a reaction event.
+type TimelineReaction struct {
+	ID        uint   `json:"id"`
+	MessageID uint   `json:"message_id"`
+	Emoji     string `json:"emoji"`
+}
+
+// GetSessionTimelineHandler handles GET /api/rooms/:id/sessions/:session_id/timeline
+// Returns the session's timeline in order, paginated. Filters: kinds (comma-separated),
+// media_item_id or file_path (only events while that media was on). Chat and reaction
+// events whose message or reaction has since been deleted (retention, the author) are
+// left out.
+func GetSessionTimelineHandler(c *gin.Context) {
+	_, room, ok := requireRoomAccess(c)
+	if !ok {
+		return
+	}
+	var session models.WatchSession
+	if err := DB.Where("room_id = ? AND session_id = ?", room.ID, c.Param("session_id")).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	query := DB.Model(&models.SessionTimelineEvent{}).Where("session_id = ?", session.SessionID)
+	if v := c.Query("kinds"); v != "" {
+		query = query.Where("kind IN ?", strings.Split(v, ","))
+	}
+	if v := c.Query("media_item_id"); v != "" {
+		itemID, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid media_item_id"})
+			return
+		}
+		query = query.Where("media_item_id = ?", itemID)
+	}
+	if v := c.Query("file_path"); v != "" {
+		query = query.Where("file_path = ?", v)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		log.Printf("GetSessionTimelineHandler: Count failed for session %s: %v", session.SessionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load timeline"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultTimelinePage)))
+	if pageSize < 1 {
+		pageSize = defaultTimelinePage
+	}
+	if pageSize > maxTimelinePage {
+		pageSize = maxTimelinePage
+	}
+
+	var events []models.SessionTimelineEvent
+	if err := query.Order("occurred_at ASC, id ASC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&events).Error; err != nil {
+		log.Printf("GetSessionTimelineHandler: Query failed for session %s: %v", session.SessionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load timeline"})
+		return
+	}
+	entries, err := timelineEntries(&session, events)
+	if err != nil {
+		log.Printf("GetSessionTimelineHandler: Failed to load messages for session %s: %v", session.SessionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load timeline"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session_id":  session.SessionID,
+		"started_at":  session.StartedAt,
+		"ended_at":    session.EndedAt,
+		"events":      entries,
+		"count":       len(entries),
+		"total":       total,
+		"page":        page,
+		"page_size":   pageSize,
+		"total_pages": int(math.Ceil(float64(total) / float64(pageSize))),
+	})
+}
+
+// timelineEntries attaches usernames, chat messages and reactions to timeline events.
+func timelineEntries(session *models.WatchSession, events []models.SessionTimelineEvent) ([]TimelineEntry, error) {
+	var messageIDs, reactionIDs []uint
+	userIDs := map[uint]bool{}
+	for _, e := range events {
+		if e.ChatMessageID != 0 {
+			messageIDs = append(messageIDs, e.ChatMessageID)
+		}
+		if e.ReactionID != 0 {
+			reactionIDs = append(reactionIDs, e.ReactionID)
+		}
+		if e.UserID != 0 {
+			userIDs[e.UserID] = true
+		}
+	}
+
+	messages := map[uint]*TimelineChatMessage{}
+	if len(messageIDs) > 0 {
+		var rows []models.ChatMessage
+		if err := DB.Where("id IN ?", messageIDs).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, m := range rows {
+			messages[m.ID] = &TimelineChatMessage{ID: m.ID, Message: m.Message, DeletedByHost: m.DeletedByHost, CreatedAt: m.CreatedAt}
+		}
+	}
+	reactions := map[uint]*TimelineReaction{}
+	if len(reactionIDs) > 0 {
+		var rows []models.Reaction
+		if err := DB.Where("id IN ?", reactionIDs).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			reactions[r.ID] = &TimelineReaction{ID: r.ID, MessageID: r.MessageID, Emoji: r.Emoji}
+		}
+	}
+	usernames := map[uint]string{}
+	if len(userIDs) > 0 {
+		ids := make([]uint, 0, len(userIDs))
+		for id := range userIDs {
+			ids = append(ids, id)
+		}
+		var users []models.User
+		if err := DB.Select("id", "username").Where("id IN ?", ids).Find(&users).Error; err != nil {
+			return nil, err
+		}
+		for _, u := range users {
+			usernames[u.ID] = u.Username
+		}
+	}
+
+	entries := make([]TimelineEntry, 0, len(events))
+	for _, e := range events {
+		entry := TimelineEntry{
+			SessionTimelineEvent: e,
+			OffsetSeconds:        math.Round(e.OccurredAt.Sub(session.StartedAt).Seconds()*1000) / 1000,
+			Username:             usernames[e.UserID],
+		}
+		switch e.Kind {
+		case models.TimelineChat:
+			if entry.Message = messages[e.ChatMessageID]; entry.Message == nil {
+				continue
+			}
+		case models.TimelineReaction:
+			if entry.Reaction = reactions[e.ReactionID]; entry.Reaction == nil {
+				continue
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
@@ -0,0 +1,478 @@
+// WeWatch/backend/internal/handlers/session_waitlist.go
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"wewatch-backend/internal/models"
+)
+
+// Session capacity. The host can cap a session's viewers (MaxViewers) and, for 3D cinema,
+// its theaters (MaxTheaters × theaterSeats seats). Viewers who connect to a full session
+// wait on its waitlist in the lobby (see session_lobby.go): they see their position live,
+// still get RoomTV content and can talk in the lobby chat. Whenever someone leaves, the
+// first in line is admitted. The session host and deciders (canDecideJoin) are never
+// held back and the session host doesn't count against the cap.
+const maxLobbyChatLength = 1000
+
+// waitlistMu serializes admissions, so two leaves don't admit past the cap
+var waitlistMu sync.Mutex
+
+// sessionCapacity returns how many viewers the session takes, or 0 for no limit.
+func sessionCapacity(session *models.WatchSession) int {
+	capacity := session.MaxViewers
+	if session.WatchType == "3d_cinema" && session.MaxTheaters > 0 {
+		if seats := session.MaxTheaters * theaterSeats; capacity == 0 || seats < capacity {
+			capacity = seats
+		}
+	}
+	return capacity
+}
+
+// activeViewerCount returns how many users other than the session host are in the session.
+func activeViewerCount(session *models.WatchSession) int64 {
+	var count int64
+	DB.Model(&models.WatchSessionMember{}).
+		Where("watch_session_id = ? AND is_active = ? AND user_id <> ?", session.ID, true, session.HostID).
+		Distinct("user_id").
+		Count(&count)
+	return count
+}
+
+// sessionIsFull reports whether userID has to wait on the session's waitlist. Viewers
+// queue behind the waitlist even when a seat is free, until it has been admitted.
+func sessionIsFull(session *models.WatchSession, userID uint) bool {
+	capacity := sessionCapacity(session)
+	if capacity == 0 || canDecideJoin(session, userID) {
+		return false
+	}
+	var count int64
+	DB.Model(&models.WatchSessionMember{}).
+		Where("watch_session_id = ? AND user_id = ? AND is_active = ?", session.ID, userID, true).
+		Count(&count)
+	if count > 0 {
+		return false // already in, from another tab
+	}
+	DB.Model(&models.SessionWaitlistEntry{}).
+		Where("session_id = ? AND status = ?", session.SessionID, models.WaitlistWaiting).
+		Count(&count)
+	return count > 0 || activeViewerCount(session) >= int64(capacity)
+}
+
+// joinUnlessFull joins the client to the session unless it has to wait on the waitlist,
+// and reports whether it joined. The check and the join hold waitlistMu like admissions
+// do, so concurrent joins can't pass the cap together.
+func (client *Client) joinUnlessFull(session *models.WatchSession) (bool, error) {
+	waitlistMu.Lock()
+	defer waitlistMu.Unlock()
+	if sessionIsFull(session, client.userID) {
+		return false, nil
+	}
+	return true, hub.JoinWatchSession(session.SessionID, client)
+}
+
+// joinWaitlist queues the client for the session and keeps it in the lobby.
+func (client *Client) joinWaitlist(session *models.WatchSession) {
+	entry := models.SessionWaitlistEntry{
+		SessionID: session.SessionID,
+		RoomID:    session.RoomID,
+		UserID:    client.userID,
+		Status:    models.WaitlistWaiting,
+		QueuedAt:  time.Now(),
+	}
+	if err := DB.Create(&entry).Error; err != nil {
+		log.Printf("❌ joinWaitlist: Failed to queue user %d for session %s: %v", client.userID, session.SessionID, err)
+		return
+	}
+
+	lobbyMu.Lock()
+	lobbyClients[client] = &lobbyWait{sessionID: session.SessionID, waitlistID: entry.ID}
+	lobbyMu.Unlock()
+	log.Printf("⏳ joinWaitlist: User %d is waiting for a place in session %s (entry %d)", client.userID, session.SessionID, entry.ID)
+
+	// What's on RoomTV right now; later changes arrive through broadcastToLobby
+	var tv []models.RoomTVContent
+	DB.Where("room_id = ? AND ends_at > ? AND (session_id = ? OR session_id IS NULL)", session.RoomID, time.Now(), session.ID).
+		Order("starts_at DESC").Limit(1).Find(&tv)
+	joined := map[string]interface{}{
+		"session_id":      session.SessionID,
+		"room_id":         session.RoomID,
+		"room_tv_content": nil,
+	}
+	if len(tv) > 0 {
+		joined["room_tv_content"] = tv[0]
+	}
+	go sendToClient(client, "waitlist_joined", joined)
+
+	broadcastWaitlist(session)
+	// Someone may have left between the capacity check and now
+	go admitFromWaitlist(session.SessionID)
+}
+
+// leaveWaitlist drops a waiting client's entry and moves the others up.
+func leaveWaitlist(wait *lobbyWait) {
+	res := DB.Model(&models.SessionWaitlistEntry{}).
+		Where("id = ? AND status = ?", wait.waitlistID, models.WaitlistWaiting).
+		Updates(map[string]interface{}{"status": models.WaitlistLeft, "left_at": time.Now()})
+	if res.Error != nil || res.RowsAffected == 0 {
+		return
+	}
+	var session models.WatchSession
+	if err := DB.Where("session_id = ?", wait.sessionID).First(&session).Error; err == nil {
+		broadcastWaitlist(&session)
+	}
+}
+
+// takeLobbyClient removes and returns the lobby client waiting on a waitlist entry.
+func takeLobbyClient(waitlistID uint) *Client {
+	lobbyMu.Lock()
+	defer lobbyMu.Unlock()
+	for c, wait := range lobbyClients {
+		if wait.waitlistID == waitlistID {
+			delete(lobbyClients, c)
+			return c
+		}
+	}
+	return nil
+}
+
+// admitFromWaitlist lets waiting viewers into the session while it has room, first in
+// line first.
+func admitFromWaitlist(sessionID string) {
+	waitlistMu.Lock()
+	defer waitlistMu.Unlock()
+
+	var session models.WatchSession
+	if err := DB.Where("session_id = ? AND ended_at IS NULL", sessionID).First(&session).Error; err != nil {
+		return
+	}
+	admitted := 0
+	for {
+		if capacity := sessionCapacity(&session); capacity != 0 && activeViewerCount(&session) >= int64(capacity) {
+			break
+		}
+		var entry models.SessionWaitlistEntry
+		if err := DB.Where("session_id = ? AND status = ?", sessionID, models.WaitlistWaiting).
+			Order("id ASC").First(&entry).Error; err != nil {
+			break
+		}
+		now := time.Now()
+		res := DB.Model(&models.SessionWaitlistEntry{}).
+			Where("id = ? AND status = ?", entry.ID, models.WaitlistWaiting).
+			Updates(map[string]interface{}{"status": models.WaitlistAdmitted, "admitted_at": now})
+		if res.Error != nil {
+			log.Printf("❌ admitFromWaitlist: Failed to admit entry %d: %v", entry.ID, res.Error)
+			break
+		}
+		client := takeLobbyClient(entry.ID)
+		if client == nil {
+			// Its client is gone without leaving the waitlist (server restart)
+			DB.Model(&models.SessionWaitlistEntry{}).Where("id = ?", entry.ID).
+				Updates(map[string]interface{}{"status": models.WaitlistLeft, "left_at": now})
+			continue
+		}
+		client.admitFromLobby(&session)
+		sendToClient(client, "waitlist_admitted", map[string]interface{}{
+			"session_id": sessionID,
+			"waited":     now.Sub(entry.QueuedAt).Seconds(),
+		})
+		admitted++
+		log.Printf("✅ admitFromWaitlist: User %d admitted to session %s", entry.UserID, sessionID)
+	}
+	if admitted > 0 {
+		broadcastWaitlist(&session)
+	}
+}
+
+// broadcastWaitlist tells every waiting client its position and the deciders how many
+// are waiting.
+func broadcastWaitlist(session *models.WatchSession) {
+	var entries []models.SessionWaitlistEntry
+	if err := DB.Where("session_id = ? AND status = ?", session.SessionID, models.WaitlistWaiting).
+		Order("id ASC").Find(&entries).Error; err != nil {
+		log.Printf("⚠️ broadcastWaitlist: Failed to load waitlist of session %s: %v", session.SessionID, err)
+		return
+	}
+	positions := make(map[uint]int, len(entries))
+	for i, e := range entries {
+		positions[e.ID] = i + 1
+	}
+
+	waiting := map[*Client]uint{}
+	lobbyMu.Lock()
+	for c, wait := range lobbyClients {
+		if wait.sessionID == session.SessionID && wait.waitlistID != 0 {
+			waiting[c] = wait.waitlistID
+		}
+	}
+	lobbyMu.Unlock()
+	for c, entryID := range waiting {
+		if position, ok := positions[entryID]; ok {
+			go sendToClient(c, "waitlist_position", map[string]interface{}{
+				"session_id": session.SessionID,
+				"position":   position,
+				"waiting":    len(entries),
+				"capacity":   sessionCapacity(session),
+			})
+		}
+	}
+
+	broadcastToDeciders(session, "waitlist_updated", map[string]interface{}{
+		"session_id": session.SessionID,
+		"waiting":    len(entries),
+		"capacity":   sessionCapacity(session),
+	})
+}
+
+// closeSessionWaitlist sends away everyone still on an ended session's waitlist.
+func closeSessionWaitlist(sessionID string) {
+	DB.Model(&models.SessionWaitlistEntry{}).
+		Where("session_id = ? AND status = ?", sessionID, models.WaitlistWaiting).
+		Updates(map[string]interface{}{"status": models.WaitlistClosed, "left_at": time.Now()})
+
+	var closed []*Client
+	lobbyMu.Lock()
+	for c, wait := range lobbyClients {
+		if wait.sessionID == sessionID && wait.waitlistID != 0 {
+			closed = append(closed, c)
+			delete(lobbyClients, c)
+		}
+	}
+	lobbyMu.Unlock()
+	for _, c := range closed {
+		client := c
+		sendToClient(client, "waitlist_closed", map[string]interface{}{"session_id": sessionID})
+		time.AfterFunc(lobbyCloseDelay, func() { client.conn.Close() })
+	}
+}
+
+// broadcastToLobby sends a message to every client in a room's lobby: viewers on the
+// waitlist and viewers waiting for approval. They aren't registered in the room, so room
+// broadcasts don't reach them.
+func broadcastToLobby(roomID uint, message []byte) {
+	var clients []*Client
+	lobbyMu.Lock()
+	for c := range lobbyClients {
+		if c.roomID == roomID {
+			clients = append(clients, c)
+		}
+	}
+	lobbyMu.Unlock()
+	for _, c := range clients {
+		func(c *Client) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("⚠️ broadcastToLobby: Client of user %d is gone: %v", c.userID, r)
+				}
+			}()
+			select {
+			case c.send <- OutgoingMessage{Data: message, IsBinary: false}:
+			default:
+				log.Printf("⚠️ broadcastToLobby: Dropping message for user %d (buffer full)", c.userID)
+			}
+		}(c)
+	}
+}
+
+// handleLobbyChat handles "lobby_chat" ({"message": "..."}) from a client in the lobby, or
+// from a decider answering the lobby. Lobby chat isn't stored: it reaches the lobby and the
+// deciders of the room's running session.
+func (client *Client) handleLobbyChat(msg WebSocketMessage) {
+	var data struct {
+		Message string `json:"message"`
+	}
+	raw, _ := json.Marshal(msg.Data)
+	if err := json.Unmarshal(raw, &data); err != nil {
+		log.Printf("[lobby_chat] ❌ Invalid data from user %d: %v", client.userID, err)
+		return
+	}
+	data.Message = strings.TrimSpace(data.Message)
+	if data.Message == "" || len(data.Message) > maxLobbyChatLength {
+		log.Printf("[lobby_chat] ❌ Empty or too long message from user %d", client.userID)
+		return
+	}
+
+	var session models.WatchSession
+	if err := DB.Where("room_id = ? AND ended_at IS NULL", client.roomID).First(&session).Error; err != nil {
+		log.Printf("[lobby_chat] ❌ No running session in room %d", client.roomID)
+		return
+	}
+	if !client.inLobby() && !canDecideJoin(&session, client.userID) {
+		log.Printf("[lobby_chat] ❌ User %d is neither in the lobby nor a host of session %s", client.userID, session.SessionID)
+		return
+	}
+
+	var username string
+	DB.Model(&models.User{}).Select("username").Where("id = ?", client.userID).Scan(&username)
+	msgBytes, err := json.Marshal(map[string]interface{}{
+		"type": "lobby_chat",
+		"data": map[string]interface{}{
+			"session_id": session.SessionID,
+			"user_id":    client.userID,
+			"username":   username,
+			"message":    data.Message,
+			"sent_at":    time.Now(),
+		},
+	})
+	if err != nil {
+		return
+	}
+	broadcastToLobby(client.roomID, msgBytes)
+	hub.BroadcastToUsers(joinDeciders(&session), OutgoingMessage{Data: msgBytes, IsBinary: false})
+}
+
+// loadHostedSession loads the running session of a session endpoint, checking the user
+// is its host or the room host. forbidden is the error for anyone else.
+func loadHostedSession(c *gin.Context, forbidden string) (*models.WatchSession, bool) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return nil, false
+	}
+	var session models.WatchSession
+	if err := DB.Where("session_id = ? AND ended_at IS NULL", c.Param("session_id")).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return nil, false
+	}
+	if session.HostID != userID.(uint) {
+		var room models.Room
+		if err := DB.First(&room, session.RoomID).Error; err != nil || room.HostID != userID.(uint) {
+			c.JSON(http.StatusForbidden, gin.H{"error": forbidden})
+			return nil, false
+		}
+	}
+	return &session, true
+}
+
+// GetSessionWaitlistHandler handles GET /api/rooms/watch-sessions/:session_id/waitlist
+// Returns the session's caps, how many viewers it has and who is waiting, in order.
+func GetSessionWaitlistHandler(c *gin.Context) {
+	session, ok := loadHostedSession(c, "Only the host can manage the session's capacity")
+	if !ok {
+		return
+	}
+
+	type waitlistEntry struct {
+		models.SessionWaitlistEntry
+		Username string `json:"username"`
+		Position int    `json:"position"`
+	}
+	var entries []waitlistEntry
+	if err := DB.Table("session_waitlist_entries AS w").
+		Select("w.*, u.username").
+		Joins("LEFT JOIN users u ON u.id = w.user_id").
+		Where("w.session_id = ? AND w.status = ?", session.SessionID, models.WaitlistWaiting).
+		Order("w.id ASC").
+		Scan(&entries).Error; err != nil {
+		log.Printf("GetSessionWaitlistHandler: Query failed for session %s: %v", session.SessionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load waitlist"})
+		return
+	}
+	for i := range entries {
+		entries[i].Position = i + 1
+	}
+	if entries == nil {
+		entries = []waitlistEntry{}
+	}
+
+	var theaters int64
+	DB.Model(&models.Theater{}).Where("watch_session_id = ?", session.ID).Count(&theaters)
+	c.JSON(http.StatusOK, gin.H{
+		"session_id":   session.SessionID,
+		"max_viewers":  session.MaxViewers,
+		"max_theaters": session.MaxTheaters,
+		"capacity":     sessionCapacity(session),
+		"viewers":      activeViewerCount(session),
+		"theaters":     theaters,
+		"waitlist":     entries,
+		"count":        len(entries),
+	})
+}
+
+// UpdateSessionCapacityHandler handles PUT /api/rooms/watch-sessions/:session_id/capacity
+// Sets max_viewers and max_theaters (0 = no limit). Lowering a cap doesn't remove anyone;
+// raising it admits from the waitlist.
+func UpdateSessionCapacityHandler(c *gin.Context) {
+	session, ok := loadHostedSession(c, "Only the host can manage the session's capacity")
+	if !ok {
+		return
+	}
+
+	var input struct {
+		MaxViewers  *int `json:"max_viewers"`
+		MaxTheaters *int `json:"max_theaters"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	updates := map[string]interface{}{}
+	if input.MaxViewers != nil {
+		if *input.MaxViewers < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "max_viewers must be 0 (no limit) or more"})
+			return
+		}
+		updates["max_viewers"] = *input.MaxViewers
+		session.MaxViewers = *input.MaxViewers
+	}
+	if input.MaxTheaters != nil {
+		if *input.MaxTheaters < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "max_theaters must be 0 (no limit) or more"})
+			return
+		}
+		if session.WatchType != "3d_cinema" && *input.MaxTheaters != 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "max_theaters only applies to 3D cinema sessions"})
+			return
+		}
+		updates["max_theaters"] = *input.MaxTheaters
+		session.MaxTheaters = *input.MaxTheaters
+	}
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to update"})
+		return
+	}
+
+	if err := DB.Model(&models.WatchSession{}).Where("id = ?", session.ID).Updates(updates).Error; err != nil {
+		log.Printf("UpdateSessionCapacityHandler: Failed to update session %s: %v", session.SessionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update capacity"})
+		return
+	}
+	hub.sessionMutex.Lock()
+	if cached, ok := hub.activeSessions[session.SessionID]; ok {
+		cached.MaxViewers = session.MaxViewers
+		cached.MaxTheaters = session.MaxTheaters
+	}
+	hub.sessionMutex.Unlock()
+
+	if msgBytes, err := json.Marshal(map[string]interface{}{
+		"type": "session_capacity_updated",
+		"data": map[string]interface{}{
+			"session_id":   session.SessionID,
+			"max_viewers":  session.MaxViewers,
+			"max_theaters": session.MaxTheaters,
+			"capacity":     sessionCapacity(session),
+		},
+	}); err == nil {
+		hub.BroadcastToRoom(session.RoomID, OutgoingMessage{Data: msgBytes, IsBinary: false}, nil)
+	}
+	go admitFromWaitlist(session.SessionID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"session_id":   session.SessionID,
+		"max_viewers":  session.MaxViewers,
+		"max_theaters": session.MaxTheaters,
+		"capacity":     sessionCapacity(session),
+	})
+}
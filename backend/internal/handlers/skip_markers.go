@@ -0,0 +1,279 @@
+// WeWatch/backend/internal/handlers/skip_markers.go
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"wewatch-backend/internal/mediatools"
+	"wewatch-backend/internal/models"
+	"wewatch-backend/internal/storage"
+)
+
+// markerDetectionStaleAfter lets a new detection start if an earlier one never reported
+// back (e.g. the server restarted mid-analysis).
+const markerDetectionStaleAfter = time.Hour
+
+// detectMarkersOnUpload reports whether new library videos are analysed automatically
+// (MEDIA_MARKER_DETECTION=upload). Otherwise detection only runs when the host asks.
+func detectMarkersOnUpload() bool {
+	return os.Getenv("MEDIA_MARKER_DETECTION") == "upload"
+}
+
+// startMarkerDetection marks an item as being analysed and runs the job in the background.
+// With overwrite the result replaces markers the host set by hand. It returns false if the
+// item isn't a video or an analysis is already running.
+func startMarkerDetection(item *models.MediaItem, overwrite bool) bool {
+	if item.MediaKind != models.MediaKindVideo {
+		return false
+	}
+	updates := map[string]interface{}{"marker_status": models.MarkerStatusAnalyzing}
+	if overwrite {
+		updates["marker_source"] = ""
+	}
+	res := DB.Model(&models.MediaItem{}).
+		Where("id = ? AND (marker_status <> ? OR updated_at < ?)", item.ID, models.MarkerStatusAnalyzing, time.Now().Add(-markerDetectionStaleAfter)).
+		Updates(updates)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return false
+	}
+	item.MarkerStatus = models.MarkerStatusAnalyzing
+	go detectSkipMarkers(item.ID)
+	return true
+}
+
+// detectSkipMarkers runs blackdetect/silencedetect/scene analysis on a stored video and
+// saves the proposed intro and credits markers. Markers the host set by hand while the
+// job ran are kept. The room hears about the result via "media_markers_updated".
+func detectSkipMarkers(itemID uint) {
+	ctx := context.Background()
+
+	var item models.MediaItem
+	if err := DB.First(&item, itemID).Error; err != nil {
+		log.Printf("detectSkipMarkers: Media item %d not found: %v", itemID, err)
+		return
+	}
+
+	fail := func(format string, args ...interface{}) {
+		log.Printf("⚠️ detectSkipMarkers: "+format, args...)
+		DB.Model(&item).Update("marker_status", models.MarkerStatusFailed)
+		item.MarkerStatus = models.MarkerStatusFailed
+		broadcastMarkersUpdated(&item)
+	}
+
+	fileKey := storage.KeyFromPath(item.FilePath)
+	localVideo, cleanup, err := storage.FetchToLocal(ctx, MediaStore, fileKey)
+	if err != nil {
+		fail("Failed to fetch %s: %v", fileKey, err)
+		return
+	}
+	defer cleanup()
+
+	started := time.Now()
+	markers, err := mediatools.Default().DetectSkipMarkers(ctx, localVideo, item.DurationSeconds, item.AudioCodec != "")
+	if err != nil {
+		fail("Analysis of media item %d failed: %v", item.ID, err)
+		return
+	}
+	log.Printf("🔎 detectSkipMarkers: Media item %d: intro %.1f-%.1fs, credits at %.1fs (took %s)",
+		item.ID, markers.IntroStart, markers.IntroEnd, markers.CreditsStart, time.Since(started).Round(time.Second))
+
+	res := DB.Model(&models.MediaItem{}).Where("id = ? AND marker_source <> ?", item.ID, models.MarkerSourceManual).
+		Updates(map[string]interface{}{
+			"intro_start":   markers.IntroStart,
+			"intro_end":     markers.IntroEnd,
+			"credits_start": markers.CreditsStart,
+			"marker_source": models.MarkerSourceDetected,
+			"marker_status": models.MarkerStatusDone,
+		})
+	if res.Error == nil && res.RowsAffected == 0 {
+		// The host set markers meanwhile; only record that the analysis finished
+		DB.Model(&models.MediaItem{}).Where("id = ?", item.ID).Update("marker_status", models.MarkerStatusDone)
+	}
+	if err := DB.First(&item, item.ID).Error; err == nil {
+		broadcastMarkersUpdated(&item)
+	}
+}
+
+// broadcastMarkersUpdated sends an item's skip markers to its room.
+func broadcastMarkersUpdated(item *models.MediaItem) {
+	if msg, err := json.Marshal(map[string]interface{}{
+		"type": "media_markers_updated",
+		"data": map[string]interface{}{
+			"media_item_id": item.ID,
+			"intro_start":   item.IntroStart,
+			"intro_end":     item.IntroEnd,
+			"credits_start": item.CreditsStart,
+			"marker_source": item.MarkerSource,
+			"marker_status": item.MarkerStatus,
+		},
+	}); err == nil {
+		hub.BroadcastToRoom(item.RoomID, OutgoingMessage{Data: msg, IsBinary: false}, nil)
+	}
+}
+
+// DetectMediaMarkersHandler handles POST /api/rooms/:id/media/:media_id/markers/detect (host only).
+// It starts the intro/credits analysis and answers 202; the result arrives as
+// "media_markers_updated". Detected markers replace earlier detected or manual ones.
+func DetectMediaMarkersHandler(c *gin.Context) {
+	userID, room, ok := requireRoomAccess(c)
+	if !ok {
+		return
+	}
+	if room.HostID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the room host can analyse media"})
+		return
+	}
+	item, ok := loadRoomMediaItem(c, room)
+	if !ok {
+		return
+	}
+	if item.MediaKind != models.MediaKindVideo {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Intro and credits detection only works on videos"})
+		return
+	}
+
+	// An explicit request may overwrite the host's own markers
+	if !startMarkerDetection(item, true) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Analysis is already running for this media item"})
+		return
+	}
+	log.Printf("🔎 DetectMediaMarkersHandler: Host %d started marker detection for media item %d", userID, item.ID)
+	c.JSON(http.StatusAccepted, gin.H{"message": "Analysis started", "media_item_id": item.ID, "marker_status": item.MarkerStatus})
+}
+
+// UpdateMediaMarkersHandler handles PUT /api/rooms/:id/media/:media_id/markers (host only).
+// Body fields intro_start, intro_end and credits_start are optional; 0 clears a marker.
+func UpdateMediaMarkersHandler(c *gin.Context) {
+	userID, room, ok := requireRoomAccess(c)
+	if !ok {
+		return
+	}
+	if room.HostID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the room host can edit skip markers"})
+		return
+	}
+	item, ok := loadRoomMediaItem(c, room)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		IntroStart   *float64 `json:"intro_start"`
+		IntroEnd     *float64 `json:"intro_end"`
+		CreditsStart *float64 `json:"credits_start"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid marker data"})
+		return
+	}
+	if input.IntroStart != nil {
+		item.IntroStart = *input.IntroStart
+	}
+	if input.IntroEnd != nil {
+		item.IntroEnd = *input.IntroEnd
+	}
+	if input.CreditsStart != nil {
+		item.CreditsStart = *input.CreditsStart
+	}
+
+	limit := item.DurationSeconds
+	inRange := func(v float64) bool { return v >= 0 && (limit <= 0 || v <= limit) }
+	if !inRange(item.IntroStart) || !inRange(item.IntroEnd) || !inRange(item.CreditsStart) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Markers must lie within the media item's duration"})
+		return
+	}
+	if item.IntroEnd > 0 && item.IntroEnd <= item.IntroStart {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "intro_end must be after intro_start"})
+		return
+	}
+	if item.IntroEnd == 0 {
+		item.IntroStart = 0
+	}
+	if item.CreditsStart > 0 && item.CreditsStart < item.IntroEnd {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "credits_start must be after the intro"})
+		return
+	}
+
+	item.MarkerSource = models.MarkerSourceManual
+	if err := DB.Model(item).Select("intro_start", "intro_end", "credits_start", "marker_source").Updates(item).Error; err != nil {
+		log.Printf("UpdateMediaMarkersHandler: Failed to update media item %d: %v", item.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update skip markers"})
+		return
+	}
+	broadcastMarkersUpdated(item)
+
+	// A changed credits marker moves the pending advance of the item playing now
+	rearmPlayback(room.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Skip markers updated",
+		"media_item_id": item.ID,
+		"intro_start":   item.IntroStart,
+		"intro_end":     item.IntroEnd,
+		"credits_start": item.CreditsStart,
+		"marker_source": item.MarkerSource,
+	})
+}
+
+// loadSkipTarget parses {"media_item_id": N} from a skip command and loads the room and
+// item, checking the sender controls playback.
+func (client *Client) loadSkipTarget(msg WebSocketMessage, command string) (*models.Room, *models.MediaItem, bool) {
+	var data struct {
+		MediaItemID uint `json:"media_item_id"`
+	}
+	raw, _ := json.Marshal(msg.Data)
+	if err := json.Unmarshal(raw, &data); err != nil || data.MediaItemID == 0 {
+		log.Printf("[%s] ❌ Invalid data from user %d: %v", command, client.userID, err)
+		return nil, nil, false
+	}
+
+	var room models.Room
+	if err := DB.First(&room, client.roomID).Error; err != nil {
+		log.Printf("[%s] ❌ Room %d not found: %v", command, client.roomID, err)
+		return nil, nil, false
+	}
+	if !isPlaybackHost(&room, client.userID) {
+		log.Printf("[%s] ❌ User %d is not the host of room %d", command, client.userID, room.ID)
+		return nil, nil, false
+	}
+	var item models.MediaItem
+	if err := DB.Where("id = ? AND room_id = ?", data.MediaItemID, room.ID).First(&item).Error; err != nil {
+		log.Printf("[%s] ❌ Media item %d not in room %d", command, data.MediaItemID, room.ID)
+		return nil, nil, false
+	}
+	return &room, &item, true
+}
+
+// handleSkipIntro moves the whole room past the current item's intro (host only).
+func (client *Client) handleSkipIntro(msg WebSocketMessage) {
+	room, item, ok := client.loadSkipTarget(msg, "skip_intro")
+	if !ok {
+		return
+	}
+	if item.IntroEnd <= 0 {
+		log.Printf("[skip_intro] ❌ Media item %d has no intro marker", item.ID)
+		return
+	}
+	log.Printf("[skip_intro] ⏩ Host %d skipping intro of media item %d in room %d (to %.1fs)", client.userID, item.ID, room.ID, item.IntroEnd)
+	broadcastPlayItem(room, item, client.userID, item.IntroEnd, map[string]interface{}{
+		"skipped":      "intro",
+		"initiated_by": client.userID,
+	})
+}
+
+// handleSkipCredits moves the room to the next library item now (host only), the same way
+// the server advances at the credits.
+func (client *Client) handleSkipCredits(msg WebSocketMessage) {
+	room, item, ok := client.loadSkipTarget(msg, "skip_credits")
+	if !ok {
+		return
+	}
+	log.Printf("[skip_credits] ⏭️ Host %d skipping the rest of media item %d in room %d", client.userID, item.ID, room.ID)
+	advancePlaylist(room.ID, item.ID, "skip_credits")
+}
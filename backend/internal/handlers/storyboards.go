@@ -0,0 +1,217 @@
+// WeWatch/backend/internal/handlers/storyboards.go
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"wewatch-backend/internal/mediatools"
+	"wewatch-backend/internal/models"
+	"wewatch-backend/internal/storage"
+)
+
+const storyboardVTTName = "storyboard.vtt"
+
+// storyboardPrefix is the storage "directory" holding a media file's sprite sheets and track.
+func storyboardPrefix(fileKey string) string {
+	return "storyboards/" + strings.TrimSuffix(fileKey, path.Ext(fileKey)) + "/"
+}
+
+// storyboardURL is the API route serving the thumbnails track for a media item.
+func storyboardURL(item *models.MediaItem) string {
+	if item.StoryboardKey == "" {
+		return ""
+	}
+	return fmt.Sprintf("/api/rooms/%d/media/%d/storyboard.vtt", item.RoomID, item.ID)
+}
+
+// generateMediaStoryboard samples the stored video into sprite sheets plus a WebVTT track
+// and records the track's key on the media item. It runs in the background after upload;
+// when it finishes the room is told via "media_storyboard_ready".
+func generateMediaStoryboard(itemID uint) {
+	ctx := context.Background()
+
+	var item models.MediaItem
+	if err := DB.First(&item, itemID).Error; err != nil {
+		log.Printf("generateMediaStoryboard: Media item %d not found: %v", itemID, err)
+		return
+	}
+	if item.MediaKind != models.MediaKindVideo {
+		return // audio and slideshows have nothing to preview
+	}
+
+	fileKey := storage.KeyFromPath(item.FilePath)
+	localVideo, cleanup, err := storage.FetchToLocal(ctx, MediaStore, fileKey)
+	if err != nil {
+		log.Printf("⚠️ generateMediaStoryboard: Failed to fetch %s: %v", fileKey, err)
+		return
+	}
+	defer cleanup()
+
+	workDir, err := uploadWorkDir()
+	if err != nil {
+		log.Printf("⚠️ generateMediaStoryboard: Failed to prepare work directory: %v", err)
+		return
+	}
+	outDir, err := os.MkdirTemp(workDir, "storyboard-*")
+	if err != nil {
+		log.Printf("⚠️ generateMediaStoryboard: Failed to create temp dir: %v", err)
+		return
+	}
+	defer os.RemoveAll(outDir)
+
+	log.Printf("🎞️ generateMediaStoryboard: Building storyboard for media item %d (%s)", item.ID, fileKey)
+	sb, err := mediatools.Default().GenerateStoryboard(ctx, localVideo, outDir, item.DurationSeconds, item.Width, item.Height)
+	if err != nil {
+		log.Printf("⚠️ generateMediaStoryboard: Failed for media item %d: %v", item.ID, err)
+		return
+	}
+
+	prefix := storyboardPrefix(fileKey)
+	for _, sheet := range sb.Sheets {
+		if err := MediaStore.PutFile(ctx, prefix+path.Base(sheet), sheet, "image/jpeg"); err != nil {
+			log.Printf("⚠️ generateMediaStoryboard: Failed to store %s: %v", sheet, err)
+			return
+		}
+	}
+
+	// Sheet names are relative; GetMediaStoryboardHandler turns them into signed URLs per user
+	vtt := sb.WebVTT(item.DurationSeconds, func(i int) string {
+		return path.Base(sb.Sheets[i])
+	})
+	vttKey := prefix + storyboardVTTName
+	if err := MediaStore.Put(ctx, vttKey, strings.NewReader(vtt), int64(len(vtt)), "text/vtt"); err != nil {
+		log.Printf("⚠️ generateMediaStoryboard: Failed to store %s: %v", vttKey, err)
+		return
+	}
+
+	// Every item sharing the blob gets the same track
+	update := DB.Model(&models.MediaItem{}).Where("id = ?", item.ID)
+	if item.BlobHash != "" {
+		update = DB.Model(&models.MediaItem{}).Where("blob_hash = ?", item.BlobHash)
+	}
+	if err := update.Update("storyboard_key", vttKey).Error; err != nil {
+		log.Printf("⚠️ generateMediaStoryboard: Failed to save storyboard key for media item %d: %v", item.ID, err)
+		return
+	}
+	log.Printf("✅ generateMediaStoryboard: %d frames on %d sheets for media item %d", sb.Frames, len(sb.Sheets), item.ID)
+
+	item.StoryboardKey = vttKey
+	msg, _ := json.Marshal(map[string]interface{}{
+		"type": "media_storyboard_ready",
+		"data": map[string]interface{}{
+			"media_item_id":  item.ID,
+			"room_id":        item.RoomID,
+			"storyboard_url": storyboardURL(&item),
+		},
+	})
+	hub.BroadcastToRoom(item.RoomID, OutgoingMessage{Data: msg, IsBinary: false}, nil)
+}
+
+// readStoryboardSheets returns the sprite sheet names referenced by a stored track.
+func readStoryboardSheets(ctx context.Context, vttKey string) ([]string, []byte, error) {
+	body, err := MediaStore.GetRange(ctx, vttKey, 0, -1)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer body.Close()
+	vtt, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var sheets []string
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(strings.NewReader(string(vtt)))
+	for scanner.Scan() {
+		name, _, found := strings.Cut(scanner.Text(), "#xywh=")
+		if found && !seen[name] {
+			seen[name] = true
+			sheets = append(sheets, name)
+		}
+	}
+	return sheets, vtt, nil
+}
+
+// removeStoryboard deletes a media item's thumbnails track and its sprite sheets.
+func removeStoryboard(ctx context.Context, vttKey string) {
+	if vttKey == "" {
+		return
+	}
+	sheets, _, err := readStoryboardSheets(ctx, vttKey)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		log.Printf("⚠️ removeStoryboard: Failed to read %s: %v", vttKey, err)
+	}
+	prefix := path.Dir(vttKey) + "/"
+	for _, sheet := range sheets {
+		if err := MediaStore.Delete(ctx, prefix+sheet); err != nil {
+			log.Printf("⚠️ removeStoryboard: Failed to delete %s: %v", prefix+sheet, err)
+		}
+	}
+	if err := MediaStore.Delete(ctx, vttKey); err != nil {
+		log.Printf("⚠️ removeStoryboard: Failed to delete %s: %v", vttKey, err)
+	}
+}
+
+// GetMediaStoryboardHandler handles GET /api/rooms/:id/media/:media_id/storyboard.vtt.
+// It serves the WebVTT thumbnails track with each sprite sheet rewritten to a signed URL
+// for the requesting user, so players can show seek previews in private rooms too.
+func GetMediaStoryboardHandler(c *gin.Context) {
+	userID, room, ok := requireRoomAccess(c)
+	if !ok {
+		return
+	}
+
+	mediaID, err := strconv.ParseUint(c.Param("media_id"), 10, 64)
+	if err != nil || mediaID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid media item ID"})
+		return
+	}
+
+	var item models.MediaItem
+	if err := DB.Where("id = ? AND room_id = ?", mediaID, room.ID).First(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Media item not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return
+	}
+	if item.StoryboardKey == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Storyboard not generated yet"})
+		return
+	}
+
+	sheets, vtt, err := readStoryboardSheets(c.Request.Context(), item.StoryboardKey)
+	if err != nil {
+		log.Printf("GetMediaStoryboardHandler: Failed to read %s: %v", item.StoryboardKey, err)
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Storyboard not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read storyboard"})
+		}
+		return
+	}
+
+	prefix := path.Dir(item.StoryboardKey) + "/"
+	out := string(vtt)
+	for _, sheet := range sheets {
+		signed, _ := signStoredURL(storage.PublicURL(prefix+sheet), userID, room.ID)
+		out = strings.ReplaceAll(out, "\n"+sheet+"#xywh=", "\n"+signed+"#xywh=")
+	}
+
+	c.Header("Cache-Control", "private, no-store")
+	c.Data(http.StatusOK, "text/vtt; charset=utf-8", []byte(out))
+}
@@ -2,7 +2,7 @@
 package handlers
 
 import (
-	"fmt"
+	"context"
 	"log"
 	"net/http"
 	"os"
@@ -13,8 +13,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"wewatch-backend/internal/mediatools"
 	"wewatch-backend/internal/models"
-	"wewatch-backend/internal/utils"
+	"wewatch-backend/internal/storage"
 )
 
 // DeleteSingleTemporaryMediaItemHandler handles DELETE /api/rooms/:id/temporary-media/:item_id
@@ -73,20 +74,23 @@ func DeleteSingleTemporaryMediaItemHandler(c *gin.Context) {
 		return
 	}
 
-	// Delete file
-	if err := os.Remove(item.FilePath); err != nil && !os.IsNotExist(err) {
-		log.Printf("Warning: failed to delete file %s: %v", item.FilePath, err)
-	}
-	// Delete thumbnail
-	thumbPath := item.FilePath + ".jpg"
-	os.Remove(thumbPath)
-
-	// Delete DB record
-	if err := DB.Delete(&item).Error; err != nil {
+	// Delete DB record, dropping its storage references with it
+	var rel mediaRelease
+	if err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&item).Error; err != nil {
+			return err
+		}
+		return releaseMediaTx(tx, item.FilePath, item.PosterURL, item.BlobHash, &rel)
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete media item"})
 		return
 	}
 
+	// Delete file and poster from storage
+	if err := rel.run(c.Request.Context()); err != nil {
+		log.Printf("Warning: failed to delete file %s: %v", item.FilePath, err)
+	}
+
 	// Broadcast deletion
 	//message := map[string]interface{}{
 	//	"type": "temporary_media_item_deleted",
@@ -114,7 +118,7 @@ func GetTemporaryMediaItemsForRoomHandler(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
-	_, ok := userIDValue.(uint)
+	userID, ok := userIDValue.(uint)
 	if !ok {
 		log.Println("GetTemporaryMediaItemsForRoomHandler: Error asserting user ID type")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
@@ -150,6 +154,12 @@ func GetTemporaryMediaItemsForRoomHandler(c *gin.Context) {
 		}
 	}
 
+	if !userHasRoomAccess(userID, &room) {
+		log.Printf("GetTemporaryMediaItemsForRoomHandler: User %d has no access to room %d", userID, roomIDUint)
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have access to this room"})
+		return
+	}
+
 	var temporaryMediaItems []models.TemporaryMediaItem
 	result = DB.Where("room_id = ?", roomIDUint).Order("created_at ASC").Find(&temporaryMediaItems)
 	if result.Error != nil {
@@ -171,24 +181,20 @@ func GetTemporaryMediaItemsForRoomHandler(c *gin.Context) {
 		if item.FilePath != "" {
 			log.Printf("🎨 Generating missing poster for %s", item.FileName)
 			
-			posterFilename := fmt.Sprintf("%s_poster.jpg", strings.TrimSuffix(item.FileName, filepath.Ext(item.FileName)))
-			posterPath := filepath.Join("./uploads/temp", posterFilename)
-			posterURL := fmt.Sprintf("/uploads/temp/%s", posterFilename)
+			fileKey := storage.KeyFromPath(item.FilePath)
+			posterKey := strings.TrimSuffix(fileKey, filepath.Ext(fileKey)) + "_poster.jpg"
+			posterURL := storage.PublicURL(posterKey)
 			
-			// Check if poster file already exists on disk
-			if _, err := os.Stat(posterPath); os.IsNotExist(err) {
-				// Generate new poster
-				err = utils.ExtractThumbnail(item.FilePath, posterPath)
-				if err != nil {
-					log.Printf("⚠️ Failed to generate poster for %s: %v", item.FileName, err)
-					item.PosterURL = "/icons/placeholder-poster.jpg"
-				} else {
-					log.Printf("✅ Poster generated: %s", posterPath)
-					item.PosterURL = posterURL
-				}
+			// Check if poster already exists in storage
+			if _, err := MediaStore.Stat(c.Request.Context(), posterKey); err == nil {
+				// Poster exists, just update the URL
+				log.Printf("✅ Found existing poster in storage: %s", posterKey)
+				item.PosterURL = posterURL
+			} else if err := regeneratePoster(c.Request.Context(), fileKey, posterKey); err != nil {
+				log.Printf("⚠️ Failed to generate poster for %s: %v", item.FileName, err)
+				item.PosterURL = "/icons/placeholder-poster.jpg"
 			} else {
-				// Poster file exists, just update the URL
-				log.Printf("✅ Found existing poster on disk: %s", posterPath)
+				log.Printf("✅ Poster generated: %s", posterKey)
 				item.PosterURL = posterURL
 			}
 			
@@ -197,6 +203,10 @@ func GetTemporaryMediaItemsForRoomHandler(c *gin.Context) {
 		}
 	}
 
+	for i := range temporaryMediaItems {
+		signTemporaryMediaItemURLs(&temporaryMediaItems[i], userID)
+	}
+
 	log.Printf("GetTemporaryMediaItemsForRoomHandler: Fetched %d temporary media items for room %d", len(temporaryMediaItems), roomIDUint)
 	c.JSON(http.StatusOK, gin.H{
 		"message":              "Temporary media items fetched successfully",
@@ -211,12 +221,16 @@ func GetTemporaryMediaItemsForRoomHandler(c *gin.Context) {
 func GetTemporaryMediaFileHandler(c *gin.Context) {
 	log.Println("GetTemporaryMediaFileHandler: Request received")
 
-	// ✅ We require auth, but don't yet enforce room membership
-	// Just ensure user is authenticated
-	if _, exists := c.Get("user_id"); !exists {
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
+	userID, ok := userIDValue.(uint)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
 
 	roomIDStr := c.Param("id")
 	itemIDStr := c.Param("item_id")
@@ -242,6 +256,10 @@ func GetTemporaryMediaFileHandler(c *gin.Context) {
 		}
 		return
 	}
+	if !userHasRoomAccess(userID, &room) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have access to this room"})
+		return
+	}
 
 	// Fetch the media item
 	var item models.TemporaryMediaItem
@@ -254,10 +272,31 @@ func GetTemporaryMediaFileHandler(c *gin.Context) {
 		return
 	}
 
-	// ✅ Serve the file
+	// ✅ Serve the file (range-aware, from whichever storage backend holds it)
 	c.Header("Content-Type", item.MimeType)
 	c.Header("Accept-Ranges", "bytes")
-	c.File(item.FilePath)
+	storage.Serve(c.Writer, c.Request, MediaStore, storage.KeyFromPath(item.FilePath))
+}
+
+// regeneratePoster pulls a stored video down (if needed), extracts a poster and stores it under posterKey.
+func regeneratePoster(ctx context.Context, fileKey, posterKey string) error {
+	localVideo, cleanup, err := storage.FetchToLocal(ctx, MediaStore, fileKey)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	workDir, err := uploadWorkDir()
+	if err != nil {
+		return err
+	}
+	workPoster := filepath.Join(workDir, filepath.Base(posterKey))
+	defer os.Remove(workPoster)
+
+	if err := mediatools.Default().ExtractThumbnail(ctx, localVideo, workPoster); err != nil {
+		return err
+	}
+	return MediaStore.PutFile(ctx, posterKey, workPoster, "image/jpeg")
 }
 
 
@@ -345,26 +384,24 @@ func DeleteTemporaryMediaItemsForRoomHandler(c *gin.Context) {
 	successCount := 0
 	failureCount := 0
 	for _, item := range temporaryMediaItems {
-		if err := os.Remove(item.FilePath); err != nil && !os.IsNotExist(err) {
-			log.Printf("DeleteTemporaryMediaItemsForRoomHandler: Warning - Failed to delete file '%s': %v", item.FilePath, err)
+		var rel mediaRelease
+		if err := DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Delete(&item).Error; err != nil {
+				return err
+			}
+			return releaseMediaTx(tx, item.FilePath, item.PosterURL, item.BlobHash, &rel)
+		}); err != nil {
+			log.Printf("DeleteTemporaryMediaItemsForRoomHandler: Error deleting DB record for item ID %d: %v", item.ID, err)
 			failureCount++
 			continue
 		}
-		log.Printf("DeleteTemporaryMediaItemsForRoomHandler: Deleted file '%s'", item.FilePath)
+		log.Printf("DeleteTemporaryMediaItemsForRoomHandler: Deleted DB record for item ID %d", item.ID)
+		successCount++
 
-		thumbnailPath := item.FilePath + ".jpg"
-		if err := os.Remove(thumbnailPath); err != nil && !os.IsNotExist(err) {
-			log.Printf("DeleteTemporaryMediaItemsForRoomHandler: Warning - Failed to delete thumbnail '%s': %v", thumbnailPath, err)
-		} else {
-			log.Printf("DeleteTemporaryMediaItemsForRoomHandler: Deleted thumbnail '%s'", thumbnailPath)
-		}
-
-		if result := DB.Delete(&item); result.Error != nil {
-			log.Printf("DeleteTemporaryMediaItemsForRoomHandler: Error deleting DB record for item ID %d: %v", item.ID, result.Error)
-			failureCount++
+		if err := rel.run(c.Request.Context()); err != nil {
+			log.Printf("DeleteTemporaryMediaItemsForRoomHandler: Warning - Failed to delete file '%s': %v", item.FilePath, err)
 		} else {
-			log.Printf("DeleteTemporaryMediaItemsForRoomHandler: Deleted DB record for item ID %d", item.ID)
-			successCount++
+			log.Printf("DeleteTemporaryMediaItemsForRoomHandler: Deleted file '%s'", item.FilePath)
 		}
 	}
 
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"log"
 
@@ -8,6 +9,13 @@ import (
 	"gorm.io/gorm"
 )
 
+// theaterSeats is the number of seats in a theater
+const theaterSeats = 42
+
+// errTheatersFull is returned when every theater is full and the session's MaxTheaters
+// doesn't allow another one
+var errTheatersFull = errors.New("all theaters are full")
+
 // GetOrCreateTheaterForSession finds an available theater or creates a new one
 // Only applies to 3D Cinema sessions (watch_type = "3d_cinema")
 func GetOrCreateTheaterForSession(session *models.WatchSession) (*models.Theater, bool, error) {
@@ -29,7 +37,7 @@ func GetOrCreateTheaterForSession(session *models.WatchSession) (*models.Theater
 			WatchSessionID: session.ID,
 			TheaterNumber:  1,
 			OccupiedSeats:  0,
-			MaxSeats:       42,
+			MaxSeats:       theaterSeats,
 		}
 		if err := DB.Create(theater).Error; err != nil {
 			return nil, false, fmt.Errorf("failed to create first theater: %w", err)
@@ -45,13 +53,16 @@ func GetOrCreateTheaterForSession(session *models.WatchSession) (*models.Theater
 		}
 	}
 
-	// All theaters are full - create new theater
+	// All theaters are full - create new theater, unless the host capped them
+	if session.MaxTheaters > 0 && len(theaters) >= session.MaxTheaters {
+		return nil, false, errTheatersFull
+	}
 	newTheaterNumber := len(theaters) + 1
 	newTheater := &models.Theater{
 		WatchSessionID: session.ID,
 		TheaterNumber:  newTheaterNumber,
 		OccupiedSeats:  0,
-		MaxSeats:       42,
+		MaxSeats:       theaterSeats,
 	}
 
 	if err := DB.Create(newTheater).Error; err != nil {
@@ -2,11 +2,13 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
-	"os/exec"
+	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -15,20 +17,10 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"wewatch-backend/internal/mediatools"
 	"wewatch-backend/internal/models"
-	"wewatch-backend/internal/utils"
 )
 
-const UploadDir = "./uploads"
-
-func init() {
-	err := os.MkdirAll(UploadDir, os.ModePerm)
-	if err != nil {
-		log.Fatalf("Failed to create upload directory '%s': %v", UploadDir, err)
-	}
-	log.Printf("Upload directory '%s' is ready.", UploadDir)
-}
-
 func UploadMediaHandler(c *gin.Context) {
 	log.Println("🚨🚨🚨 UploadMediaHandler CALLED 🚨🚨🚨")
 
@@ -89,6 +81,81 @@ func UploadMediaHandler(c *gin.Context) {
 	const maxMemory int64 = 256 << 20 // 256 MB memory buffer
 	const maxSize int64 = 1 << 30     // 1 GB max file size
 
+	// ✅ INSTANT UPLOAD - a client that sends ?sha256=<hex>&filename=<name> for content we
+	// already store gets the item created without sending the file again
+	if contentHash := strings.ToLower(c.Query("sha256")); contentHash != "" {
+		if !sha256HexPattern.MatchString(contentHash) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "sha256 must be 64 hex characters"})
+			return
+		}
+		var known models.MediaBlob
+		err := DB.Where("hash = ?", contentHash).First(&known).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		// Only content already in one of the user's rooms counts; anything else answers
+		// like unknown content, so the hash can't be used to probe what others uploaded
+		visible := false
+		if err == nil {
+			if visible, err = blobVisibleTo(authenticatedUserID, contentHash); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+				return
+			}
+		}
+		if visible {
+			originalName := c.Query("filename")
+			if originalName == "" {
+				originalName = path.Base(known.StorageKey)
+			}
+			if err := checkUploadQuota(authenticatedUserID, room.ID, sessionID, isTemporary, known.Size); err != nil {
+				var qe *QuotaError
+				if errors.As(err, &qe) {
+					respondQuotaError(c, qe)
+				} else {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check storage quota"})
+				}
+				return
+			}
+			blob, err := acquireBlob(contentHash)
+			if err == nil {
+				log.Printf("⚡ UploadMediaHandler: Instant upload of %s by user %d", contentHash, authenticatedUserID)
+				finishUpload(c, &room, authenticatedUserID, isTemporary, sessionID, originalName, blob)
+				return
+			}
+			// Released in between: fall through to a normal upload
+		}
+		if c.Request.ContentLength <= 0 {
+			// Unknown content and nothing attached: the client should send the file
+			c.JSON(http.StatusNotFound, gin.H{"error": "unknown_content", "message": "Content not stored yet, upload the file"})
+			return
+		}
+	}
+
+	// ✅ QUOTAS - checked against Content-Length before any of the body is read
+	if c.Request.ContentLength < 0 {
+		c.JSON(http.StatusLengthRequired, gin.H{"error": "Content-Length is required for uploads"})
+		return
+	}
+	if c.Request.ContentLength > maxSize+(1<<20) {
+		log.Printf("UploadMediaHandler: Request too large (%d bytes). Max size: %d bytes.", c.Request.ContentLength, maxSize)
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File too large. Maximum size is 1 GB."})
+		return
+	}
+	if err := checkUploadQuota(authenticatedUserID, room.ID, sessionID, isTemporary, c.Request.ContentLength); err != nil {
+		var qe *QuotaError
+		if errors.As(err, &qe) {
+			log.Printf("UploadMediaHandler: Rejecting upload by user %d: %v", authenticatedUserID, qe)
+			respondQuotaError(c, qe)
+		} else {
+			log.Printf("UploadMediaHandler: Failed to check quota: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check storage quota"})
+		}
+		return
+	}
+	// Never read more than was declared (plus multipart overhead) - the quota check relied on it
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, c.Request.ContentLength)
+
 	// Parse multipart form with higher memory limit
 	if err := c.Request.ParseMultipartForm(maxMemory); err != nil {
 		log.Printf("UploadMediaHandler: Error parsing multipart form: %v", err)
@@ -110,112 +177,159 @@ func UploadMediaHandler(c *gin.Context) {
 		return
 	}
 
-	allowedExtensions := map[string]bool{
-		".mp4": true, ".avi": true, ".mov": true, ".mkv": true, ".webm": true,
+	// Re-check with the exact file size; concurrent uploads may have landed meanwhile
+	if err := checkUploadQuota(authenticatedUserID, room.ID, sessionID, isTemporary, formFile.Size); err != nil {
+		var qe *QuotaError
+		if errors.As(err, &qe) {
+			respondQuotaError(c, qe)
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check storage quota"})
+		}
+		return
 	}
+
 	ext := strings.ToLower(filepath.Ext(formFile.Filename))
-	if !allowedExtensions[ext] {
+	kind := mediaKindForExt(ext)
+	if kind == "" {
 		log.Printf("UploadMediaHandler: Invalid file type: %s", ext)
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid file type '%s'. Allowed types: mp4, avi, mov, mkv, webm.", ext)})
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid file type '%s'. Allowed types: mp4, avi, mov, mkv, webm (video), mp3, flac, ogg, m4a (audio), jpg, png, webp (slideshow images).", ext)})
+		return
+	}
+	if kind == models.MediaKindImage && isTemporary {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Images can only be added to the room library, as slideshow slides"})
 		return
 	}
 
 	uniqueID := uuid.New()
 	uniqueFilename := fmt.Sprintf("%s%s", uniqueID.String(), ext)
 
-	var filePath string
-	if isTemporary {
-		tempUploadDir := filepath.Join(UploadDir, "temp")
-		if err := os.MkdirAll(tempUploadDir, os.ModePerm); err != nil {
-			log.Printf("UploadMediaHandler: Failed to create temp upload directory '%s': %v", tempUploadDir, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare temp storage"})
-			return
-		}
-		filePath = filepath.Join(tempUploadDir, uniqueFilename)
-		log.Printf("UploadMediaHandler: Saving temporary file to: %s", filePath)
-	} else {
-		filePath = filepath.Join(UploadDir, uniqueFilename)
-		log.Printf("UploadMediaHandler: Saving permanent file to: %s", filePath)
+	// ffmpeg needs a real file, so process in the work dir and hand the result to MediaStore afterwards
+	workDir, err := uploadWorkDir()
+	if err != nil {
+		log.Printf("UploadMediaHandler: Failed to prepare work directory: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare storage"})
+		return
 	}
+	workPath := filepath.Join(workDir, uniqueFilename)
+	defer os.Remove(workPath)
 
-	if err := c.SaveUploadedFile(formFile, filePath); err != nil {
-		log.Printf("UploadMediaHandler: Error saving file to '%s': %v", filePath, err)
+	if err := c.SaveUploadedFile(formFile, workPath); err != nil {
+		log.Printf("UploadMediaHandler: Error saving file to '%s': %v", workPath, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save uploaded file"})
 		return
 	}
-	log.Printf("✅ UploadMediaHandler: File saved successfully to '%s'", filePath)
-	// ✅ OPTIMIZE MP4 FOR WEB STREAMING
-	if ext == ".mp4" {
-	log.Printf("🎥 Optimizing MP4 for web streaming: %s", filePath)
-	tempOptimizedPath := filePath + ".optimized"
-	cmd := exec.Command("ffmpeg",
-		"-i", filePath,
-		"-c", "copy",              // stream copy (fast, no re-encode)
-		"-movflags", "+faststart", // move moov atom to front
-		tempOptimizedPath,
-	)
-	if err := cmd.Run(); err != nil {
-		log.Printf("⚠️ Failed to optimize MP4, using original: %v", err)
-		// Keep original if optimization fails
-	} else {
-		// Replace original with optimized version
-		os.Remove(filePath)
-		os.Rename(tempOptimizedPath, filePath)
-		log.Printf("✅ MP4 optimized successfully: %s", filePath)
-		}
-	} // ← THIS BRACE WAS MISSING!
+	log.Printf("✅ UploadMediaHandler: File received into work dir '%s'", workPath)
 
-	// ✅ GET DURATION
-	log.Printf("⏱️ UploadMediaHandler: Extracting duration for '%s'", filePath)
-	duration, err := utils.GetVideoDuration(filePath)
+	// ✅ HASH THE CONTENT - identical uploads share one stored file (MediaBlob)
+	contentHash, err := hashFile(workPath)
 	if err != nil {
-		log.Printf("❌ UploadMediaHandler: Failed to extract duration: %v", err)
-		duration = "00:00:00"
+		log.Printf("UploadMediaHandler: Failed to hash '%s': %v", workPath, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process uploaded file"})
+		return
 	}
-	log.Printf("✅ UploadMediaHandler: Duration extracted successfully: %s", duration)
-
-	// ✅ GENERATE POSTER/THUMBNAIL
-	log.Printf("🎨 UploadMediaHandler: Generating poster for '%s'", filePath)
-	posterFilename := fmt.Sprintf("%s_poster.jpg", strings.TrimSuffix(uniqueFilename, filepath.Ext(uniqueFilename)))
-	var posterPath string
-	var posterURL string
-	
-	if isTemporary {
-		posterPath = filepath.Join(UploadDir, "temp", posterFilename)
-		posterURL = fmt.Sprintf("/uploads/temp/%s", posterFilename)
-	} else {
-		posterPath = filepath.Join(UploadDir, posterFilename)
-		posterURL = fmt.Sprintf("/uploads/%s", posterFilename)
+	if blob, err := acquireBlob(contentHash); err == nil {
+		log.Printf("♻️ UploadMediaHandler: Content %s already stored as '%s', reusing it", contentHash, blob.StorageKey)
+		finishUpload(c, &room, authenticatedUserID, isTemporary, sessionID, formFile.Filename, blob)
+		return
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("UploadMediaHandler: Failed to look up blob %s: %v", contentHash, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
 	}
-	
-	err = utils.ExtractThumbnail(filePath, posterPath)
+
+	// ✅ VALIDATE CONTENT WITH FFPROBE (extension alone proves nothing)
+	log.Printf("🔍 UploadMediaHandler: Probing '%s' as %s", workPath, kind)
+	mediaInfo, err := probeUpload(c.Request.Context(), kind, workPath)
 	if err != nil {
-		log.Printf("⚠️ UploadMediaHandler: Failed to generate poster: %v", err)
-		// Use placeholder poster if thumbnail generation fails
-		posterURL = "/icons/placeholder-poster.jpg"
-	} else {
-		log.Printf("✅ UploadMediaHandler: Poster generated successfully: %s", posterPath)
+		log.Printf("❌ UploadMediaHandler: Rejecting '%s': %v", formFile.Filename, err)
+		switch {
+		case errors.Is(err, mediatools.ErrNotVideo):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Uploaded file does not contain a video stream"})
+		case errors.Is(err, mediatools.ErrNotAudio):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Uploaded file does not contain an audio stream"})
+		case errors.Is(err, mediatools.ErrNotImage):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Uploaded file is not a JPEG, PNG or WebP image"})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Uploaded file is not a readable %s (corrupt or unsupported)", kind)})
+		}
+		return
+	}
+	metadata := mediaMetadataFromInfo(mediaInfo)
+	log.Printf("✅ UploadMediaHandler: Probed %dx%d %s/%s, %.1fs, container=%s", metadata.Width, metadata.Height, metadata.VideoCodec, metadata.AudioCodec, metadata.DurationSeconds, metadata.Container)
+
+	// ✅ OPTIMIZE MP4 FOR WEB STREAMING
+	if ext == ".mp4" || ext == ".m4a" {
+		log.Printf("🎥 Optimizing MP4 for web streaming: %s", workPath)
+		tempOptimizedPath := workPath + ".optimized.mp4"
+		if err := mediatools.Default().Faststart(c.Request.Context(), workPath, tempOptimizedPath); err != nil {
+			log.Printf("⚠️ Failed to optimize MP4, using original: %v", err)
+			// Keep original if optimization fails
+			os.Remove(tempOptimizedPath)
+		} else {
+			// Replace original with optimized version
+			os.Remove(workPath)
+			os.Rename(tempOptimizedPath, workPath)
+			log.Printf("✅ MP4 optimized successfully: %s", workPath)
+		}
+	}
+
+	// ✅ POSTER + STORAGE + BLOB RECORD
+	blob, err := storeBlob(c.Request.Context(), workPath, contentHash, ext, formFile.Size, mediaInfo)
+	if err != nil {
+		log.Printf("UploadMediaHandler: Error storing '%s': %v", workPath, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save uploaded file"})
+		return
 	}
 
+	finishUpload(c, &room, authenticatedUserID, isTemporary, sessionID, formFile.Filename, blob)
+}
+
+// finishUpload creates the MediaItem/TemporaryMediaItem for a stored blob (on which the
+// caller already holds a reference) and writes the upload response.
+func finishUpload(c *gin.Context, room *models.Room, authenticatedUserID uint, isTemporary bool, sessionID, originalName string, blob *models.MediaBlob) {
+	// Images become slides of a slideshow item rather than items of their own
+	if blob.MediaKind == models.MediaKindImage {
+		if isTemporary {
+			releaseBlob(c.Request.Context(), blob.Hash)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Images can only be added to the room library, as slideshow slides"})
+			return
+		}
+		finishSlideUpload(c, room, authenticatedUserID, originalName, blob)
+		return
+	}
+
+	fileName := path.Base(blob.StorageKey)
+
 	if isTemporary {
 		newTempMediaItem := models.TemporaryMediaItem{
-			FileName:     uniqueFilename,
-			OriginalName: formFile.Filename,
-			MimeType:     getMimeType(ext),
-			FileSize:     formFile.Size,
-			FilePath:     filePath,
-			PosterURL:    posterURL,
-			RoomID:       room.ID,
-			UploaderID:   authenticatedUserID,
-			Duration:     duration,
-			OrderIndex:   0,
-			SessionID:    sessionID, // ✅ Link to watch session
+			FileName:          fileName,
+			OriginalName:      originalName,
+			MimeType:          blob.MimeType,
+			FileSize:          blob.Size,
+			FilePath:          blob.StorageKey,
+			BlobHash:          blob.Hash,
+			PosterURL:         blob.PosterURL,
+			PosterVariantKeys: blob.PosterVariantKeys,
+			RoomID:            room.ID,
+			UploaderID:        authenticatedUserID,
+			Duration:          blob.Duration,
+			OrderIndex:        0,
+			SessionID:         sessionID, // ✅ Link to watch session
+			MediaMetadata:     blob.MediaMetadata,
 		}
 
-		result = DB.Create(&newTempMediaItem)
-		if result.Error != nil {
-			log.Printf("UploadMediaHandler: Error creating TemporaryMediaItem record: %v", result.Error)
-			os.Remove(filePath)
+		// The quota is checked again, atomically with the insert
+		err := createWithinQuota(authenticatedUserID, room.ID, sessionID, true, blob.Size, func(tx *gorm.DB) error {
+			return tx.Create(&newTempMediaItem).Error
+		})
+		if err != nil {
+			releaseBlob(c.Request.Context(), blob.Hash)
+			var qe *QuotaError
+			if errors.As(err, &qe) {
+				respondQuotaError(c, qe)
+				return
+			}
+			log.Printf("UploadMediaHandler: Error creating TemporaryMediaItem record: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "File uploaded but failed to save temporary media information"})
 			return
 		}
@@ -232,44 +346,63 @@ func UploadMediaHandler(c *gin.Context) {
 		//	hub.BroadcastToRoom(roomIDUint, messageBytes)
 		//}
 
-		// ✅ Construct public URL for browser access
-		publicURL := fmt.Sprintf("/uploads/temp/%s", uniqueFilename)
+		// ✅ Signed URLs for browser access (private rooms reject unsigned ones)
+		signTemporaryMediaItemURLs(&newTempMediaItem, authenticatedUserID)
 
 		log.Printf("🎉 UploadMediaHandler: Temporary media item '%s' (ID: %d) uploaded successfully to room %d by user %d", newTempMediaItem.FileName, newTempMediaItem.ID, room.ID, authenticatedUserID)
 		c.JSON(http.StatusCreated, gin.H{
-			"message":       "Temporary media item uploaded successfully",
-			"media_item_id": newTempMediaItem.ID,
-			"file_name":     newTempMediaItem.FileName,
-			"original_name": newTempMediaItem.OriginalName,
-			"mime_type":     newTempMediaItem.MimeType,
-			"file_size":     newTempMediaItem.FileSize,
-			"file_path":     newTempMediaItem.FilePath,          // internal path (for cleanup)
-			"file_url":      publicURL,                        // ✅ public URL for playback
-			"poster_url":    newTempMediaItem.PosterURL,       // ✅ poster URL
-			"room_id":       newTempMediaItem.RoomID,
-			"uploader_id":   newTempMediaItem.UploaderID,
-			"duration":      newTempMediaItem.Duration,
-			"is_temporary":  true,
+			"message":        "Temporary media item uploaded successfully",
+			"media_item_id":  newTempMediaItem.ID,
+			"file_name":      newTempMediaItem.FileName,
+			"original_name":  newTempMediaItem.OriginalName,
+			"mime_type":      newTempMediaItem.MimeType,
+			"file_size":      newTempMediaItem.FileSize,
+			"file_path":      newTempMediaItem.FilePath,   // storage key (for cleanup)
+			"file_url":       newTempMediaItem.FileURL,    // ✅ signed URL for playback
+			"poster_url":     newTempMediaItem.PosterURL,  // ✅ poster URL
+			"url_expires_at": newTempMediaItem.URLExpiresAt,
+			"room_id":        newTempMediaItem.RoomID,
+			"uploader_id":    newTempMediaItem.UploaderID,
+			"duration":       newTempMediaItem.Duration,
+			"metadata":       newTempMediaItem.MediaMetadata,
+			"blob_hash":      newTempMediaItem.BlobHash,
+			"is_temporary":   true,
 		})
 
 	} else {
 		newMediaItem := models.MediaItem{
-			FileName:     uniqueFilename,
-			OriginalName: formFile.Filename,
-			MimeType:     getMimeType(ext),
-			FileSize:     formFile.Size,
-			FilePath:     filePath,
-			PosterURL:    posterURL,
-			RoomID:       room.ID,
-			UploaderID:   authenticatedUserID,
-			Duration:     duration,
-			OrderIndex:   0,
+			FileName:          fileName,
+			OriginalName:      originalName,
+			MimeType:          blob.MimeType,
+			FileSize:          blob.Size,
+			FilePath:          blob.StorageKey,
+			BlobHash:          blob.Hash,
+			PosterURL:         blob.PosterURL,
+			PosterVariantKeys: blob.PosterVariantKeys,
+			RoomID:            room.ID,
+			UploaderID:        authenticatedUserID,
+			Duration:          blob.Duration,
+			OrderIndex:        0,
+			MediaMetadata:     blob.MediaMetadata,
+		}
+
+		// Content seen before may already have a storyboard
+		var sibling models.MediaItem
+		if err := DB.Select("storyboard_key").Where("blob_hash = ? AND storyboard_key <> ''", blob.Hash).First(&sibling).Error; err == nil {
+			newMediaItem.StoryboardKey = sibling.StoryboardKey
 		}
 
-		result = DB.Create(&newMediaItem)
-		if result.Error != nil {
-			log.Printf("UploadMediaHandler: Error creating MediaItem record: %v", result.Error)
-			os.Remove(filePath)
+		err := createWithinQuota(authenticatedUserID, room.ID, "", false, blob.Size, func(tx *gorm.DB) error {
+			return tx.Create(&newMediaItem).Error
+		})
+		if err != nil {
+			releaseBlob(c.Request.Context(), blob.Hash)
+			var qe *QuotaError
+			if errors.As(err, &qe) {
+				respondQuotaError(c, qe)
+				return
+			}
+			log.Printf("UploadMediaHandler: Error creating MediaItem record: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "File uploaded but failed to save media information"})
 			return
 		}
@@ -286,24 +419,81 @@ func UploadMediaHandler(c *gin.Context) {
 		//	hub.BroadcastToRoom(roomIDUint, messageBytes)
 		//}
 
+		// Chapters embedded in the file become the item's initial chapter list
+		importEmbeddedChapters(newMediaItem.ID, blob.Chapters)
+
+		// Seek-preview sprites take a while on long videos, so build them after responding
+		if newMediaItem.StoryboardKey == "" && newMediaItem.MediaKind == models.MediaKindVideo {
+			go generateMediaStoryboard(newMediaItem.ID)
+		}
+		if detectMarkersOnUpload() {
+			startMarkerDetection(&newMediaItem, false)
+		}
+
+		signMediaItemURLs(&newMediaItem, authenticatedUserID)
+
 		log.Printf("🎉 UploadMediaHandler: Media item '%s' (ID: %d) uploaded successfully to room %d by user %d", newMediaItem.FileName, newMediaItem.ID, room.ID, authenticatedUserID)
 		c.JSON(http.StatusCreated, gin.H{
-			"message":       "Media item uploaded successfully",
-			"media_item":    newMediaItem,
-			"file_name":     newMediaItem.FileName,
-			"original_name": newMediaItem.OriginalName,
-			"mime_type":     newMediaItem.MimeType,
-			"file_size":     newMediaItem.FileSize,
-			"file_path":     newMediaItem.FilePath,
-			"poster_url":    newMediaItem.PosterURL,
-			"room_id":       newMediaItem.RoomID,
-			"uploader_id":   newMediaItem.UploaderID,
-			"duration":      newMediaItem.Duration,
-			"is_temporary":  false,
+			"message":        "Media item uploaded successfully",
+			"media_item":     newMediaItem,
+			"file_name":      newMediaItem.FileName,
+			"original_name":  newMediaItem.OriginalName,
+			"mime_type":      newMediaItem.MimeType,
+			"file_size":      newMediaItem.FileSize,
+			"file_path":      newMediaItem.FilePath,
+			"file_url":       newMediaItem.FileURL,
+			"poster_url":     newMediaItem.PosterURL,
+			"url_expires_at": newMediaItem.URLExpiresAt,
+			"room_id":        newMediaItem.RoomID,
+			"uploader_id":    newMediaItem.UploaderID,
+			"duration":       newMediaItem.Duration,
+			"metadata":       newMediaItem.MediaMetadata,
+			"blob_hash":      newMediaItem.BlobHash,
+			"is_temporary":   false,
 		})
 	}
 }
 
+// Upload allowlists per media kind.
+var (
+	videoExtensions = map[string]bool{".mp4": true, ".avi": true, ".mov": true, ".mkv": true, ".webm": true}
+	audioExtensions = map[string]bool{".mp3": true, ".flac": true, ".ogg": true, ".m4a": true}
+	imageExtensions = map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".webp": true}
+)
+
+// mediaKindForExt returns the media kind an upload with this extension is treated as,
+// or "" if the extension is not allowed.
+func mediaKindForExt(ext string) string {
+	switch {
+	case videoExtensions[ext]:
+		return models.MediaKindVideo
+	case audioExtensions[ext]:
+		return models.MediaKindAudio
+	case imageExtensions[ext]:
+		return models.MediaKindImage
+	default:
+		return ""
+	}
+}
+
+// probeUpload validates an uploaded file as the given kind and returns its metadata.
+// Images only carry their dimensions.
+func probeUpload(ctx context.Context, kind, workPath string) (*mediatools.MediaInfo, error) {
+	runner := mediatools.Default()
+	switch kind {
+	case models.MediaKindAudio:
+		return runner.ProbeAudio(ctx, workPath)
+	case models.MediaKindImage:
+		width, height, err := runner.ProbeImage(ctx, workPath)
+		if err != nil {
+			return nil, err
+		}
+		return &mediatools.MediaInfo{Width: width, Height: height, AudioLanguages: []string{}}, nil
+	default:
+		return runner.ProbeMedia(ctx, workPath)
+	}
+}
+
 func getMimeType(ext string) string {
 	switch ext {
 	case ".mp4":
@@ -316,7 +506,45 @@ func getMimeType(ext string) string {
 		return "video/x-matroska"
 	case ".webm":
 		return "video/webm"
+	case ".mp3":
+		return "audio/mpeg"
+	case ".flac":
+		return "audio/flac"
+	case ".ogg":
+		return "audio/ogg"
+	case ".m4a":
+		return "audio/mp4"
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".png":
+		return "image/png"
+	case ".webp":
+		return "image/webp"
 	default:
 		return "application/octet-stream"
 	}
+}
+
+// mediaMetadataFromInfo copies probed ffprobe info into the model's metadata columns.
+func mediaMetadataFromInfo(info *mediatools.MediaInfo) models.MediaMetadata {
+	return models.MediaMetadata{
+		DurationSeconds: info.DurationSeconds,
+		Width:           info.Width,
+		Height:          info.Height,
+		FrameRate:       info.FrameRate,
+		VideoCodec:      info.VideoCodec,
+		AudioCodec:      info.AudioCodec,
+		Bitrate:         info.Bitrate,
+		AudioLanguages:  info.AudioLanguages,
+		Container:       info.Container,
+	}
+}
+
+// chapterMarksFromInfo copies probed embedded chapters into the form kept on MediaBlob.
+func chapterMarksFromInfo(info *mediatools.MediaInfo) []models.ChapterMark {
+	var marks []models.ChapterMark
+	for _, ch := range info.Chapters {
+		marks = append(marks, models.ChapterMark{Title: ch.Title, Start: ch.Start, End: ch.End})
+	}
+	return marks
 }
\ No newline at end of file
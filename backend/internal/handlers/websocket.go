@@ -3,6 +3,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -87,6 +88,7 @@ type Hub struct {
 	
 	// ✅ Track host disconnections for auto-end after grace period
 	hostDisconnectTimes map[string]time.Time // session_id → host disconnect time
+	handoffsInFlight    map[string]bool      // session_id → an auto-handoff is running
 	hostDisconnectMutex sync.RWMutex
 
 	// Track which user is streaming in each room (server broadcast)
@@ -160,6 +162,7 @@ func NewHub() *Hub {
 		sessionMembers:      make(map[string]map[*Client]bool),
 		orphanedSessions:    make(map[string]time.Time),
 		hostDisconnectTimes: make(map[string]time.Time), // ✅ Initialize host disconnect tracking
+		handoffsInFlight:    make(map[string]bool),
 		roomStreamHost:      make(map[uint]uint),
 		roomStreamActive:    make(map[uint]bool),
 		clientRegistry:      make(map[uint]map[uint]*Client),
@@ -168,17 +171,34 @@ func NewHub() *Hub {
 	}
 }
 
-// ✅ CheckHostDisconnectTimers runs periodically to auto-end sessions when host is gone > 10 minutes
+// ✅ CheckHostDisconnectTimers runs periodically to hand sessions to a co-host or admin when
+// the host is away, and to auto-end them when nobody took over within 10 minutes
 func (h *Hub) CheckHostDisconnectTimers() {
 	h.hostDisconnectMutex.Lock()
 	defer h.hostDisconnectMutex.Unlock()
 	
 	now := time.Now()
 	const gracePeriod = 10 * time.Minute
+	handoffAfter := hostHandoffAfter()
 	
 	for sessionID, disconnectTime := range h.hostDisconnectTimes {
 		elapsed := now.Sub(disconnectTime)
 		
+		if handoffAfter > 0 && elapsed >= handoffAfter && elapsed < gracePeriod {
+			// One attempt at a time per session; a slow one must not pile up across ticks
+			if h.handoffsInFlight[sessionID] {
+				continue
+			}
+			h.handoffsInFlight[sessionID] = true
+			go func(sid string) {
+				autoHandoffHost(sid)
+				h.hostDisconnectMutex.Lock()
+				delete(h.handoffsInFlight, sid)
+				h.hostDisconnectMutex.Unlock()
+			}(sessionID)
+			continue
+		}
+		
 		if elapsed >= gracePeriod {
 			log.Printf("⏰ Host disconnect grace period exceeded for session %s (%.1f minutes) - auto-ending session", 
 				sessionID, elapsed.Minutes())
@@ -188,10 +208,8 @@ func (h *Hub) CheckHostDisconnectTimers() {
 			
 			// Auto-end the session (run in goroutine to avoid blocking)
 			go func(sid string) {
-				if err := AutoEndSession(sid); err != nil {
+				if _, err := EndSession(context.Background(), sid, models.SessionEndHostTimeout, 0); err != nil && !errors.Is(err, ErrSessionEnded) {
 					log.Printf("❌ Failed to auto-end session %s: %v", sid, err)
-				} else {
-					log.Printf("✅ Successfully auto-ended session %s after host disconnect", sid)
 				}
 			}(sessionID)
 		}
@@ -220,8 +238,19 @@ func (h *Hub) startBroadcastWorkers() {
                 continue
             }
 
-            // Fan-out non-blocking to each client
+            // Fan-out non-blocking to each client; clients held out of the session only
+            // get room-level messages
+            var roomLevel *bool
             for c := range clients {
+                if c.heldOutOfSession() {
+                    if roomLevel == nil {
+                        v := roomLevelMessage(data)
+                        roomLevel = &v
+                    }
+                    if !*roomLevel {
+                        continue
+                    }
+                }
                 // ✅ Check if channel is closed before sending
                 func() {
                     defer func() {
@@ -320,15 +349,30 @@ func (h *Hub) JoinWatchSession(sessionID string, client *Client) error {
         IsActive:      true,
         UserRole:      "viewer",
     }
+    // Co-hosts stay co-hosts when they reconnect
+    var coHostRows int64
+    DB.Model(&models.WatchSessionMember{}).
+        Where("watch_session_id = ? AND user_id = ? AND is_co_host = ?", session.ID, client.userID, true).
+        Count(&coHostRows)
+    member.IsCoHost = coHostRows > 0
 
     if err := DB.Create(&member).Error; err != nil {
         delete(h.sessionMembers[sessionID], client)
         return fmt.Errorf("failed to record session member: %v", err)
     }
 
+    go touchSession(sessionID)
+    go recordTimelineMember(sessionID, session.RoomID, client.userID, models.TimelineJoin)
     return nil
 }
 
+// isSessionMember reports whether the client joined the watch session
+func (h *Hub) isSessionMember(sessionID string, client *Client) bool {
+    h.sessionMutex.RLock()
+    defer h.sessionMutex.RUnlock()
+    return h.sessionMembers[sessionID][client]
+}
+
 // cleanupClientSync removes a client from all hub state immediately
 func (h *Hub) cleanupClientSync(client *Client) {
     log.Printf("[cleanupClientSync] 🧹 Starting cleanup for client %p (user %d, room %d)", client, client.userID, client.roomID)
@@ -446,18 +490,15 @@ func (h *Hub) Run() {
 							log.Printf("⚠️ Failed to mark user %d as left from session %d: %v", client.userID, activeSession.ID, result.Error)
 						} else if result.RowsAffected > 0 {
 							log.Printf("✅ Marked user %d as left from session %s (watch_session_id=%d)", client.userID, activeSession.SessionID, activeSession.ID)
+							recordTimelineMember(activeSession.SessionID, activeSession.RoomID, client.userID, models.TimelineLeave)
+							go admitFromWaitlist(activeSession.SessionID)
 						}
 						
-						// ✅ CHECK IF DISCONNECTING USER IS THE HOST
-						// If host disconnects, start 10-minute countdown to auto-end session
-						var room models.Room
-						if err := DB.First(&room, activeSession.RoomID).Error; err == nil {
-							if room.HostID == client.userID {
-								h.hostDisconnectMutex.Lock()
-								h.hostDisconnectTimes[activeSession.SessionID] = now
-								h.hostDisconnectMutex.Unlock()
-								log.Printf("⏱️ Host (user %d) disconnected from session %s - 10-minute auto-end timer started", client.userID, activeSession.SessionID)
-							}
+						// ✅ CHECK IF DISCONNECTING USER IS THE SESSION HOST
+						// If host disconnects, start the handoff / 10-minute auto-end countdown
+						if activeSession.HostID == client.userID {
+							h.sessionHostDisconnected(activeSession.SessionID, now)
+							log.Printf("⏱️ Host (user %d) disconnected from session %s - handoff/auto-end timer started", client.userID, activeSession.SessionID)
 						}
 					}
 
@@ -743,6 +784,7 @@ func (c *Client) readPump() {
     
     defer func() {
         log.Printf("[readPump] 🛑 Exiting read loop for user %d (client=%p)", c.userID, c)
+        c.leaveLobby()
         c.hub.unregister <- c
         c.conn.Close()
     }()
@@ -921,6 +963,7 @@ func WebSocketHandler(c *gin.Context) {
 					RoomID:    roomID,
 					HostID:    authenticatedUserID,
 					StartedAt: time.Now(),
+					State:     models.SessionStateCreated,
 				}
 				if err := DB.Create(&watchSession).Error; err != nil {
 					log.Printf("Failed to create watch session: %v", err)
@@ -997,19 +1040,28 @@ func WebSocketHandler(c *gin.Context) {
 	log.Printf("[WebSocketHandler] ✅ Registered client %p in clientRegistry for user %d, room %d", client, authenticatedUserID, roomID)
 	hub.registryMutex.Unlock()
 
-	// Join the watch session (only if there's an active session)
-	if sessionID != "" {
-		if err := hub.JoinWatchSession(sessionID, client); err != nil {
+	// Sessions that require approval hold new viewers back: a VideoWatch connection knocks
+	// and waits in the lobby, a RoomPage connection stays out of the session
+	needsApproval := sessionID != "" && needsJoinApproval(&watchSession, authenticatedUserID)
+	inLobby := needsApproval && c.Query("session_id") != ""
+	// Join the watch session (only if there's an active session); a full session puts
+	// them on its waitlist instead
+	isFull := false
+	if sessionID != "" && !needsApproval {
+		joined, err := client.joinUnlessFull(&watchSession)
+		if err != nil {
 			log.Printf("Failed to join watch session: %v", err)
 		}
+		isFull = !joined
 	}
+	waitlisted := isFull && c.Query("session_id") != ""
 
 	// ✅ SMART RECONNECTION: Check if user was previously in this session and disconnected
 	// ⚠️ CRITICAL: Only reactivate if session_id was provided in query params
 	// This prevents RoomPage reconnections from cancelling the auto-end timer
 	var restoredSeatID string
 	sessionIDFromQuery := c.Query("session_id")
-	if sessionIDFromQuery != "" && watchSession.ID != 0 {
+	if sessionIDFromQuery != "" && watchSession.ID != 0 && !inLobby && !waitlisted {
 		var inactiveMember models.WatchSessionMember
 		err := DB.Where("watch_session_id = ? AND user_id = ? AND is_active = ?", 
 			watchSession.ID, authenticatedUserID, false).
@@ -1034,21 +1086,6 @@ func WebSocketHandler(c *gin.Context) {
 			} else {
 				log.Printf("✅ Reactivated session membership for user %d", authenticatedUserID)
 				
-				// ✅ CANCEL HOST AUTO-END TIMER IF HOST RECONNECTS
-				var room models.Room
-				if err := DB.First(&room, watchSession.RoomID).Error; err == nil {
-					if room.HostID == authenticatedUserID {
-						hub.hostDisconnectMutex.Lock()
-						if disconnectTime, exists := hub.hostDisconnectTimes[sessionID]; exists {
-							delete(hub.hostDisconnectTimes, sessionID)
-							elapsed := time.Since(disconnectTime)
-							log.Printf("✅ Host (user %d) reconnected to session %s after %.1f seconds - auto-end timer cancelled", 
-								authenticatedUserID, sessionID, elapsed.Seconds())
-						}
-						hub.hostDisconnectMutex.Unlock()
-					}
-				}
-				
 				// Try to restore their previous seat
 				hub.seatingMutex.Lock()
 				if _, exists := hub.seatingAssignments[roomID]; !exists {
@@ -1078,6 +1115,11 @@ func WebSocketHandler(c *gin.Context) {
 			// First time joining this session - normal flow
 			log.Printf("👋 User %d joining session %s for the first time", authenticatedUserID, sessionID)
 		}
+
+		// ✅ The host joining makes the session live (and cancels the host auto-end timer)
+		if watchSession.HostID == authenticatedUserID {
+			hub.sessionHostConnected(sessionID)
+		}
 	} else if sessionID != "" && sessionIDFromQuery == "" {
 		// Active session exists but user connected to RoomPage (no session_id param)
 		// Do NOT reactivate membership - let the auto-end timer continue
@@ -1086,9 +1128,70 @@ func WebSocketHandler(c *gin.Context) {
 
 	// --- COLLECT STARTUP MESSAGES (but DO NOT send yet) ---
 	var startupMessages []OutgoingMessage
+	if inLobby {
+		client.knock(&watchSession)
+	} else if waitlisted {
+		client.joinWaitlist(&watchSession)
+	} else {
+		if status := sessionStatusMessage(roomID, &watchSession); status.Data != nil {
+			startupMessages = append(startupMessages, status)
+		}
+
+		// Screen sharing startup is now handled by LiveKit
+
+		// A RoomPage connection that still needs approval, or found the session full, stays
+		// out of the session's traffic
+		if needsApproval || isFull {
+			client.holdOutOfSession(sessionID)
+		}
+		hub.enterRoom(client)
+	}
+
+	// --- START PUMPS FIRST ---
+	log.Printf("[WebSocketHandler] 🚀 Starting pumps for user %d in room %d, client=%p", authenticatedUserID, roomID, client)
+	go func() {
+		log.Printf("[writePump] ▶️ STARTED for user %d (client=%p)", client.userID, client)
+		client.writePump()
+		log.Printf("[writePump] ⏹️ EXITED for user %d (client=%p)", client.userID, client)
+	}()
+	go func() {
+		log.Printf("[readPump] ▶️ STARTED for user %d (client=%p)", client.userID, client)
+		client.readPump()
+		log.Printf("[readPump] ⏹️ EXITED for user %d (client=%p)", client.userID, client)
+	}()
+
+	// --- SEND STARTUP MESSAGES AFTER PUMPS ARE RUNNING ---
+	go func() {
+		// Small delay to ensure writePump is listening
+		time.Sleep(10 * time.Millisecond)
+		
+		// ✅ Recover from panic if channel is closed during send
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("⚠️ Recovered from panic sending startup messages to user %d (client was cleaned up): %v", client.userID, r)
+			}
+		}()
+		
+		for _, msg := range startupMessages {
+			select {
+			case client.send <- msg:
+				log.Printf("✅ Sent startup message to user %d", client.userID)
+			case <-time.After(100 * time.Millisecond):
+				log.Printf("⚠️ Timeout sending startup message to user %d (client likely cleaned up)", client.userID)
+				return // Stop trying if we timeout
+			}
+		}
+	}()
+
+	log.Printf("[WebSocketHandler] ✅ Pumps launched for user %d, blocking forever", authenticatedUserID)
+	select {}
+}
 
-	// Build session_status (only if there's an active session)
-	if sessionID != "" && watchSession.ID != 0 {
+// sessionStatusMessage builds the session_status a client gets when it enters the room:
+// the running session's members and seating, or an empty status when there is none.
+func sessionStatusMessage(roomID uint, watchSession *models.WatchSession) OutgoingMessage {
+	var status OutgoingMessage
+	if watchSession.SessionID != "" && watchSession.ID != 0 {
 		members, err := GetSessionMembers(DB, watchSession.ID)
 		if err != nil {
 			log.Printf("Failed to fetch session members: %v", err)
@@ -1099,7 +1202,7 @@ func WebSocketHandler(c *gin.Context) {
 				},
 			}
 			if msgBytes, err := json.Marshal(statusMsg); err == nil {
-				startupMessages = append(startupMessages, OutgoingMessage{Data: msgBytes, IsBinary: false})
+				status = OutgoingMessage{Data: msgBytes, IsBinary: false}
 			}
 		} else {
 			trimmedMembers := make([]map[string]interface{}, len(members))
@@ -1133,7 +1236,7 @@ func WebSocketHandler(c *gin.Context) {
 				},
 			}
 			if msgBytes, err := json.Marshal(statusMsg); err == nil {
-				startupMessages = append(startupMessages, OutgoingMessage{Data: msgBytes, IsBinary: false})
+				status = OutgoingMessage{Data: msgBytes, IsBinary: false}
 			}
 		}
 	} else {
@@ -1149,25 +1252,27 @@ func WebSocketHandler(c *gin.Context) {
 			},
 		}
 		if msgBytes, err := json.Marshal(statusMsg); err == nil {
-			startupMessages = append(startupMessages, OutgoingMessage{Data: msgBytes, IsBinary: false})
+			status = OutgoingMessage{Data: msgBytes, IsBinary: false}
 		}
 	}
+	return status
+}
 
-	// Screen sharing startup is now handled by LiveKit
-
-	// ✅ Now register in hub.rooms
-	hub.mutex.Lock()
-	if _, ok := hub.rooms[roomID]; !ok {
-		hub.rooms[roomID] = make(map[*Client]bool)
+// enterRoom registers a client in its room, so it gets the room's broadcasts, and tells the
+// others it joined.
+func (h *Hub) enterRoom(client *Client) {
+	h.mutex.Lock()
+	if _, ok := h.rooms[client.roomID]; !ok {
+		h.rooms[client.roomID] = make(map[*Client]bool)
 	}
-	hub.rooms[roomID][client] = true
-	hub.mutex.Unlock()
-	log.Printf("Hub: Client %p (User %d) synchronously registered for room %d", client, authenticatedUserID, roomID)
+	h.rooms[client.roomID][client] = true
+	h.mutex.Unlock()
+	log.Printf("Hub: Client %p (User %d) synchronously registered for room %d", client, client.userID, client.roomID)
 
 	// ✅ FETCH USERNAME FOR JOIN MESSAGE
 	var username string
-	if err := DB.Model(&models.User{}).Select("username").Where("id = ?", authenticatedUserID).Scan(&username).Error; err != nil {
-		log.Printf("⚠️ Could not fetch username for user %d: %v", authenticatedUserID, err)
+	if err := DB.Model(&models.User{}).Select("username").Where("id = ?", client.userID).Scan(&username).Error; err != nil {
+		log.Printf("⚠️ Could not fetch username for user %d: %v", client.userID, err)
 		username = "Anonymous"
 	}
 
@@ -1175,52 +1280,13 @@ func WebSocketHandler(c *gin.Context) {
 	joinMsg := WebSocketMessage{
 		Type: "participant_join",
 		Data: map[string]interface{}{
-			"userId":   authenticatedUserID,
+			"userId":   client.userID,
 			"username": username,
 		},
 	}
 	if joinBytes, err := json.Marshal(joinMsg); err == nil {
-		hub.BroadcastToRoom(roomID, OutgoingMessage{Data: joinBytes, IsBinary: false}, client) // exclude self
+		h.BroadcastToRoom(client.roomID, OutgoingMessage{Data: joinBytes, IsBinary: false}, client) // exclude self
 	}
-
-	// --- START PUMPS FIRST ---
-	log.Printf("[WebSocketHandler] 🚀 Starting pumps for user %d in room %d, client=%p", authenticatedUserID, roomID, client)
-	go func() {
-		log.Printf("[writePump] ▶️ STARTED for user %d (client=%p)", client.userID, client)
-		client.writePump()
-		log.Printf("[writePump] ⏹️ EXITED for user %d (client=%p)", client.userID, client)
-	}()
-	go func() {
-		log.Printf("[readPump] ▶️ STARTED for user %d (client=%p)", client.userID, client)
-		client.readPump()
-		log.Printf("[readPump] ⏹️ EXITED for user %d (client=%p)", client.userID, client)
-	}()
-
-	// --- SEND STARTUP MESSAGES AFTER PUMPS ARE RUNNING ---
-	go func() {
-		// Small delay to ensure writePump is listening
-		time.Sleep(10 * time.Millisecond)
-		
-		// ✅ Recover from panic if channel is closed during send
-		defer func() {
-			if r := recover(); r != nil {
-				log.Printf("⚠️ Recovered from panic sending startup messages to user %d (client was cleaned up): %v", client.userID, r)
-			}
-		}()
-		
-		for _, msg := range startupMessages {
-			select {
-			case client.send <- msg:
-				log.Printf("✅ Sent startup message to user %d", client.userID)
-			case <-time.After(100 * time.Millisecond):
-				log.Printf("⚠️ Timeout sending startup message to user %d (client likely cleaned up)", client.userID)
-				return // Stop trying if we timeout
-			}
-		}
-	}()
-
-	log.Printf("[WebSocketHandler] ✅ Pumps launched for user %d, blocking forever", authenticatedUserID)
-	select {}
 }
 
 // InitializeHub creates and starts the global hub.
@@ -1249,6 +1315,9 @@ func InitializeHub() {
             }
         }()
         log.Println("✅ Stale session cleanup started (runs hourly, ends sessions >24 hours old)")
+        
+        startSessionExpiry()
+        startRetentionPurge()
     }
 }
 
@@ -1272,31 +1341,7 @@ func CleanupStaleSessions() {
 	}
 	
 	log.Printf("🗑️ [CleanupStaleSessions] Found %d stale sessions to clean up", len(staleSessions))
-	
-	for _, session := range staleSessions {
-		sessionAge := time.Since(session.StartedAt)
-		log.Printf("🔚 [CleanupStaleSessions] Ending stale session %s (age: %.1f hours)", 
-			session.SessionID, sessionAge.Hours())
-		
-		// End the session
-		now := time.Now()
-		if err := DB.Model(&session).Update("ended_at", now).Error; err != nil {
-			log.Printf("❌ [CleanupStaleSessions] Failed to end session %s: %v", session.SessionID, err)
-			continue
-		}
-		
-		// Broadcast session_ended to the room (in case any clients are still connected)
-		broadcastMsg := OutgoingMessage{
-			Data: []byte(fmt.Sprintf(`{"type":"session_ended","data":{"session_id":"%s","room_id":%d,"reason":"stale_cleanup"}}`, 
-				session.SessionID, session.RoomID)),
-			IsBinary: false,
-		}
-		hub.BroadcastToRoom(session.RoomID, broadcastMsg, nil)
-		
-		log.Printf("✅ [CleanupStaleSessions] Ended stale session %s", session.SessionID)
-	}
-	
-	log.Printf("✅ [CleanupStaleSessions] Cleanup complete - ended %d stale sessions", len(staleSessions))
+	endSessions(staleSessions, models.SessionEndStale, "CleanupStaleSessions")
 }
 
 // Helper to create a pointer to an int
@@ -1340,6 +1385,26 @@ func (client *Client) handleMessage(message []byte) {
 
     log.Printf("[handleMessage] 📋 Message type: '%s' from user %d", msg.Type, client.userID)
 
+    // ✅ Clients waiting for join approval may only cancel their request
+    if client.inLobby() {
+        client.handleLobbyMessage(msg)
+        return
+    }
+
+    // ✅ Room sockets held out of the session can't send into it
+    if client.heldOutOfSession() {
+        log.Printf("[handleMessage] Ignoring '%s' from user %d, who is not in the session", msg.Type, client.userID)
+        return
+    }
+
+    // ✅ Anything a member sends keeps their watch session from expiring as idle
+    touchSession(client.streamID)
+
+    // ✅ Handle playback_report - periodic "still watching" ping from players, not relayed
+    if msg.Type == "playback_report" {
+        return
+    }
+
     // ✅ Handle client_ready: send session_status
     if msg.Type == "client_ready" {
         log.Printf("Client %d sent client_ready for room %d", client.userID, client.roomID)
@@ -1923,6 +1988,8 @@ func (client *Client) handleMessage(message []byte) {
                 log.Printf("⚠️ [leave_seat] Failed to mark user %d as left: %v", leaveSeat.UserID, result.Error)
             } else if result.RowsAffected > 0 {
                 log.Printf("✅ [leave_seat] Marked user %d as left from session %s", leaveSeat.UserID, activeSession.SessionID)
+                recordTimelineMember(activeSession.SessionID, activeSession.RoomID, leaveSeat.UserID, models.TimelineLeave)
+                go admitFromWaitlist(activeSession.SessionID)
             }
             
             // 🎭 THEATER CLEANUP: Remove user from theater assignment
@@ -2074,11 +2141,10 @@ func (client *Client) handleMessage(message []byte) {
 
     // ✅ Handle chat_message - save to DB and broadcast
     if msg.Type == "chat_message" {
+        // The sender is whoever owns this connection; user_id/username in the payload are ignored
         var chatData struct {
             Message   string `json:"message"`
             SessionID string `json:"session_id"`
-            UserID    uint   `json:"user_id"`
-            Username  string `json:"username"`
         }
 
         if dataBytes, ok := msg.Data.([]byte); ok {
@@ -2087,14 +2153,13 @@ func (client *Client) handleMessage(message []byte) {
                 return
             }
         } else if m, ok := msg.Data.(map[string]interface{}); ok {
-            chatData.Message = m["message"].(string)
-            chatData.SessionID = m["session_id"].(string)
-            if uid, ok := m["user_id"].(float64); ok {
-                chatData.UserID = uint(uid)
-            }
-            if uname, ok := m["username"].(string); ok {
-                chatData.Username = uname
-            }
+            chatData.Message, _ = m["message"].(string)
+            chatData.SessionID, _ = m["session_id"].(string)
+        }
+        var username string
+        if err := DB.Model(&models.User{}).Select("username").Where("id = ?", client.userID).Scan(&username).Error; err != nil {
+            log.Printf("[chat_message] ❌ Failed to look up user %d: %v", client.userID, err)
+            return
         }
 
         // 🎭 Get theater info for this user (only for 3D cinema)
@@ -2103,10 +2168,37 @@ func (client *Client) handleMessage(message []byte) {
         var totalTheaters int
         
         var activeSession models.WatchSession
-        if err := DB.Where("session_id = ?", chatData.SessionID).First(&activeSession).Error; err == nil {
+        sessionErr := DB.Where("session_id = ?", chatData.SessionID).First(&activeSession).Error
+
+        // An ended session's chat takes no new messages (and is read-only for good when archived)
+        archived := sessionChatArchived(chatData.SessionID)
+        if archived || (sessionErr == nil && activeSession.EndedAt != nil) {
+            reason := "session_ended"
+            if archived {
+                reason = "chat_archived"
+            }
+            log.Printf("[chat_message] ❌ Rejected message from user %d: session %s has ended", client.userID, chatData.SessionID)
+            go sendToClient(client, "chat_rejected", map[string]interface{}{
+                "session_id": chatData.SessionID,
+                "reason":     reason,
+            })
+            return
+        }
+
+        // Only members of a session can post to its chat
+        if chatData.SessionID != "" && !client.hub.isSessionMember(chatData.SessionID, client) {
+            log.Printf("[chat_message] ❌ Rejected message from user %d: not a member of session %s", client.userID, chatData.SessionID)
+            go sendToClient(client, "chat_rejected", map[string]interface{}{
+                "session_id": chatData.SessionID,
+                "reason":     "not_a_member",
+            })
+            return
+        }
+
+        if sessionErr == nil {
             if activeSession.WatchType == "3d_cinema" {
                 // Get user's theater assignment
-                assignment, err := GetUserTheaterAssignment(chatData.UserID, activeSession.ID)
+                assignment, err := GetUserTheaterAssignment(client.userID, activeSession.ID)
                 if err == nil && assignment != nil && assignment.Theater != nil {
                     theaterNumber = assignment.Theater.TheaterNumber
                     theaterName = assignment.Theater.GetDisplayName()
@@ -2124,15 +2216,22 @@ func (client *Client) handleMessage(message []byte) {
         chatMessage := models.ChatMessage{
             RoomID:    client.roomID,
             SessionID: chatData.SessionID,
-            UserID:    chatData.UserID,
-            Username:  chatData.Username,
+            UserID:    client.userID,
+            Username:  username,
             Message:   chatData.Message,
         }
 
         if err := DB.Create(&chatMessage).Error; err != nil {
             log.Printf("[chat_message] ❌ Failed to save chat message: %v", err)
         } else {
-            log.Printf("[chat_message] ✅ Saved message ID=%d from user %d in session %s", chatMessage.ID, chatData.UserID, chatData.SessionID)
+            log.Printf("[chat_message] ✅ Saved message ID=%d from user %d in session %s", chatMessage.ID, client.userID, chatData.SessionID)
+            recordTimelineEvent(models.SessionTimelineEvent{
+                SessionID:     chatMessage.SessionID,
+                RoomID:        client.roomID,
+                Kind:          models.TimelineChat,
+                UserID:        chatMessage.UserID,
+                ChatMessageID: chatMessage.ID,
+            })
         }
 
         // Broadcast enriched message with DB ID and theater info
@@ -2151,7 +2250,7 @@ func (client *Client) handleMessage(message []byte) {
             messageData["theater_number"] = theaterNumber
             messageData["theater_name"] = theaterName
             messageData["total_theaters"] = totalTheaters
-            log.Printf("[chat_message] 🎭 User %d in Theater %d (total: %d theaters)", chatData.UserID, theaterNumber, totalTheaters)
+            log.Printf("[chat_message] 🎭 User %d in Theater %d (total: %d theaters)", client.userID, theaterNumber, totalTheaters)
         }
         
         enrichedMsg := map[string]interface{}{
@@ -2431,6 +2530,54 @@ func (client *Client) handleMessage(message []byte) {
         return
     }
 
+    // ✅ Handle jump_to_chapter - host moves the whole room to a chapter
+    if msg.Type == "jump_to_chapter" {
+        client.handleJumpToChapter(msg)
+        return
+    }
+
+    // ✅ Handle transfer_host / set_cohost - the host hands over the session or picks co-hosts
+    if msg.Type == "transfer_host" {
+        client.handleTransferHost(msg)
+        return
+    }
+    if msg.Type == "set_cohost" {
+        client.handleSetCoHost(msg)
+        return
+    }
+
+    // ✅ Handle approve_join / deny_join / set_join_approval - the host answers knocks
+    if msg.Type == "approve_join" || msg.Type == "deny_join" {
+        client.handleJoinDecision(msg)
+        return
+    }
+    if msg.Type == "set_join_approval" {
+        client.handleSetJoinApproval(msg)
+        return
+    }
+
+    // ✅ Handle lobby_chat - hosts talk to the viewers waiting in the lobby
+    if msg.Type == "lobby_chat" {
+        client.handleLobbyChat(msg)
+        return
+    }
+
+    // ✅ Handle breakout_chat_message - chat within a breakout group
+    if msg.Type == "breakout_chat_message" {
+        client.handleBreakoutChat(msg)
+        return
+    }
+
+    // ✅ Handle skip_intro / skip_credits - host skips the current item's marked intro or credits
+    if msg.Type == "skip_intro" {
+        client.handleSkipIntro(msg)
+        return
+    }
+    if msg.Type == "skip_credits" {
+        client.handleSkipCredits(msg)
+        return
+    }
+
     // ✅ Handle reaction - save to DB and broadcast
     if msg.Type == "reaction" {
         var reactionData struct {
@@ -2462,6 +2609,11 @@ func (client *Client) handleMessage(message []byte) {
             }
         }
 
+        if sessionChatArchived(reactionData.SessionID) {
+            log.Printf("[reaction] ❌ Chat of session %s is archived", reactionData.SessionID)
+            return
+        }
+
         // Save to database
         reaction := models.Reaction{
             UserID:    reactionData.UserID,
@@ -2476,6 +2628,13 @@ func (client *Client) handleMessage(message []byte) {
             log.Printf("[reaction] ❌ Failed to save reaction: %v", err)
         } else {
             log.Printf("[reaction] ✅ Saved reaction ID=%d emoji=%s for message %d", reaction.ID, reaction.Emoji, reactionData.MessageID)
+            recordTimelineEvent(models.SessionTimelineEvent{
+                SessionID:  reaction.SessionID,
+                RoomID:     client.roomID,
+                Kind:       models.TimelineReaction,
+                UserID:     reaction.UserID,
+                ReactionID: reaction.ID,
+            })
         }
 
         // Broadcast reaction
@@ -2496,6 +2655,17 @@ func (client *Client) handleMessage(message []byte) {
         return
     }
     
+    // ✅ playback_control is relayed like everything else; the server only follows along
+    // so it can advance the playlist at the credits and note what the session watched
+    if msg.Type == "playback_control" {
+        client.observePlaybackControl(message)
+        client.observeMediaPlay(message)
+        var room models.Room
+        if err := DB.First(&room, client.roomID).Error; err == nil && isPlaybackHost(&room, client.userID) {
+            recordTimelinePlayback(client.timelineSessionID(), client.roomID, client.userID, message)
+        }
+    }
+
     // ✅ Default: Broadcast all other message types to room
     // This handles: playback_control, update_room_status, platform_selected, etc.
     log.Printf("[handleMessage] 📢 Broadcasting message type '%s' to room %d", msg.Type, client.roomID)
@@ -0,0 +1,405 @@
+// WeWatch/backend/internal/mediagc/mediagc.go
+
+// Package mediagc reconciles the media store with the database: it deletes stored
+// objects nothing points to, removes rows whose file is gone (or whose session has
+// ended), and repairs MediaBlob reference counts. It backs both the scheduled job in
+// the server and the cmd/mediagc CLI.
+package mediagc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"wewatch-backend/internal/models"
+	"wewatch-backend/internal/storage"
+)
+
+// DefaultGracePeriod protects objects written moments ago: uploads reach storage
+// before their database rows exist.
+const DefaultGracePeriod = time.Hour
+
+// Options controls a collection run.
+type Options struct {
+	DryRun      bool          // report what would be done without changing anything
+	GracePeriod time.Duration // objects younger than this are never treated as orphans
+}
+
+// Report summarises a run. In dry-run mode it lists what would have been removed.
+type Report struct {
+	DryRun         bool      `json:"dry_run"`
+	StartedAt      time.Time `json:"started_at"`
+	FinishedAt     time.Time `json:"finished_at"`
+	ObjectsScanned int       `json:"objects_scanned"`
+	BytesScanned   int64     `json:"bytes_scanned"`
+
+	OrphanObjects []string `json:"orphan_objects"`
+	OrphanBytes   int64    `json:"orphan_bytes"`
+
+	DanglingMediaItems []uint   `json:"dangling_media_items"` // file missing from storage
+	DanglingTempItems  []uint   `json:"dangling_temp_items"`  // file missing from storage
+	ExpiredTempItems   []uint   `json:"expired_temp_items"`   // session ended or gone
+	UnreferencedBlobs  []string `json:"unreferenced_blobs"`   // blob rows no item points to
+	MissingBlobs       []string `json:"missing_blobs"`        // blob rows whose file is gone
+	RefCountsFixed     int      `json:"ref_counts_fixed"`
+
+	Errors []string `json:"errors"`
+}
+
+// Summary renders the report as a short human-readable block.
+func (r *Report) Summary() string {
+	verb := "Removed"
+	if r.DryRun {
+		verb = "Would remove"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Media GC (%s, dry-run=%v)\n", r.FinishedAt.Sub(r.StartedAt).Round(time.Millisecond), r.DryRun)
+	fmt.Fprintf(&b, "  Scanned:              %d objects, %s\n", r.ObjectsScanned, formatBytes(r.BytesScanned))
+	fmt.Fprintf(&b, "  %s orphans:  %d objects, %s\n", verb, len(r.OrphanObjects), formatBytes(r.OrphanBytes))
+	fmt.Fprintf(&b, "  Dangling media rows:  %d\n", len(r.DanglingMediaItems))
+	fmt.Fprintf(&b, "  Dangling temp rows:   %d\n", len(r.DanglingTempItems))
+	fmt.Fprintf(&b, "  Expired temp rows:    %d\n", len(r.ExpiredTempItems))
+	fmt.Fprintf(&b, "  Unreferenced blobs:   %d\n", len(r.UnreferencedBlobs))
+	fmt.Fprintf(&b, "  Missing blobs:        %d\n", len(r.MissingBlobs))
+	fmt.Fprintf(&b, "  Ref counts fixed:     %d\n", r.RefCountsFixed)
+	fmt.Fprintf(&b, "  Errors:               %d\n", len(r.Errors))
+	return b.String()
+}
+
+func (r *Report) errorf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	log.Printf("⚠️ mediagc: %s", msg)
+	r.Errors = append(r.Errors, msg)
+}
+
+// collector holds the state of one run.
+type collector struct {
+	db      *gorm.DB
+	store   storage.Storage
+	opts    Options
+	report  *Report
+	objects map[string]storage.ObjectInfo
+
+	referenced map[string]bool
+	prefixes   []string // storyboard directories in use
+}
+
+// Run performs one reconciliation pass.
+func Run(ctx context.Context, db *gorm.DB, store storage.Storage, opts Options) (*Report, error) {
+	if opts.GracePeriod <= 0 {
+		opts.GracePeriod = DefaultGracePeriod
+	}
+	c := &collector{
+		db:         db,
+		store:      store,
+		opts:       opts,
+		report:     &Report{DryRun: opts.DryRun, StartedAt: time.Now()},
+		objects:    map[string]storage.ObjectInfo{},
+		referenced: map[string]bool{},
+	}
+
+	if err := store.List(ctx, "", func(obj storage.ObjectInfo) error {
+		c.objects[obj.Key] = obj
+		c.report.ObjectsScanned++
+		c.report.BytesScanned += obj.Size
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list media store: %w", err)
+	}
+
+	// Rows first, so files they leave behind are collected in the same pass
+	if err := c.reconcileTemporaryItems(); err != nil {
+		return nil, err
+	}
+	if err := c.reconcileMediaItems(); err != nil {
+		return nil, err
+	}
+	if err := c.reconcileBlobs(); err != nil {
+		return nil, err
+	}
+	if err := c.markUserAvatars(); err != nil {
+		return nil, err
+	}
+	c.collectOrphans(ctx)
+
+	c.report.FinishedAt = time.Now()
+	return c.report, nil
+}
+
+func (c *collector) exists(key string) bool {
+	_, ok := c.objects[key]
+	return ok
+}
+
+// markItem records everything a media row keeps in storage.
+func (c *collector) markItem(fileKey, posterURL string, variantKeys []string, storyboardKey string) {
+	c.referenced[fileKey] = true
+	if storage.IsStoredURL(posterURL) {
+		c.referenced[storage.KeyFromPath(posterURL)] = true
+	}
+	for _, k := range variantKeys {
+		c.referenced[k] = true
+	}
+	if storyboardKey != "" {
+		c.prefixes = append(c.prefixes, path.Dir(storyboardKey)+"/")
+	}
+}
+
+// deleteRows soft-deletes rows the same way the handlers do (unless dry-run).
+func (c *collector) deleteRows(model interface{}, ids []uint) {
+	if c.opts.DryRun || len(ids) == 0 {
+		return
+	}
+	if err := c.db.Where("id IN ?", ids).Delete(model).Error; err != nil {
+		c.report.errorf("failed to delete %T rows %v: %v", model, ids, err)
+	}
+}
+
+// reconcileTemporaryItems drops temp rows whose session is over or whose file is gone.
+func (c *collector) reconcileTemporaryItems() error {
+	var items []models.TemporaryMediaItem
+	if err := c.db.Find(&items).Error; err != nil {
+		return fmt.Errorf("failed to load temporary media items: %w", err)
+	}
+
+	var liveSessions []string
+	if err := c.db.Model(&models.WatchSession{}).Where("ended_at IS NULL").Pluck("session_id", &liveSessions).Error; err != nil {
+		return fmt.Errorf("failed to load live sessions: %w", err)
+	}
+	live := make(map[string]bool, len(liveSessions))
+	for _, id := range liveSessions {
+		live[id] = true
+	}
+
+	var expired, dangling []uint
+	for _, item := range items {
+		fileKey := storage.KeyFromPath(item.FilePath)
+		switch {
+		case item.SessionID != "" && !live[item.SessionID]:
+			expired = append(expired, item.ID)
+		case !c.exists(fileKey):
+			dangling = append(dangling, item.ID)
+		default:
+			c.markItem(fileKey, item.PosterURL, item.PosterVariantKeys, "")
+		}
+	}
+
+	c.report.ExpiredTempItems = expired
+	c.report.DanglingTempItems = dangling
+	c.deleteRows(&models.TemporaryMediaItem{}, append(append([]uint{}, expired...), dangling...))
+	return nil
+}
+
+// reconcileMediaItems drops library rows whose file is gone.
+func (c *collector) reconcileMediaItems() error {
+	var items []models.MediaItem
+	if err := c.db.Find(&items).Error; err != nil {
+		return fmt.Errorf("failed to load media items: %w", err)
+	}
+
+	var dangling []uint
+	for _, item := range items {
+		fileKey := storage.KeyFromPath(item.FilePath)
+		if !c.exists(fileKey) {
+			dangling = append(dangling, item.ID)
+			continue
+		}
+		c.markItem(fileKey, item.PosterURL, item.PosterVariantKeys, item.StoryboardKey)
+	}
+
+	c.report.DanglingMediaItems = dangling
+	c.deleteRows(&models.MediaItem{}, dangling)
+	return nil
+}
+
+// reconcileBlobs recounts references from the surviving rows, fixing drifted counts and
+// dropping blobs nothing points to (their files become orphans) or whose file is gone.
+// Each blob is settled in its own transaction holding its row lock, the lock the
+// handlers take to acquire or release it. Blobs touched within the grace period are only
+// marked: an upload acquires its reference before inserting the row that holds it.
+func (c *collector) reconcileBlobs() error {
+	var hashes []string
+	if err := c.db.Model(&models.MediaBlob{}).Pluck("hash", &hashes).Error; err != nil {
+		return fmt.Errorf("failed to load media blobs: %w", err)
+	}
+
+	skip := map[string][]uint{
+		"media_items":           c.report.DanglingMediaItems,
+		"temporary_media_items": append(append([]uint{}, c.report.ExpiredTempItems...), c.report.DanglingTempItems...),
+	}
+	cutoff := time.Now().Add(-c.opts.GracePeriod)
+
+	for _, hash := range hashes {
+		if err := c.db.Transaction(func(tx *gorm.DB) error {
+			return c.reconcileBlob(tx, hash, skip, cutoff)
+		}); err != nil {
+			c.report.errorf("failed to reconcile blob %s: %v", hash, err)
+		}
+	}
+	return nil
+}
+
+func (c *collector) reconcileBlob(tx *gorm.DB, hash string, skip map[string][]uint, cutoff time.Time) error {
+	var blob models.MediaBlob
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("hash = ?", hash).First(&blob).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil // released since we listed it
+	}
+	if err != nil {
+		return err
+	}
+	keep := func() {
+		c.markItem(blob.StorageKey, blob.PosterURL, blob.PosterVariantKeys,
+			"storyboards/"+strings.TrimSuffix(blob.StorageKey, path.Ext(blob.StorageKey))+"/storyboard.vtt")
+	}
+	if blob.UpdatedAt.After(cutoff) {
+		keep()
+		return nil
+	}
+
+	refs := 0
+	for _, src := range []struct {
+		model interface{}
+		table string
+	}{
+		{&models.MediaItem{}, "media_items"},
+		{&models.TemporaryMediaItem{}, "temporary_media_items"},
+		{&models.MediaSlide{}, "media_slides"},
+	} {
+		q := tx.Model(src.model).Where("blob_hash = ?", blob.Hash)
+		if ids := skip[src.table]; len(ids) > 0 {
+			q = q.Where("id NOT IN ?", ids) // rows this run removes (or would remove)
+		}
+		var n int64
+		if err := q.Count(&n).Error; err != nil {
+			return fmt.Errorf("failed to count references: %w", err)
+		}
+		refs += int(n)
+	}
+
+	switch {
+	case !c.exists(blob.StorageKey):
+		c.report.MissingBlobs = append(c.report.MissingBlobs, blob.Hash)
+	case refs == 0:
+		c.report.UnreferencedBlobs = append(c.report.UnreferencedBlobs, blob.Hash)
+	default:
+		keep()
+		if refs != blob.RefCount {
+			log.Printf("🔧 mediagc: Blob %s ref_count %d -> %d", blob.Hash, blob.RefCount, refs)
+			c.report.RefCountsFixed++
+			if !c.opts.DryRun {
+				if err := tx.Model(&blob).Update("ref_count", refs).Error; err != nil {
+					return fmt.Errorf("failed to fix ref_count: %w", err)
+				}
+			}
+		}
+		return nil
+	}
+
+	if c.opts.DryRun {
+		return nil
+	}
+	if err := tx.Delete(&blob).Error; err != nil {
+		return fmt.Errorf("failed to delete blob row: %w", err)
+	}
+	return nil
+}
+
+// markUserAvatars keeps uploaded profile pictures.
+func (c *collector) markUserAvatars() error {
+	var avatars []string
+	if err := c.db.Model(&models.User{}).Where("avatar_url LIKE ?", storage.PublicPrefix+"%").Pluck("avatar_url", &avatars).Error; err != nil {
+		return fmt.Errorf("failed to load user avatars: %w", err)
+	}
+	for _, u := range avatars {
+		c.referenced[storage.KeyFromPath(u)] = true
+	}
+	return nil
+}
+
+func (c *collector) isReferenced(key string) bool {
+	if c.referenced[key] {
+		return true
+	}
+	for _, prefix := range c.prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// collectOrphans deletes (or lists, in dry-run) objects nothing references.
+func (c *collector) collectOrphans(ctx context.Context) {
+	cutoff := time.Now().Add(-c.opts.GracePeriod)
+
+	keys := make([]string, 0, len(c.objects))
+	for key := range c.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		obj := c.objects[key]
+		if c.isReferenced(key) || obj.ModTime.After(cutoff) {
+			continue
+		}
+		c.report.OrphanObjects = append(c.report.OrphanObjects, key)
+		c.report.OrphanBytes += obj.Size
+		if c.opts.DryRun {
+			continue
+		}
+		if err := c.deleteOrphan(ctx, key, cutoff); err != nil {
+			c.report.errorf("failed to delete orphan %s: %v", key, err)
+		}
+	}
+}
+
+// deleteOrphan deletes key under the storage key lock the handlers take to store or
+// delete it, unless it was stored again since the listing (a re-upload of the same content
+// writes the same blob key).
+func (c *collector) deleteOrphan(ctx context.Context, key string, cutoff time.Time) error {
+	return c.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", key).Error; err != nil {
+			return err
+		}
+		var stored int64
+		if err := tx.Model(&models.MediaBlob{}).Where("storage_key = ?", key).Count(&stored).Error; err != nil {
+			return err
+		}
+		if stored > 0 {
+			return nil
+		}
+		info, err := c.store.Stat(ctx, key)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.ModTime.After(cutoff) {
+			return nil
+		}
+		return c.store.Delete(ctx, key)
+	})
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
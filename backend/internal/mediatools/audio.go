@@ -0,0 +1,91 @@
+// WeWatch/backend/internal/mediatools/audio.go
+package mediatools
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// WaveformBuckets is how many peaks WaveformPeaks returns by default - enough for a
+// full-width seek bar without shipping the decoded audio.
+const WaveformBuckets = 1000
+
+// waveformSampleRate is the rate audio is decoded at for peak analysis. Peaks only
+// need the envelope, so a low rate keeps the decoded buffer small (~7 MB per hour).
+const waveformSampleRate = 1000
+
+// ExtractCoverArt writes the file's embedded album art (its attached picture stream) to
+// outputPath as a JPEG. It fails if the file has none - check MediaInfo.HasCoverArt first.
+func (r *Runner) ExtractCoverArt(ctx context.Context, inputPath, outputPath string) error {
+	return r.FFmpeg(ctx, "-y",
+		"-i", inputPath,
+		"-map", "0:v:0",
+		"-an",
+		"-frames:v", "1",
+		"-q:v", "2",
+		outputPath,
+	)
+}
+
+// RenderWaveformImage draws the audio's waveform into a width x height JPEG at
+// outputPath. Audio without album art uses it as its poster.
+func (r *Runner) RenderWaveformImage(ctx context.Context, inputPath, outputPath string, width, height int) error {
+	// showwavespic draws on a transparent canvas; overlay it on a dark background so the JPEG isn't black-on-black
+	filter := fmt.Sprintf("color=c=0x111827:s=%[1]dx%[2]d[bg];"+
+		"[0:a]aformat=channel_layouts=mono,showwavespic=s=%[1]dx%[2]d:colors=0x818cf8[wave];"+
+		"[bg][wave]overlay=format=auto,format=yuvj420p", width, height)
+	return r.FFmpeg(ctx, "-y",
+		"-i", inputPath,
+		"-filter_complex", filter,
+		"-frames:v", "1",
+		"-q:v", "3",
+		outputPath,
+	)
+}
+
+// WaveformPeaks decodes the audio to mono and returns the peak amplitude (0-1) of each
+// of `buckets` equal slices of the file, rounded to three decimals.
+func (r *Runner) WaveformPeaks(ctx context.Context, inputPath string, buckets int) ([]float64, error) {
+	if buckets <= 0 {
+		buckets = WaveformBuckets
+	}
+	pcm, err := r.FFmpegOutput(ctx,
+		"-v", "error",
+		"-i", inputPath,
+		"-vn",
+		"-ac", "1",
+		"-ar", fmt.Sprint(waveformSampleRate),
+		"-f", "s16le",
+		"-",
+	)
+	if err != nil {
+		return nil, err
+	}
+	samples := len(pcm) / 2
+	if samples == 0 {
+		return nil, fmt.Errorf("no audio samples decoded")
+	}
+	if samples < buckets {
+		buckets = samples
+	}
+
+	peaks := make([]float64, buckets)
+	for b := 0; b < buckets; b++ {
+		from := b * samples / buckets
+		to := (b + 1) * samples / buckets
+		var peak int
+		for i := from; i < to; i++ {
+			v := int(int16(binary.LittleEndian.Uint16(pcm[i*2:])))
+			if v < 0 {
+				v = -v
+			}
+			if v > peak {
+				peak = v
+			}
+		}
+		peaks[b] = math.Round(float64(peak)/32768*1000) / 1000
+	}
+	return peaks, nil
+}
@@ -0,0 +1,135 @@
+// WeWatch/backend/internal/mediatools/clips.go
+package mediatools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// A cut starting this close after a keyframe is stream-copied from that keyframe
+	keyframeTolerance = 0.1
+	// How far back to look for the keyframe preceding a cut
+	keyframeSearchWindow = 15.0
+)
+
+// KeyframeBefore returns the timestamp of the last video keyframe at or before seconds.
+// It returns -1 if no keyframe was found in the preceding keyframeSearchWindow seconds.
+func (r *Runner) KeyframeBefore(ctx context.Context, inputPath string, seconds float64) (float64, error) {
+	from := seconds - keyframeSearchWindow
+	if from < 0 {
+		from = 0
+	}
+	output, err := r.FFprobe(ctx, "-v", "error",
+		"-select_streams", "v:0",
+		"-skip_frame", "nokey",
+		"-read_intervals", fmt.Sprintf("%.3f%%%.3f", from, seconds+keyframeTolerance),
+		"-show_entries", "frame=pts_time",
+		"-of", "csv=p=0",
+		inputPath,
+	)
+	if err != nil {
+		return -1, err
+	}
+
+	best := -1.0
+	for _, line := range strings.Split(string(output), "\n") {
+		t, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(line, ",")), 64)
+		if err != nil {
+			continue
+		}
+		if t <= seconds+keyframeTolerance && t > best {
+			best = t
+		}
+	}
+	return best, nil
+}
+
+// ClipCut is a clip written by CutClip.
+type ClipCut struct {
+	Path         string
+	Start        float64 // where the clip really starts in the source, in seconds
+	StreamCopied bool
+}
+
+// CutClip writes [start, end) of inputPath into outDir as base+extension. When start
+// falls on a keyframe the streams are copied in the source container (fast, lossless);
+// otherwise, or if copying fails, the clip is re-encoded to H.264/AAC MP4 so it starts
+// exactly at start. A copy begins at whatever keyframe ffmpeg landed on, so its Start is
+// read back from the output.
+func (r *Runner) CutClip(ctx context.Context, inputPath, outDir, base string, start, end float64) (*ClipCut, error) {
+	if end <= start {
+		return nil, fmt.Errorf("clip end must be after its start")
+	}
+
+	if kf, err := r.KeyframeBefore(ctx, inputPath, start); err == nil && kf >= 0 && start-kf <= keyframeTolerance {
+		ext := strings.ToLower(filepath.Ext(inputPath))
+		out := filepath.Join(outDir, base+ext)
+		args := []string{"-y",
+			"-ss", strconv.FormatFloat(kf, 'f', 6, 64),
+			"-i", inputPath,
+			"-t", strconv.FormatFloat(end-kf, 'f', 6, 64),
+			"-map", "0:v:0", "-map", "0:a?", "-map_chapters", "-1",
+			"-c", "copy",
+			"-avoid_negative_ts", "make_zero",
+		}
+		if ext == ".mp4" || ext == ".mov" {
+			args = append(args, "-movflags", "+faststart")
+		}
+		if err := r.FFmpeg(ctx, append(args, out)...); err == nil {
+			return &ClipCut{Path: out, Start: r.copiedClipStart(ctx, out, kf, end), StreamCopied: true}, nil
+		} else if ctx.Err() != nil {
+			return nil, err
+		}
+		// Some containers/codecs refuse a copy cut - re-encode instead
+	}
+
+	out := filepath.Join(outDir, base+".mp4")
+	err := r.FFmpeg(ctx, "-y",
+		"-ss", strconv.FormatFloat(start, 'f', 3, 64),
+		"-i", inputPath,
+		"-t", strconv.FormatFloat(end-start, 'f', 3, 64),
+		"-map", "0:v:0", "-map", "0:a:0?", "-map_chapters", "-1",
+		"-c:v", "libx264", "-preset", "veryfast", "-crf", "20", "-pix_fmt", "yuv420p",
+		"-c:a", "aac", "-b:a", "160k",
+		"-movflags", "+faststart",
+		out,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cut clip: %w", err)
+	}
+	return &ClipCut{Path: out, Start: start}, nil
+}
+
+// copiedClipStart works out where a stream-copied clip starts in the source. The copy
+// still stops at end but starts at the keyframe the input seek found, which can be one
+// before kf; -avoid_negative_ts shifts that lead-in to zero, so the output's video
+// duration tells how far back it reaches. It falls back to kf if the output can't be probed.
+func (r *Runner) copiedClipStart(ctx context.Context, clipPath string, kf, end float64) float64 {
+	output, err := r.FFprobe(ctx, "-v", "error",
+		"-select_streams", "v:0",
+		"-show_entries", "stream=duration:format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		clipPath,
+	)
+	if err != nil {
+		return kf
+	}
+	for _, line := range strings.Fields(string(output)) {
+		duration, err := strconv.ParseFloat(line, 64)
+		if err != nil || duration <= 0 {
+			continue // "N/A" when the container doesn't store a stream duration
+		}
+		start := end - duration
+		if start < 0 {
+			start = 0
+		}
+		// Never later than the keyframe we asked for: that one is in the output
+		return math.Min(start, kf)
+	}
+	return kf
+}
@@ -0,0 +1,217 @@
+// WeWatch/backend/internal/mediatools/detect.go
+package mediatools
+
+import (
+	"context"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Skip-marker detection. Intros and end credits are not labelled in most files, so they are
+// inferred from three signals ffmpeg can measure cheaply on a downscaled stream:
+//   - blackdetect: fades to black usually bracket an opening title sequence and precede credits
+//   - silencedetect: the same boundaries tend to have a gap in the audio
+//   - scene changes: title sequences are montages (many cuts), credit rolls have almost none
+const (
+	detectMinDuration = 120.0 // shorter items get no markers
+	introWindowMax    = 600.0 // only the first 10 minutes can hold the intro...
+	creditsWindowMax  = 600.0 // ...and the last 10 the credits
+	introMinLength    = 15.0
+	introMaxLength    = 150.0
+	introMinCuts      = 4
+	creditsMinLength  = 20.0
+	creditsMaxCutRate = 0.1 // scene changes per second in a credit roll
+	sceneThreshold    = "0.35"
+	breakMergeGap     = 0.5 // black/silence intervals closer than this form one break
+)
+
+// Interval is a time range in seconds from the start of the file.
+type Interval struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// SegmentAnalysis is what AnalyzeSegment measured in one stretch of a file.
+// All times are absolute (offset already added).
+type SegmentAnalysis struct {
+	Start   float64
+	End     float64
+	Black   []Interval
+	Silence []Interval
+	Cuts    []float64 // scene-change timestamps
+}
+
+// SkipMarkers are proposed intro and credits positions; zero values mean "not found".
+type SkipMarkers struct {
+	IntroStart   float64
+	IntroEnd     float64
+	CreditsStart float64
+}
+
+var (
+	blackLinePattern    = regexp.MustCompile(`black_start:\s*([\d.]+)\s+black_end:\s*([\d.]+)`)
+	silenceStartPattern = regexp.MustCompile(`silence_start:\s*(-?[\d.]+)`)
+	silenceEndPattern   = regexp.MustCompile(`silence_end:\s*([\d.]+)`)
+	ptsTimePattern      = regexp.MustCompile(`pts_time:\s*([\d.]+)`)
+)
+
+// AnalyzeSegment runs blackdetect, scene detection and (if hasAudio) silencedetect over
+// length seconds of the file starting at from.
+func (r *Runner) AnalyzeSegment(ctx context.Context, inputPath string, from, length float64, hasAudio bool) (*SegmentAnalysis, error) {
+	args := []string{
+		"-ss", strconv.FormatFloat(from, 'f', 3, 64),
+		"-t", strconv.FormatFloat(length, 'f', 3, 64),
+		"-i", inputPath,
+		"-map", "0:v:0",
+		"-vf", "scale=320:-2,blackdetect=d=0.4:pix_th=0.10,select='gt(scene," + sceneThreshold + ")',metadata=print:key=lavfi.scene_score",
+	}
+	if hasAudio {
+		args = append(args, "-map", "0:a:0?", "-af", "silencedetect=n=-40dB:d=0.8")
+	}
+	args = append(args, "-f", "null", "-")
+
+	out, err := r.FFmpegLog(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseSegmentLog(out, from, from+length), nil
+}
+
+// parseSegmentLog extracts the detector output from ffmpeg's log. Times in the log are
+// relative to the seek position, so from is added back.
+func parseSegmentLog(out string, from, to float64) *SegmentAnalysis {
+	a := &SegmentAnalysis{Start: from, End: to}
+	silenceOpen := math.NaN()
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case strings.Contains(line, "blackdetect"):
+			if m := blackLinePattern.FindStringSubmatch(line); m != nil {
+				start, _ := strconv.ParseFloat(m[1], 64)
+				end, _ := strconv.ParseFloat(m[2], 64)
+				a.Black = append(a.Black, Interval{Start: from + start, End: from + end})
+			}
+		case strings.Contains(line, "silencedetect"):
+			if m := silenceStartPattern.FindStringSubmatch(line); m != nil {
+				start, _ := strconv.ParseFloat(m[1], 64)
+				silenceOpen = math.Max(start, 0)
+			} else if m := silenceEndPattern.FindStringSubmatch(line); m != nil && !math.IsNaN(silenceOpen) {
+				end, _ := strconv.ParseFloat(m[1], 64)
+				a.Silence = append(a.Silence, Interval{Start: from + silenceOpen, End: from + end})
+				silenceOpen = math.NaN()
+			}
+		case strings.Contains(line, "Parsed_metadata"):
+			if m := ptsTimePattern.FindStringSubmatch(line); m != nil {
+				t, _ := strconv.ParseFloat(m[1], 64)
+				a.Cuts = append(a.Cuts, from+t)
+			}
+		}
+	}
+	if !math.IsNaN(silenceOpen) {
+		// Silence running into the end of the segment
+		a.Silence = append(a.Silence, Interval{Start: from + silenceOpen, End: to})
+	}
+	return a
+}
+
+// DetectSkipMarkers analyses the opening and closing stretches of a video and proposes
+// where its intro sits and where the end credits begin.
+func (r *Runner) DetectSkipMarkers(ctx context.Context, inputPath string, durationSeconds float64, hasAudio bool) (*SkipMarkers, error) {
+	markers := &SkipMarkers{}
+	if durationSeconds < detectMinDuration {
+		return markers, nil
+	}
+
+	introWindow := math.Min(durationSeconds/3, introWindowMax)
+	opening, err := r.AnalyzeSegment(ctx, inputPath, 0, introWindow, hasAudio)
+	if err != nil {
+		return nil, err
+	}
+	markers.IntroStart, markers.IntroEnd = proposeIntro(opening)
+
+	creditsWindow := math.Min(durationSeconds/4, creditsWindowMax)
+	closing, err := r.AnalyzeSegment(ctx, inputPath, durationSeconds-creditsWindow, creditsWindow, hasAudio)
+	if err != nil {
+		return nil, err
+	}
+	markers.CreditsStart = proposeCredits(closing)
+	return markers, nil
+}
+
+// segmentBreaks merges black and silent intervals into sorted "breaks" - the points where
+// a sequence can start or end.
+func segmentBreaks(a *SegmentAnalysis) []Interval {
+	all := append(append([]Interval{}, a.Black...), a.Silence...)
+	sort.Slice(all, func(i, j int) bool { return all[i].Start < all[j].Start })
+
+	var merged []Interval
+	for _, iv := range all {
+		if n := len(merged); n > 0 && iv.Start <= merged[n-1].End+breakMergeGap {
+			merged[n-1].End = math.Max(merged[n-1].End, iv.End)
+			continue
+		}
+		merged = append(merged, iv)
+	}
+	return merged
+}
+
+// cutsBetween counts scene changes in (from, to).
+func cutsBetween(cuts []float64, from, to float64) int {
+	n := 0
+	for _, t := range cuts {
+		if t > from && t < to {
+			n++
+		}
+	}
+	return n
+}
+
+// proposeIntro picks the stretch between two breaks (or the start of the file and a break)
+// that looks most like a title montage: 15-150 seconds long with the densest scene cuts.
+// It returns zeros when nothing qualifies.
+func proposeIntro(a *SegmentAnalysis) (float64, float64) {
+	breaks := segmentBreaks(a)
+	starts := []float64{a.Start}
+	for _, b := range breaks {
+		starts = append(starts, b.End)
+	}
+
+	bestScore, bestStart, bestEnd := 0.0, 0.0, 0.0
+	for _, s := range starts {
+		for _, b := range breaks {
+			e := b.Start
+			length := e - s
+			if length < introMinLength || length > introMaxLength {
+				continue
+			}
+			cuts := cutsBetween(a.Cuts, s, e)
+			if cuts < introMinCuts {
+				continue
+			}
+			// Dense montages win; the square root keeps long stretches from winning on size alone
+			score := float64(cuts) / length * math.Sqrt(length)
+			if score > bestScore {
+				bestScore, bestStart, bestEnd = score, s, b.End
+			}
+		}
+	}
+	return math.Round(bestStart*10) / 10, math.Round(bestEnd*10) / 10
+}
+
+// proposeCredits returns the earliest break after which the rest of the segment (at least
+// 20 seconds) is nearly free of scene cuts - a credit roll - or 0 when there is none.
+func proposeCredits(a *SegmentAnalysis) float64 {
+	for _, b := range segmentBreaks(a) {
+		start := b.End
+		length := a.End - start
+		if length < creditsMinLength {
+			break
+		}
+		if float64(cutsBetween(a.Cuts, start, a.End))/length <= creditsMaxCutRate {
+			return math.Round(start*10) / 10
+		}
+	}
+	return 0
+}
@@ -0,0 +1,196 @@
+// WeWatch/backend/internal/mediatools/detect_test.go
+package mediatools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Lines as ffmpeg 6 logs them for the AnalyzeSegment filter graph
+const (
+	blackLine        = "[blackdetect @ 0x5581c3a4e2c0] black_start:%g black_end:%g black_duration:%g\n"
+	silenceStartLine = "[silencedetect @ 0x5581c3a51f00] silence_start: %g\n"
+	silenceEndLine   = "[silencedetect @ 0x5581c3a51f00] silence_end: %g | silence_duration: %g\n"
+	sceneLines       = "[Parsed_metadata_3 @ 0x5581c3a50a80] frame:%d    pts:%d  pts_time:%g\n" +
+		"[Parsed_metadata_3 @ 0x5581c3a50a80] lavfi.scene_score=0.482113\n"
+	progressLine = "frame= 1450 fps=412 q=-0.0 size=N/A time=00:01:00.41 bitrate=N/A speed=17.2x\n"
+)
+
+func black(start, end float64) string {
+	return fmt.Sprintf(blackLine, start, end, end-start)
+}
+
+func silence(start, end float64) string {
+	return fmt.Sprintf(silenceStartLine, start) + fmt.Sprintf(silenceEndLine, end, end-start)
+}
+
+// cuts logs a scene change every step seconds in [from, to].
+func cuts(from, to, step float64) string {
+	var b strings.Builder
+	for t := from; t <= to; t += step {
+		frame := int(t * 24)
+		fmt.Fprintf(&b, sceneLines, frame, frame*1001, t)
+	}
+	return b.String()
+}
+
+func TestParseSegmentLog(t *testing.T) {
+	log := "Input #0, matroska,webm, from 'in.mkv':\n" +
+		black(0, 1.5) +
+		fmt.Sprintf(silenceStartLine, -0.02) +
+		progressLine +
+		fmt.Sprintf(silenceEndLine, 1.25, 1.27) +
+		cuts(12.012, 12.012, 1) +
+		fmt.Sprintf(silenceStartLine, 58.4) // still silent when the segment ends
+
+	got := parseSegmentLog(log, 3000, 3060)
+	want := &SegmentAnalysis{
+		Start:   3000,
+		End:     3060,
+		Black:   []Interval{{Start: 3000, End: 3001.5}},
+		Silence: []Interval{{Start: 3000, End: 3001.25}, {Start: 3058.4, End: 3060}},
+		Cuts:    []float64{3012.012},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseSegmentLog =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestProposeIntro(t *testing.T) {
+	tests := []struct {
+		name      string
+		log       string
+		wantStart float64
+		wantEnd   float64
+	}{
+		{
+			name: "montage between a cold open fade and a black, silent break",
+			log: black(0, 1.5) + cuts(5, 45, 5) +
+				silence(59.8, 61.2) + black(60, 61) + cuts(120, 300, 90),
+			wantStart: 1.5, wantEnd: 61.2,
+		},
+		{
+			name:      "montage straight from the first frame",
+			log:       cuts(3, 27, 3) + black(30.02, 31.07) + cuts(200, 400, 100),
+			wantStart: 0, wantEnd: 31.1,
+		},
+		{
+			name: "densest of two candidate stretches wins",
+			log: cuts(10, 80, 10) + black(90, 91) +
+				cuts(95, 125, 2) + black(130, 131),
+			wantStart: 91, wantEnd: 131,
+		},
+		{
+			name: "too few cuts",
+			log:  black(0, 1) + cuts(10, 30, 10) + black(40, 41),
+		},
+		{
+			name: "break too soon after the start",
+			log:  cuts(2, 10, 2) + black(12, 13),
+		},
+		{
+			name: "montage longer than any intro",
+			log:  cuts(5, 195, 5) + black(200, 201),
+		},
+		{
+			name: "no breaks at all",
+			log:  cuts(5, 300, 5),
+		},
+		{
+			name: "empty log",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := proposeIntro(parseSegmentLog(tt.log, 0, 600))
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("proposeIntro = (%g, %g), want (%g, %g)", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestProposeCredits(t *testing.T) {
+	tests := []struct {
+		name string
+		log  string
+		want float64
+	}{
+		{
+			name: "roll after the last scene",
+			log:  cuts(5, 415, 5) + black(420, 421.5) + silence(420.3, 422),
+			want: 3422,
+		},
+		{
+			name: "earlier break followed by more scenes is skipped",
+			log:  cuts(5, 95, 5) + black(100, 101) + cuts(105, 415, 5) + black(420, 422),
+			want: 3422,
+		},
+		{
+			name: "a few cuts inside the roll are tolerated",
+			log:  cuts(5, 415, 5) + black(420, 422) + cuts(500, 560, 30),
+			want: 3422,
+		},
+		{
+			name: "scenes continue to the end",
+			log:  cuts(5, 415, 5) + black(420, 422) + cuts(425, 595, 5),
+		},
+		{
+			name: "break too close to the end",
+			log:  cuts(5, 585, 5) + black(588, 590),
+		},
+		{
+			name: "no breaks at all",
+			log:  cuts(5, 595, 5),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := proposeCredits(parseSegmentLog(tt.log, 3000, 3600)); got != tt.want {
+				t.Errorf("proposeCredits = %g, want %g", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectSkipMarkers(t *testing.T) {
+	dir := t.TempDir()
+	opening := black(0, 1.5) + cuts(5, 45, 5) + silence(59.8, 61.2) + black(60, 61) + cuts(120, 300, 90)
+	closing := cuts(5, 415, 5) + black(420, 421.5) + silence(420.3, 422)
+	for name, log := range map[string]string{"opening.log": opening, "closing.log": closing} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(log), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The opening is analysed from -ss 0, the closing stretch from further in
+	r := NewRunner(fakeTool(t, `case " $* " in
+*" -ss 0.000 "*) cat "`+dir+`/opening.log" >&2 ;;
+*) cat "`+dir+`/closing.log" >&2 ;;
+esac`), "", 1, time.Minute)
+
+	got, err := r.DetectSkipMarkers(context.Background(), "in.mkv", 3600, true)
+	if err != nil {
+		t.Fatalf("DetectSkipMarkers: %v", err)
+	}
+	if want := (SkipMarkers{IntroStart: 1.5, IntroEnd: 61.2, CreditsStart: 3422}); *got != want {
+		t.Errorf("DetectSkipMarkers = %+v, want %+v", *got, want)
+	}
+}
+
+func TestDetectSkipMarkersSkipsShortItems(t *testing.T) {
+	// A short item must not run ffmpeg at all
+	r := NewRunner(filepath.Join(t.TempDir(), "missing-ffmpeg"), "", 1, time.Minute)
+	got, err := r.DetectSkipMarkers(context.Background(), "in.mp4", detectMinDuration-1, true)
+	if err != nil {
+		t.Fatalf("DetectSkipMarkers: %v", err)
+	}
+	if *got != (SkipMarkers{}) {
+		t.Errorf("DetectSkipMarkers = %+v, want no markers", *got)
+	}
+}
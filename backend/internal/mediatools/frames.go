@@ -0,0 +1,163 @@
+// WeWatch/backend/internal/mediatools/frames.go
+package mediatools
+
+import (
+	"context"
+	"fmt"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Poster frame selection: candidates are taken at these fractions of the duration,
+// so clips shorter than a few seconds still get a real poster.
+var posterCandidateFractions = []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.05}
+
+const (
+	posterMinLuma     = 24.0 // mean luma (0-255) below this counts as a black frame
+	posterMinVariance = 12.0 // luma std deviation below this is a flat frame (fades, title cards)
+)
+
+// ExtractThumbnail writes a representative poster frame for the video to outputPath.
+// It probes the duration and lets SelectPosterFrame skip black/flat frames.
+func (r *Runner) ExtractThumbnail(ctx context.Context, inputPath, outputPath string) error {
+	info, err := r.ProbeMedia(ctx, inputPath)
+	if err != nil {
+		return err
+	}
+	_, err = r.SelectPosterFrame(ctx, inputPath, outputPath, info.DurationSeconds)
+	return err
+}
+
+// ExtractFrameAt writes the frame at the given second to outputPath as a JPEG.
+func (r *Runner) ExtractFrameAt(ctx context.Context, inputPath, outputPath string, seconds float64) error {
+	return r.FFmpeg(ctx, "-y",
+		"-ss", strconv.FormatFloat(seconds, 'f', 3, 64),
+		"-i", inputPath,
+		"-frames:v", "1",
+		"-q:v", "2",
+		outputPath,
+	)
+}
+
+// frameLuma returns the mean and standard deviation of the luma of a JPEG frame.
+func frameLuma(path string) (mean, stddev float64, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	img, err := jpeg.Decode(f)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Sampling every 4th pixel is plenty to tell a black/flat frame from a real one
+	bounds := img.Bounds()
+	var sum, sumSq, n float64
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += 4 {
+		for x := bounds.Min.X; x < bounds.Max.X; x += 4 {
+			l := float64(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+			sum += l
+			sumSq += l * l
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, 0, fmt.Errorf("empty frame")
+	}
+	mean = sum / n
+	return mean, math.Sqrt(math.Max(sumSq/n-mean*mean, 0)), nil
+}
+
+// SelectPosterFrame picks a poster for the video and writes it to outputPath.
+// Candidates are tried at percentages of the duration; the first that is neither black
+// nor low-variance wins, otherwise the most detailed candidate is used.
+// It returns the timestamp (seconds) of the chosen frame.
+func (r *Runner) SelectPosterFrame(ctx context.Context, inputPath, outputPath string, durationSeconds float64) (float64, error) {
+	if durationSeconds <= 0 {
+		if err := r.ExtractFrameAt(ctx, inputPath, outputPath, 0); err != nil {
+			return 0, err
+		}
+		return 0, nil
+	}
+
+	bestAt, bestStddev := -1.0, -1.0
+	candidate := outputPath + ".candidate.jpg"
+	defer os.Remove(candidate)
+
+	var lastErr error
+	for _, fraction := range posterCandidateFractions {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		at := durationSeconds * fraction
+		if err := r.ExtractFrameAt(ctx, inputPath, candidate, at); err != nil {
+			lastErr = err
+			continue
+		}
+		mean, stddev, err := frameLuma(candidate)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if mean >= posterMinLuma && stddev >= posterMinVariance {
+			return at, os.Rename(candidate, outputPath)
+		}
+		if stddev > bestStddev {
+			bestAt, bestStddev = at, stddev
+			if err := os.Rename(candidate, outputPath); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if bestAt < 0 {
+		return 0, fmt.Errorf("could not extract any poster frame: %w", lastErr)
+	}
+	return bestAt, nil
+}
+
+// PosterWidths are the widths rendered by RenderPosterVariants.
+var PosterWidths = []int{320, 640, 1280}
+
+// PosterFormats are the formats rendered by RenderPosterVariants (file extensions).
+var PosterFormats = []string{"jpg", "webp"}
+
+// PosterVariantName names one rendition, e.g. base "abc_poster" -> "abc_poster_640.webp".
+func PosterVariantName(base string, width int, format string) string {
+	return fmt.Sprintf("%s_%d.%s", base, width, format)
+}
+
+// RenderPosterVariants scales a poster image to every PosterWidths x PosterFormats rendition
+// inside outDir (never upscaling) and returns the local paths it wrote.
+// srcPath may be any image ffmpeg can read (JPEG, PNG, WebP).
+func (r *Runner) RenderPosterVariants(ctx context.Context, srcPath, outDir, base string) ([]string, error) {
+	var outputs []string
+	for _, width := range PosterWidths {
+		for _, format := range PosterFormats {
+			out := filepath.Join(outDir, PosterVariantName(base, width, format))
+			args := []string{"-y", "-i", srcPath,
+				"-vf", fmt.Sprintf("scale='min(%d,iw)':-2", width),
+				"-frames:v", "1",
+			}
+			if format == "webp" {
+				args = append(args, "-c:v", "libwebp", "-quality", "80")
+			} else {
+				args = append(args, "-q:v", "3")
+			}
+			if err := r.FFmpeg(ctx, append(args, out)...); err != nil {
+				for _, o := range outputs {
+					os.Remove(o)
+				}
+				return nil, fmt.Errorf("failed to render %s poster at %dpx: %w", format, width, err)
+			}
+			outputs = append(outputs, out)
+		}
+	}
+	return outputs, nil
+}
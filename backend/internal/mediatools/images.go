@@ -0,0 +1,39 @@
+// WeWatch/backend/internal/mediatools/images.go
+package mediatools
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotImage is returned by ProbeImage when the file is not a single JPEG, PNG or WebP image.
+var ErrNotImage = errors.New("file is not a supported image")
+
+// imageCodecs are the still-image codecs accepted for slideshow slides.
+var imageCodecs = map[string]bool{"mjpeg": true, "png": true, "webp": true}
+
+// ProbeImage checks that the file is a still image and returns its dimensions.
+func (r *Runner) ProbeImage(ctx context.Context, filePath string) (width, height int, err error) {
+	probe, err := r.Probe(ctx, filePath)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(probe.Streams) != 1 {
+		return 0, 0, ErrNotImage
+	}
+	s := probe.Streams[0]
+	if s.CodecType != "video" || !imageCodecs[s.CodecName] || s.Width <= 0 || s.Height <= 0 {
+		return 0, 0, ErrNotImage
+	}
+	return s.Width, s.Height, nil
+}
+
+// ConvertToJPEG re-encodes an image as a JPEG (posters are always JPEG).
+func (r *Runner) ConvertToJPEG(ctx context.Context, inputPath, outputPath string) error {
+	return r.FFmpeg(ctx, "-y",
+		"-i", inputPath,
+		"-frames:v", "1",
+		"-q:v", "2",
+		outputPath,
+	)
+}
@@ -0,0 +1,217 @@
+// WeWatch/backend/internal/mediatools/probe.go
+package mediatools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotVideo is returned by ProbeMedia when the file parses but contains no video stream.
+var ErrNotVideo = errors.New("file does not contain a video stream")
+
+// ErrNotAudio is returned by ProbeAudio when the file parses but contains no audio stream.
+var ErrNotAudio = errors.New("file does not contain an audio stream")
+
+// MediaInfo holds the structured metadata we keep for an uploaded media file.
+type MediaInfo struct {
+	DurationSeconds float64
+	Width           int
+	Height          int
+	FrameRate       float64
+	VideoCodec      string
+	AudioCodec      string
+	Bitrate         int64
+	AudioLanguages  []string
+	Container       string
+	Chapters        []Chapter // embedded chapter markers, in order
+	HasCoverArt     bool      // an attached picture (album art) is embedded
+}
+
+// Chapter is a chapter marker embedded in a media file.
+type Chapter struct {
+	Title string
+	Start float64 // seconds
+	End   float64 // seconds
+}
+
+// ProbeStream is one entry of ffprobe's "streams" array.
+type ProbeStream struct {
+	Index        int               `json:"index"`
+	CodecType    string            `json:"codec_type"`
+	CodecName    string            `json:"codec_name"`
+	Width        int               `json:"width"`
+	Height       int               `json:"height"`
+	AvgFrameRate string            `json:"avg_frame_rate"`
+	RFrameRate   string            `json:"r_frame_rate"`
+	Duration     string            `json:"duration"`
+	Tags         map[string]string `json:"tags"`
+	Disposition  map[string]int    `json:"disposition"`
+}
+
+// ProbeFormat is ffprobe's "format" object.
+type ProbeFormat struct {
+	FormatName string            `json:"format_name"`
+	Duration   string            `json:"duration"`
+	BitRate    string            `json:"bit_rate"`
+	Size       string            `json:"size"`
+	Tags       map[string]string `json:"tags"`
+}
+
+// ProbeChapter is one entry of ffprobe's "chapters" array.
+type ProbeChapter struct {
+	ID        int64             `json:"id"`
+	StartTime string            `json:"start_time"`
+	EndTime   string            `json:"end_time"`
+	Tags      map[string]string `json:"tags"`
+}
+
+// ProbeResult is the parsed output of `ffprobe -show_streams -show_format -show_chapters -of json`.
+type ProbeResult struct {
+	Streams  []ProbeStream  `json:"streams"`
+	Format   ProbeFormat    `json:"format"`
+	Chapters []ProbeChapter `json:"chapters"`
+}
+
+// Probe runs ffprobe against the file and returns its parsed stream, format and chapter data.
+func (r *Runner) Probe(ctx context.Context, filePath string) (*ProbeResult, error) {
+	output, err := r.FFprobe(ctx, "-v", "error", "-show_streams", "-show_format", "-show_chapters", "-of", "json", filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var probe ProbeResult
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+	return &probe, nil
+}
+
+// ProbeMedia probes the file and returns its metadata.
+// It fails if ffprobe can't parse the file (corrupt / not media) or if there is no video stream.
+func (r *Runner) ProbeMedia(ctx context.Context, filePath string) (*MediaInfo, error) {
+	probe, err := r.Probe(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	info, hasVideo := mediaInfoFromProbe(probe)
+	if !hasVideo {
+		return nil, ErrNotVideo
+	}
+	return info, nil
+}
+
+// ProbeAudio is ProbeMedia for audio-only files (mp3, flac, ogg, m4a): it requires an
+// audio stream and ignores any video. Embedded album art only sets HasCoverArt.
+func (r *Runner) ProbeAudio(ctx context.Context, filePath string) (*MediaInfo, error) {
+	probe, err := r.Probe(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	info, _ := mediaInfoFromProbe(probe)
+	if info.AudioCodec == "" {
+		return nil, ErrNotAudio
+	}
+	info.VideoCodec = ""
+	info.Width, info.Height, info.FrameRate = 0, 0, 0
+	return info, nil
+}
+
+// mediaInfoFromProbe extracts MediaInfo from ffprobe output and reports whether the
+// file has a real video stream (cover art doesn't count).
+func mediaInfoFromProbe(probe *ProbeResult) (*MediaInfo, bool) {
+	info := &MediaInfo{
+		Container:      probe.Format.FormatName,
+		AudioLanguages: []string{},
+	}
+	info.DurationSeconds, _ = strconv.ParseFloat(probe.Format.Duration, 64)
+	info.Bitrate, _ = strconv.ParseInt(probe.Format.BitRate, 10, 64)
+
+	hasVideo := false
+	for _, s := range probe.Streams {
+		switch s.CodecType {
+		case "video":
+			// Cover art in mp4/mkv/mp3 shows up as a video stream too - skip it
+			if s.Disposition["attached_pic"] == 1 {
+				info.HasCoverArt = true
+				continue
+			}
+			if hasVideo {
+				continue
+			}
+			hasVideo = true
+			info.VideoCodec = s.CodecName
+			info.Width = s.Width
+			info.Height = s.Height
+			info.FrameRate = parseFrameRate(s.AvgFrameRate)
+			if info.FrameRate == 0 {
+				info.FrameRate = parseFrameRate(s.RFrameRate)
+			}
+		case "audio":
+			if info.AudioCodec == "" {
+				info.AudioCodec = s.CodecName
+			}
+			lang := s.Tags["language"]
+			if lang == "" {
+				lang = "und"
+			}
+			info.AudioLanguages = append(info.AudioLanguages, lang)
+		}
+	}
+
+	for i, ch := range probe.Chapters {
+		start, err1 := strconv.ParseFloat(ch.StartTime, 64)
+		end, err2 := strconv.ParseFloat(ch.EndTime, 64)
+		if err1 != nil || err2 != nil || end <= start {
+			continue
+		}
+		title := strings.TrimSpace(ch.Tags["title"])
+		if title == "" {
+			title = fmt.Sprintf("Chapter %d", i+1)
+		}
+		info.Chapters = append(info.Chapters, Chapter{Title: title, Start: start, End: end})
+	}
+	return info, hasVideo
+}
+
+// GetVideoDuration returns the file's duration in HH:MM:SS format.
+func (r *Runner) GetVideoDuration(ctx context.Context, filePath string) (string, error) {
+	probe, err := r.Probe(ctx, filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get video duration: %w", err)
+	}
+	seconds, err := strconv.ParseFloat(probe.Format.Duration, 64)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse duration: %w", err)
+	}
+	return FormatDuration(seconds), nil
+}
+
+// parseFrameRate turns ffprobe's "30000/1001" style rates into a float.
+func parseFrameRate(rate string) float64 {
+	num, den, found := strings.Cut(rate, "/")
+	if !found {
+		f, _ := strconv.ParseFloat(rate, 64)
+		return f
+	}
+	n, err1 := strconv.ParseFloat(num, 64)
+	d, err2 := strconv.ParseFloat(den, 64)
+	if err1 != nil || err2 != nil || d == 0 {
+		return 0
+	}
+	return n / d
+}
+
+// FormatDuration converts seconds to the HH:MM:SS string stored on media items.
+func FormatDuration(seconds float64) string {
+	duration := time.Duration(seconds * float64(time.Second))
+	hours := int(duration.Hours())
+	minutes := int(duration.Minutes()) % 60
+	secs := int(duration.Seconds()) % 60
+
+	return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, secs)
+}
@@ -0,0 +1,222 @@
+// WeWatch/backend/internal/mediatools/runner.go
+
+// Package mediatools wraps ffmpeg and ffprobe: probing, posters, storyboards and
+// remuxing. Every invocation goes through a Runner, which bounds concurrency, applies
+// a timeout, honours context cancellation and keeps stderr for error messages.
+package mediatools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrTimeout is returned (wrapped in *ExecError) when a command outlives the runner's timeout.
+var ErrTimeout = errors.New("mediatools: command timed out")
+
+const (
+	defaultTimeout   = 30 * time.Minute
+	stderrTailLength = 4096    // bytes of stderr kept for error messages
+	maxLogLength     = 8 << 20 // bytes of stderr FFmpegLog returns
+)
+
+// Runner executes ffmpeg/ffprobe. Binary paths are injectable so tests can point them
+// at fake scripts.
+type Runner struct {
+	FFmpegPath  string
+	FFprobePath string
+	Timeout     time.Duration // per command; 0 means no timeout
+
+	sem chan struct{}
+}
+
+// NewRunner creates a runner allowing at most maxConcurrent commands at once.
+func NewRunner(ffmpegPath, ffprobePath string, maxConcurrent int, timeout time.Duration) *Runner {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	return &Runner{
+		FFmpegPath:  ffmpegPath,
+		FFprobePath: ffprobePath,
+		Timeout:     timeout,
+		sem:         make(chan struct{}, maxConcurrent),
+	}
+}
+
+// NewRunnerFromEnv reads FFMPEG_PATH, FFPROBE_PATH, MEDIA_TOOLS_CONCURRENCY (default:
+// number of CPUs) and MEDIA_TOOLS_TIMEOUT (Go duration, default 30m).
+func NewRunnerFromEnv() *Runner {
+	ffmpeg := os.Getenv("FFMPEG_PATH")
+	if ffmpeg == "" {
+		ffmpeg = "ffmpeg"
+	}
+	ffprobe := os.Getenv("FFPROBE_PATH")
+	if ffprobe == "" {
+		ffprobe = "ffprobe"
+	}
+
+	concurrency := runtime.NumCPU()
+	if v := os.Getenv("MEDIA_TOOLS_CONCURRENCY"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			concurrency = n
+		} else {
+			log.Printf("⚠️ Invalid MEDIA_TOOLS_CONCURRENCY=%q, using %d", v, concurrency)
+		}
+	}
+
+	timeout := defaultTimeout
+	if v := os.Getenv("MEDIA_TOOLS_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			timeout = d
+		} else {
+			log.Printf("⚠️ Invalid MEDIA_TOOLS_TIMEOUT=%q, using %s", v, timeout)
+		}
+	}
+
+	return NewRunner(ffmpeg, ffprobe, concurrency, timeout)
+}
+
+var (
+	defaultRunner     *Runner
+	defaultRunnerOnce sync.Once
+	defaultRunnerMu   sync.RWMutex
+)
+
+// Default returns the process-wide runner, built from the environment on first use
+// (after main has loaded .env).
+func Default() *Runner {
+	defaultRunnerOnce.Do(func() {
+		defaultRunnerMu.Lock()
+		if defaultRunner == nil {
+			defaultRunner = NewRunnerFromEnv()
+		}
+		defaultRunnerMu.Unlock()
+	})
+	defaultRunnerMu.RLock()
+	defer defaultRunnerMu.RUnlock()
+	return defaultRunner
+}
+
+// SetDefault replaces the process-wide runner (e.g. with one using fake binaries).
+func SetDefault(r *Runner) {
+	defaultRunnerOnce.Do(func() {})
+	defaultRunnerMu.Lock()
+	defaultRunner = r
+	defaultRunnerMu.Unlock()
+}
+
+// ExecError reports a failed ffmpeg/ffprobe invocation along with the tail of its stderr.
+type ExecError struct {
+	Tool   string
+	Args   []string
+	Err    error
+	Stderr string
+}
+
+func (e *ExecError) Error() string {
+	msg := fmt.Sprintf("%s failed: %v", e.Tool, e.Err)
+	if e.Stderr != "" {
+		msg += ": " + e.Stderr
+	}
+	return msg
+}
+
+func (e *ExecError) Unwrap() error { return e.Err }
+
+// tailBuffer keeps only the last n bytes written to it.
+type tailBuffer struct {
+	n   int
+	buf []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > t.n {
+		t.buf = t.buf[len(t.buf)-t.n:]
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	return strings.TrimSpace(string(t.buf))
+}
+
+// run executes a binary once a concurrency slot is free and returns its stdout.
+func (r *Runner) run(ctx context.Context, bin string, args ...string) ([]byte, error) {
+	return r.runLogged(ctx, bin, nil, args...)
+}
+
+// runLogged is run with the full stderr also copied to log (analysis filters report there).
+func (r *Runner) runLogged(ctx context.Context, bin string, logOut io.Writer, args ...string) ([]byte, error) {
+	select {
+	case r.sem <- struct{}{}:
+		defer func() { <-r.sem }()
+	case <-ctx.Done():
+		return nil, &ExecError{Tool: bin, Args: args, Err: ctx.Err()}
+	}
+
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+
+	var stdout bytes.Buffer
+	stderr := &tailBuffer{n: stderrTailLength}
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = stderr
+	if logOut != nil {
+		cmd.Stderr = io.MultiWriter(stderr, logOut)
+	}
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = ErrTimeout
+		} else if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return nil, &ExecError{Tool: bin, Args: args, Err: err, Stderr: stderr.String()}
+	}
+	return stdout.Bytes(), nil
+}
+
+// FFmpeg runs ffmpeg with the given arguments ("-hide_banner -nostdin" are added).
+func (r *Runner) FFmpeg(ctx context.Context, args ...string) error {
+	_, err := r.run(ctx, r.FFmpegPath, append([]string{"-hide_banner", "-nostdin"}, args...)...)
+	return err
+}
+
+// FFmpegOutput runs ffmpeg and returns its stdout (for pipes like "-f null -").
+func (r *Runner) FFmpegOutput(ctx context.Context, args ...string) ([]byte, error) {
+	return r.run(ctx, r.FFmpegPath, append([]string{"-hide_banner", "-nostdin"}, args...)...)
+}
+
+// FFmpegLog runs ffmpeg and returns what it logged to stderr (at most the last
+// maxLogLength bytes), for filters like blackdetect that only report through the log.
+func (r *Runner) FFmpegLog(ctx context.Context, args ...string) (string, error) {
+	logBuf := &tailBuffer{n: maxLogLength}
+	_, err := r.runLogged(ctx, r.FFmpegPath, logBuf, append([]string{"-hide_banner", "-nostdin", "-nostats"}, args...)...)
+	return string(logBuf.buf), err
+}
+
+// FFprobe runs ffprobe and returns its stdout.
+func (r *Runner) FFprobe(ctx context.Context, args ...string) ([]byte, error) {
+	return r.run(ctx, r.FFprobePath, args...)
+}
+
+// Faststart remuxes an MP4 so the moov atom comes first (playback can start before
+// the whole file downloads). Streams are copied, not re-encoded.
+func (r *Runner) Faststart(ctx context.Context, inputPath, outputPath string) error {
+	return r.FFmpeg(ctx, "-y", "-i", inputPath, "-c", "copy", "-movflags", "+faststart", outputPath)
+}
@@ -0,0 +1,186 @@
+// WeWatch/backend/internal/mediatools/runner_test.go
+package mediatools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeTool writes an executable shell script standing in for ffmpeg/ffprobe.
+func fakeTool(t *testing.T, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake tools are shell scripts")
+	}
+	p := filepath.Join(t.TempDir(), "fake-ffmpeg")
+	if err := os.WriteFile(p, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestRunnerPassesArgsAndReturnsStdout(t *testing.T) {
+	r := NewRunner(fakeTool(t, `echo "$@"`), "", 1, time.Minute)
+	out, err := r.FFmpegOutput(context.Background(), "-i", "in.mp4", "-f", "null", "-")
+	if err != nil {
+		t.Fatalf("FFmpegOutput: %v", err)
+	}
+	if got, want := strings.TrimSpace(string(out)), "-hide_banner -nostdin -i in.mp4 -f null -"; got != want {
+		t.Errorf("stdout = %q, want %q", got, want)
+	}
+}
+
+func TestRunnerKeepsStderrTail(t *testing.T) {
+	// 1000 numbered lines, then the real error, then a failing exit status
+	r := NewRunner(fakeTool(t, `i=0
+while [ $i -lt 1000 ]; do echo "noise line $i" >&2; i=$((i+1)); done
+echo "in.mp4: Invalid data found when processing input" >&2
+exit 1`), "", 1, time.Minute)
+
+	err := r.FFmpeg(context.Background(), "-i", "in.mp4")
+	var execErr *ExecError
+	if !errors.As(err, &execErr) {
+		t.Fatalf("FFmpeg err = %v, want *ExecError", err)
+	}
+	if !strings.HasSuffix(execErr.Stderr, "Invalid data found when processing input") {
+		t.Errorf("Stderr does not end with the error line: %q", execErr.Stderr)
+	}
+	if len(execErr.Stderr) > stderrTailLength {
+		t.Errorf("Stderr kept %d bytes, want at most %d", len(execErr.Stderr), stderrTailLength)
+	}
+	if strings.Contains(execErr.Stderr, "noise line 0\n") {
+		t.Error("Stderr kept the start of the output instead of its tail")
+	}
+	if !strings.Contains(err.Error(), "Invalid data found") {
+		t.Errorf("Error() = %q, want it to include stderr", err.Error())
+	}
+}
+
+func TestRunnerFFmpegLogReturnsFullStderr(t *testing.T) {
+	r := NewRunner(fakeTool(t, `echo "black_start:1 black_end:2" >&2`), "", 1, time.Minute)
+	out, err := r.FFmpegLog(context.Background(), "-i", "in.mp4")
+	if err != nil {
+		t.Fatalf("FFmpegLog: %v", err)
+	}
+	if !strings.Contains(out, "black_start:1 black_end:2") {
+		t.Errorf("FFmpegLog = %q, want the logged line", out)
+	}
+}
+
+func TestRunnerTimeout(t *testing.T) {
+	// exec, so the kill reaches the sleeping process and not just the shell
+	r := NewRunner(fakeTool(t, `exec sleep 10`), "", 1, 200*time.Millisecond)
+
+	start := time.Now()
+	err := r.FFmpeg(context.Background())
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("FFmpeg err = %v, want ErrTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("timed out command returned after %s", elapsed)
+	}
+}
+
+func TestRunnerCancelledContext(t *testing.T) {
+	r := NewRunner(fakeTool(t, `exec sleep 10`), "", 1, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	err := r.FFmpeg(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("FFmpeg err = %v, want context.Canceled", err)
+	}
+}
+
+func TestRunnerLimitsConcurrency(t *testing.T) {
+	const limit, calls = 2, 6
+	dir := t.TempDir()
+	// Each run leaves a marker while it works and records how many markers it saw
+	r := NewRunner(fakeTool(t, `dir="$1"
+touch "$dir/running.$$"
+ls "$dir" | grep -c '^running\.' >> "$dir/seen"
+sleep 0.3
+rm "$dir/running.$$"`), "", limit, time.Minute)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, calls)
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// run directly: FFmpeg would put its flags before the directory
+			if _, err := r.run(context.Background(), r.FFmpegPath, dir); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("run: %v", err)
+	}
+
+	seen, err := os.ReadFile(filepath.Join(dir, "seen"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Fields(string(seen))
+	if len(lines) != calls {
+		t.Fatalf("recorded %d runs, want %d", len(lines), calls)
+	}
+	for _, l := range lines {
+		if n, _ := strconv.Atoi(l); n > limit {
+			t.Errorf("%d commands ran at once, want at most %d", n, limit)
+		}
+	}
+}
+
+func TestRunnerWaitingForSlotHonoursContext(t *testing.T) {
+	r := NewRunner(fakeTool(t, `exec sleep 10`), "", 1, 0)
+
+	busy, stop := context.WithCancel(context.Background())
+	defer stop()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.FFmpeg(busy)
+	}()
+	// Wait until the first command holds the only slot
+	for deadline := time.Now().Add(5 * time.Second); len(r.sem) == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("first command never started")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := r.FFmpeg(ctx)
+	var execErr *ExecError
+	if !errors.As(err, &execErr) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("FFmpeg while the slot is taken = %v, want an *ExecError for the context deadline", err)
+	}
+	if execErr.Stderr != "" {
+		t.Errorf("command should not have run, got stderr %q", execErr.Stderr)
+	}
+
+	stop()
+	<-done
+}
+
+func TestTailBuffer(t *testing.T) {
+	b := &tailBuffer{n: 5}
+	b.Write([]byte("abc"))
+	b.Write([]byte("defg"))
+	if got := b.String(); got != "cdefg" {
+		t.Errorf("tailBuffer = %q, want %q", got, "cdefg")
+	}
+}
@@ -0,0 +1,116 @@
+// WeWatch/backend/internal/mediatools/storyboard.go
+package mediatools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Storyboard describes the sprite sheets produced by GenerateStoryboard.
+// Frame i (0-based) covers [i*Interval, (i+1)*Interval) and sits on sheet i/(Columns*Rows).
+type Storyboard struct {
+	Interval   float64  // seconds between sampled frames
+	TileWidth  int      // pixels
+	TileHeight int      // pixels
+	Columns    int      // tiles per row
+	Rows       int      // tile rows per sheet
+	Frames     int      // total sampled frames
+	Sheets     []string // local paths of the sprite sheets, in order
+}
+
+const (
+	storyboardTileWidth = 160
+	storyboardColumns   = 10
+	storyboardRows      = 10
+	storyboardMaxFrames = 400 // keeps long films to a handful of sheets
+)
+
+// StoryboardInterval picks the sampling interval for a video: every 5 seconds,
+// stretched for long videos so they never exceed storyboardMaxFrames thumbnails.
+func StoryboardInterval(durationSeconds float64) float64 {
+	interval := 5.0
+	if durationSeconds/interval > storyboardMaxFrames {
+		interval = math.Ceil(durationSeconds / storyboardMaxFrames)
+	}
+	return interval
+}
+
+// GenerateStoryboard samples frames from inputPath at a regular interval and tiles them into
+// JPEG sprite sheets (sheet_001.jpg, sheet_002.jpg, ...) inside outDir.
+// width/height are the source dimensions, used to keep the tiles' aspect ratio.
+func (r *Runner) GenerateStoryboard(ctx context.Context, inputPath, outDir string, durationSeconds float64, width, height int) (*Storyboard, error) {
+	if durationSeconds <= 0 {
+		return nil, fmt.Errorf("cannot build a storyboard for a video without a duration")
+	}
+
+	sb := &Storyboard{
+		Interval:   StoryboardInterval(durationSeconds),
+		TileWidth:  storyboardTileWidth,
+		TileHeight: storyboardTileWidth * 9 / 16,
+		Columns:    storyboardColumns,
+		Rows:       storyboardRows,
+	}
+	if width > 0 && height > 0 {
+		sb.TileHeight = int(math.Round(float64(storyboardTileWidth)*float64(height)/float64(width)/2)) * 2
+	}
+	sb.Frames = int(math.Ceil(durationSeconds / sb.Interval))
+
+	filter := fmt.Sprintf("fps=1/%g,scale=%d:%d,tile=%dx%d", sb.Interval, sb.TileWidth, sb.TileHeight, sb.Columns, sb.Rows)
+	if err := r.FFmpeg(ctx, "-y",
+		"-i", inputPath,
+		"-vf", filter,
+		"-q:v", "5",
+		filepath.Join(outDir, "sheet_%03d.jpg"),
+	); err != nil {
+		return nil, fmt.Errorf("storyboard: %w", err)
+	}
+
+	perSheet := sb.Columns * sb.Rows
+	for i := 1; i <= (sb.Frames+perSheet-1)/perSheet; i++ {
+		sheet := filepath.Join(outDir, fmt.Sprintf("sheet_%03d.jpg", i))
+		if _, err := os.Stat(sheet); err != nil {
+			// ffmpeg may sample one frame fewer than the duration suggests
+			break
+		}
+		sb.Sheets = append(sb.Sheets, sheet)
+	}
+	if len(sb.Sheets) == 0 {
+		return nil, fmt.Errorf("ffmpeg produced no storyboard sheets")
+	}
+	if capacity := len(sb.Sheets) * perSheet; sb.Frames > capacity {
+		sb.Frames = capacity
+	}
+	return sb, nil
+}
+
+// WebVTT renders the storyboard as a WebVTT thumbnails track. Each cue points at a tile
+// using a media fragment, e.g. "sheet_001.jpg#xywh=160,0,160,90"; sheetName(i) names sheet i (0-based).
+func (sb *Storyboard) WebVTT(durationSeconds float64, sheetName func(i int) string) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n")
+
+	perSheet := sb.Columns * sb.Rows
+	for i := 0; i < sb.Frames; i++ {
+		start := float64(i) * sb.Interval
+		end := math.Min(start+sb.Interval, durationSeconds)
+		if end <= start {
+			break
+		}
+		pos := i % perSheet
+		x := (pos % sb.Columns) * sb.TileWidth
+		y := (pos / sb.Columns) * sb.TileHeight
+		fmt.Fprintf(&b, "\n%s --> %s\n%s#xywh=%d,%d,%d,%d\n",
+			VTTTimestamp(start), VTTTimestamp(end), sheetName(i/perSheet), x, y, sb.TileWidth, sb.TileHeight)
+	}
+	return b.String()
+}
+
+// VTTTimestamp formats seconds as a WebVTT timestamp (HH:MM:SS.mmm).
+func VTTTimestamp(seconds float64) string {
+	ms := int64(math.Round(seconds * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
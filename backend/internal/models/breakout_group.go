@@ -0,0 +1,36 @@
+// WeWatch/backend/internal/models/breakout_group.go
+package models
+
+import "time"
+
+// BreakoutGroup is a small discussion group split off a watch session, with its own chat
+// and LiveKit room. Groups stay open until the host recalls everyone (ClosedAt).
+type BreakoutGroup struct {
+	ID          uint       `gorm:"primarykey" json:"id"`
+	SessionID   string     `gorm:"type:varchar(36);not null;index" json:"session_id"`
+	RoomID      uint       `gorm:"not null;index" json:"room_id"`
+	Number      int        `gorm:"not null" json:"number"` // 1..N within one split
+	Name        string     `gorm:"type:varchar(100)" json:"name"`
+	LiveKitRoom string     `gorm:"column:livekit_room;type:varchar(100);not null" json:"livekit_room"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ClosedAt    *time.Time `json:"closed_at,omitempty"`
+}
+
+// TableName overrides the table name used by GORM.
+func (BreakoutGroup) TableName() string {
+	return "breakout_groups"
+}
+
+// BreakoutGroupMember assigns a session member to a breakout group.
+type BreakoutGroupMember struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	GroupID    uint      `gorm:"not null;uniqueIndex:idx_breakout_group_user" json:"group_id"`
+	SessionID  string    `gorm:"type:varchar(36);not null;index" json:"session_id"`
+	UserID     uint      `gorm:"not null;uniqueIndex:idx_breakout_group_user;index" json:"user_id"`
+	AssignedAt time.Time `gorm:"not null" json:"assigned_at"`
+}
+
+// TableName overrides the table name used by GORM.
+func (BreakoutGroupMember) TableName() string {
+	return "breakout_group_members"
+}
@@ -10,4 +10,5 @@ type ChatMessage struct {
 	Username      string `gorm:"not null"`
 	Message       string `gorm:"type:text;not null"`
 	DeletedByHost bool   `gorm:"default:false"` // ← NEW: track if deleted by host
+	BreakoutGroupID *uint `gorm:"index"` // set for messages in a breakout group's chat
 }
\ No newline at end of file
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// MediaBlob is one stored media file, addressed by the SHA-256 of the uploaded bytes.
+// MediaItem and TemporaryMediaItem rows point at it through BlobHash; RefCount tracks
+// how many rows do, and the file is only deleted when it drops to zero.
+type MediaBlob struct {
+	ID                uint     `gorm:"primarykey" json:"id"`
+	Hash              string   `gorm:"type:varchar(64);not null;uniqueIndex" json:"hash"` // hex SHA-256 of the upload
+	StorageKey        string   `gorm:"type:text;not null" json:"-"`                       // "blobs/<hash><ext>"
+	Size              int64    `gorm:"type:bigint;not null;default:0" json:"size"`
+	MimeType          string   `gorm:"type:varchar(100);not null" json:"mime_type"`
+	RefCount          int      `gorm:"type:int;not null;default:0" json:"ref_count"`
+	PosterURL         string   `gorm:"type:text;not null;default:''" json:"poster_url"` // Poster generated at first upload
+	PosterVariantKeys []string `gorm:"type:text;serializer:json" json:"-"`
+	Duration          string   `gorm:"type:varchar(20);not null;default:''" json:"duration"` // HH:MM:SS
+	MediaMetadata     `gorm:"embedded"`
+	Chapters          []ChapterMark `gorm:"type:text;serializer:json" json:"-"` // Embedded chapter markers found at first upload
+	Waveform          []float64     `gorm:"type:text;serializer:json" json:"-"` // Audio only: peak amplitudes (0-1) across the file
+	CreatedAt         time.Time     `json:"created_at"`
+	UpdatedAt         time.Time     `json:"updated_at"`
+}
+
+// TableName overrides the table name used by GORM.
+func (MediaBlob) TableName() string {
+	return "media_blobs"
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Chapter kinds and sources.
+const (
+	ChapterKindChapter  = "chapter"  // a named section, exported in the WebVTT chapters track
+	ChapterKindBookmark = "bookmark" // a single point of interest
+
+	ChapterSourceManual   = "manual"   // added by the host
+	ChapterSourceEmbedded = "embedded" // imported from the file's own chapter markers
+)
+
+// MediaChapter is a chapter or bookmark on a room's MediaItem.
+// EndSeconds is 0 when a chapter simply runs until the next one (or the end of the video).
+type MediaChapter struct {
+	ID           uint      `gorm:"primarykey" json:"id"`
+	MediaItemID  uint      `gorm:"not null;index" json:"media_item_id"`
+	Kind         string    `gorm:"type:varchar(20);not null;default:'chapter'" json:"kind"`
+	Title        string    `gorm:"type:varchar(255);not null" json:"title"`
+	StartSeconds float64   `gorm:"type:decimal(12,3);not null;default:0" json:"start_seconds"`
+	EndSeconds   float64   `gorm:"type:decimal(12,3);not null;default:0" json:"end_seconds,omitempty"`
+	Source       string    `gorm:"type:varchar(20);not null;default:'manual'" json:"source"`
+	CreatedByID  *uint     `json:"created_by_id,omitempty"` // nil for imported chapters
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TableName overrides the table name used by GORM.
+func (MediaChapter) TableName() string {
+	return "media_chapters"
+}
+
+// ChapterMark is a chapter embedded in a stored file, kept on its MediaBlob so every
+// item created from the blob can import it without probing again.
+type ChapterMark struct {
+	Title string  `json:"title"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
@@ -16,10 +16,11 @@ type MediaItem struct {
 	MimeType string `gorm:"type:varchar(100);not null" json:"mime_type"` 
 	// Size of the file in bytes.
 	FileSize int64 `gorm:"type:bigint;not null;default:0" json:"file_size"`
-	// Path where the file is stored on the server (relative or absolute).
-    // MVP: Relative path like "./uploads/room_123_video.mp4"
-    // Production: URL to cloud storage (S3, GCS)
-    FilePath string `gorm:"type:text;not null" json:"file_path"`
+	// Storage key of the file in the media store (e.g. "blobs/<sha256>.mp4"), served at /uploads/<key>.
+    // Older rows hold a local path like "./uploads/room_123_video.mp4" - see storage.KeyFromPath
+    FilePath string `gorm:"type:text;not null;index" json:"file_path"`
+	// SHA-256 of the content; the file is shared through MediaBlob. Empty for rows from before deduplication.
+	BlobHash string `gorm:"type:varchar(64);not null;default:'';index" json:"blob_hash,omitempty"`
 
 	// --- Foreign Keys for Relationships ---
 	//RoomID references the Room this media item belongs to
@@ -30,12 +31,62 @@ type MediaItem struct {
 	UploaderID uint `gorm:"not null;index" json:"uploader_id"` // Index for faster lookups
 	Uploader User `gorm:"foreignKey:UploaderID" json:"-"` // Optional: Embed User data
 	OrderIndex 	int 	`gorm:"type:int;default:0" json:"order_index"`
-	PosterURL string `gorm:"type:text;not null;default:''" json:"poster_url"` // ← NEW
+	PosterURL string `gorm:"type:text;not null;default:'';index" json:"poster_url"` // ← NEW
 	Duration  string `gorm:"type:varchar(20);not null;default:''" json:"duration"` // ← NEW
+	// Storage keys of the resized poster renditions (JPEG + WebP); exposed as PosterVariants
+	PosterVariantKeys []string `gorm:"type:text;serializer:json" json:"-"`
+	// Probed technical metadata (duration in seconds, resolution, codecs, audio tracks...)
+	MediaMetadata `gorm:"embedded"`
 
-	// Add fields later like Title, Description, Duration (if extractable), ThumbnailPath, etc
+	// Storage key of the seek-preview WebVTT track ("storyboards/<stem>/storyboard.vtt"), empty until generated.
+	// Its sprite sheets sit next to it; clients use StoryboardURL instead.
+	StoryboardKey string `gorm:"type:text;not null;default:'';index" json:"-"`
+
+	// Set on clips cut from another media item: the source item and the cut range in seconds
+	ClipOfID  *uint   `gorm:"index" json:"clip_of_id,omitempty"`
+	ClipStart float64 `gorm:"not null;default:0" json:"clip_start,omitempty"`
+	ClipEnd   float64 `gorm:"not null;default:0" json:"clip_end,omitempty"`
+
+	// Signed, short-lived playback URL for the requesting user (not stored)
+	FileURL      string `gorm:"-" json:"file_url,omitempty"`
+	URLExpiresAt int64  `gorm:"-" json:"url_expires_at,omitempty"` // Unix seconds; refresh the URLs before this
+	// Signed URLs of the poster renditions (not stored)
+	PosterVariants []PosterVariant `gorm:"-" json:"poster_variants,omitempty"`
+	// API URL of the thumbnails track for seek previews (not stored)
+	StoryboardURL string `gorm:"-" json:"storyboard_url,omitempty"`
+
+	// Library metadata edited by the host; Title falls back to OriginalName in clients when empty
+	Title         string `gorm:"type:varchar(255);not null;default:''" json:"title"`
+	Description   string `gorm:"type:text;not null;default:''" json:"description"`
+	Year          *int   `json:"year,omitempty"`
+	ContentRating string `gorm:"type:varchar(20);not null;default:''" json:"content_rating"` // e.g. "PG-13", "TV-MA"; see ContentRatings
+	// Tags from media_item_tags (not stored on the row)
+	Tags []string `gorm:"-" json:"tags,omitempty"`
+
+	// Slideshows only: seconds each slide stays up; DurationSeconds is slides x SlideDuration
+	SlideDuration float64 `gorm:"type:decimal(8,3);not null;default:0" json:"slide_duration,omitempty"`
+	// Slides with signed image URLs (not stored on the row)
+	Slides []MediaSlide `gorm:"-" json:"slides,omitempty"`
+
+	// Skip markers in seconds, 0 when unset: the opening titles and where the end credits begin.
+	// Proposed by the detection job (MarkerSource "detected") or set by the host ("manual").
+	IntroStart   float64 `gorm:"type:decimal(12,3);not null;default:0" json:"intro_start,omitempty"`
+	IntroEnd     float64 `gorm:"type:decimal(12,3);not null;default:0" json:"intro_end,omitempty"`
+	CreditsStart float64 `gorm:"type:decimal(12,3);not null;default:0" json:"credits_start,omitempty"`
+	MarkerSource string  `gorm:"type:varchar(20);not null;default:''" json:"marker_source,omitempty"`
+	MarkerStatus string  `gorm:"type:varchar(20);not null;default:''" json:"marker_status,omitempty"` // "", "analyzing", "done", "failed"
 }
 
+// Skip marker sources and detection states.
+const (
+	MarkerSourceDetected = "detected"
+	MarkerSourceManual   = "manual"
+
+	MarkerStatusAnalyzing = "analyzing"
+	MarkerStatusDone      = "done"
+	MarkerStatusFailed    = "failed"
+)
+
 // TableName overrides the table name used by GORM.
 // By default, GORM uses the plural of the struct name ("media_items").
 // This is optional but explicit.
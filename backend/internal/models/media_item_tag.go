@@ -0,0 +1,20 @@
+package models
+
+// MediaItemTag attaches a lower-case tag (e.g. "horror", "classic") to a library item.
+type MediaItemTag struct {
+	ID          uint   `gorm:"primarykey" json:"id"`
+	MediaItemID uint   `gorm:"not null;uniqueIndex:idx_media_item_tag" json:"media_item_id"`
+	Tag         string `gorm:"type:varchar(50);not null;uniqueIndex:idx_media_item_tag;index" json:"tag"`
+}
+
+// TableName overrides the table name used by GORM.
+func (MediaItemTag) TableName() string {
+	return "media_item_tags"
+}
+
+// ContentRatings are the accepted values of MediaItem.ContentRating ("" means unrated).
+var ContentRatings = []string{
+	"G", "PG", "PG-13", "R", "NC-17",
+	"TV-Y", "TV-Y7", "TV-G", "TV-PG", "TV-14", "TV-MA",
+	"NR",
+}
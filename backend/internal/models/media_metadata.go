@@ -0,0 +1,26 @@
+package models
+
+// Media kinds. Blobs hold a single video, audio file or image; library items are
+// videos, audio tracks or slideshows (an ordered set of image blobs, see MediaSlide).
+const (
+	MediaKindVideo     = "video"
+	MediaKindAudio     = "audio"
+	MediaKindImage     = "image" // blobs only: one slide's picture
+	MediaKindSlideshow = "slideshow"
+)
+
+// MediaMetadata holds the technical details ffprobe extracts from an upload.
+// It is embedded in both MediaItem and TemporaryMediaItem so clients can show
+// resolution and pick audio tracks without probing the file themselves.
+type MediaMetadata struct {
+	MediaKind       string   `gorm:"type:varchar(20);not null;default:'video'" json:"media_kind"` // MediaKindVideo, MediaKindAudio, ...
+	DurationSeconds float64  `gorm:"type:decimal(12,3);not null;default:0" json:"duration_seconds"`
+	Width           int      `gorm:"type:int;not null;default:0" json:"width"`
+	Height          int      `gorm:"type:int;not null;default:0" json:"height"`
+	FrameRate       float64  `gorm:"type:decimal(8,3);not null;default:0" json:"frame_rate"`
+	VideoCodec      string   `gorm:"type:varchar(50);not null;default:''" json:"video_codec"`
+	AudioCodec      string   `gorm:"type:varchar(50);not null;default:''" json:"audio_codec"`
+	Bitrate         int64    `gorm:"type:bigint;not null;default:0" json:"bitrate"`          // bits per second
+	AudioLanguages  []string `gorm:"type:text;serializer:json" json:"audio_languages"`       // One entry per audio track, "und" if untagged
+	Container       string   `gorm:"type:varchar(100);not null;default:''" json:"container"` // ffprobe format_name, e.g. "mov,mp4,m4a,3gp,3g2,mj2"
+}
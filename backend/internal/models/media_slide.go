@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// MediaSlide is one image of a slideshow MediaItem. The picture is a MediaBlob
+// (MediaKindImage) shared like any other upload; each slide holds one reference to it.
+type MediaSlide struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	MediaItemID uint      `gorm:"not null;index" json:"media_item_id"`
+	Position    int       `gorm:"type:int;not null;default:0" json:"position"` // 0-based display order
+	BlobHash    string    `gorm:"type:varchar(64);not null;index" json:"blob_hash"`
+	ImageKey    string    `gorm:"type:text;not null;index" json:"-"` // storage key of the blob's image
+	MimeType    string    `gorm:"type:varchar(100);not null" json:"mime_type"`
+	Size        int64     `gorm:"type:bigint;not null;default:0" json:"size"`
+	Width       int       `gorm:"type:int;not null;default:0" json:"width"`
+	Height      int       `gorm:"type:int;not null;default:0" json:"height"`
+	Caption     string    `gorm:"type:varchar(255);not null;default:''" json:"caption"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Signed URL of the image for the requesting user (not stored)
+	ImageURL string `gorm:"-" json:"image_url,omitempty"`
+}
+
+// TableName overrides the table name used by GORM.
+func (MediaSlide) TableName() string {
+	return "media_slides"
+}
@@ -0,0 +1,10 @@
+package models
+
+// PosterVariant is one resized rendition of a media item's poster, e.g. 640px WebP.
+// Variants are stored by key on the item (PosterVariantKeys) and turned into signed
+// URLs per request, so this type is never persisted itself.
+type PosterVariant struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Format string `json:"format"` // "jpg" or "webp"
+}
@@ -0,0 +1,28 @@
+// WeWatch/backend/internal/models/retention_purge.go
+package models
+
+import "time"
+
+// RetentionPurge records one run of the retention purge job deleting a room's data.
+type RetentionPurge struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	RoomID     uint      `gorm:"not null;index" json:"room_id"`
+	Kind       string    `gorm:"type:varchar(30);not null" json:"kind"` // RetentionPurge* below
+	Count      int       `gorm:"not null;default:0" json:"count"`
+	ItemIDs    []uint    `gorm:"type:text;serializer:json" json:"item_ids"`              // IDs of the deleted rows
+	SessionIDs []string  `gorm:"type:text;serializer:json" json:"session_ids,omitempty"` // sessions whose chat was purged
+	Cutoff     time.Time `json:"cutoff"`                                                 // what was older than this went
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+}
+
+// What a RetentionPurge deleted
+const (
+	RetentionPurgeSessionChat    = "session_chat"
+	RetentionPurgeRoomMessages   = "room_messages"
+	RetentionPurgeTemporaryMedia = "temporary_media"
+)
+
+// TableName overrides the table name used by GORM.
+func (RetentionPurge) TableName() string {
+	return "retention_purges"
+}
@@ -24,15 +24,28 @@ type Room struct {
 	ScreenSharingUserID  uint `gorm:"default:0" json:"screen_sharing_user_id"`
 	// Persistent state
 	LoopMode string `gorm:"type:varchar(20);default:'none'" json:"loop_mode"` // 'none', 'playlist', 'single'
+	AdvanceAtCredits bool `gorm:"default:false" json:"advance_at_credits"` // Server moves to the next item when the credits start
 	OverridePlaying 	string  `gorm:"override_playing,omitempty"`
 	OverrideComingNext	string	`gorm:"override_coming_next,omitempty"`
 	SeatingModeEnabled   bool `json:"seating_mode_enabled"`
     HostBroadcastEnabled bool `json:"host_broadcast_enabled"`
 	ShowHost             bool `gorm:"default:true" json:"show_host"`
 	ShowDescription      bool `gorm:"default:true" json:"show_description"`
+	// Retention, enforced at session end and by the retention purge job
+	ChatRetention         string `gorm:"type:varchar(20);default:'delete'" json:"chat_retention"` // 'delete' at session end, 'keep', 'archive' (kept read-only)
+	ChatRetentionDays     int    `gorm:"default:0" json:"chat_retention_days"`       // Session chat is purged this many days after the session ends; 0 = never (keep, archive)
+	RoomMessageMaxAgeDays int    `gorm:"default:0" json:"room_message_max_age_days"` // 0 = room messages are kept forever
+	TempMediaMaxHours     int    `gorm:"default:0" json:"temp_media_max_hours"`      // 0 = temporary uploads last until their session ends
 	// Add more fields later like MaxViewers, Password, etc.
 }
 
+// Session chat retention modes (Room.ChatRetention)
+const (
+	ChatRetentionDelete  = "delete"
+	ChatRetentionKeep    = "keep"
+	ChatRetentionArchive = "archive"
+)
+
 // Remove or fix any incorrect hook functions like BeforeCreate or BeforeUpdate
 // that might contain 'return Error'. They should 'return nil' if no error occurs.
 // Example of a corrected hook (optional):
@@ -14,6 +14,7 @@ type RoomMessage struct {
 	Username      string    `gorm:"-" json:"username"` // Not stored, populated from User
 	Message       string    `gorm:"type:text;not null" json:"message"`
 	DeletedByHost bool      `gorm:"default:false" json:"deleted_by_host"` // Track if deleted by host
+	MediaItemID   *uint     `gorm:"index" json:"media_item_id,omitempty"` // Attached media item (e.g. a shared clip)
 	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
 	
 	// Relationships
@@ -0,0 +1,31 @@
+// WeWatch/backend/internal/models/session_join_request.go
+package models
+
+import "time"
+
+// SessionJoinRequest is a viewer knocking on a watch session that requires approval.
+type SessionJoinRequest struct {
+	ID          uint       `gorm:"primarykey" json:"id"`
+	SessionID   string     `gorm:"type:varchar(36);not null;index" json:"session_id"`
+	RoomID      uint       `gorm:"not null;index" json:"room_id"`
+	UserID      uint       `gorm:"not null;index" json:"user_id"`
+	Status      string     `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"` // JoinRequest* below
+	RequestedAt time.Time  `gorm:"not null" json:"requested_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	DecidedAt   *time.Time `json:"decided_at,omitempty"`
+	DecidedBy   *uint      `json:"decided_by,omitempty"` // nil when nobody decided (expired, cancelled)
+}
+
+// Join request statuses
+const (
+	JoinRequestPending   = "pending"
+	JoinRequestApproved  = "approved"
+	JoinRequestDenied    = "denied"
+	JoinRequestExpired   = "expired"   // nobody decided in time
+	JoinRequestCancelled = "cancelled" // the requester left the lobby
+)
+
+// TableName overrides the table name used by GORM.
+func (SessionJoinRequest) TableName() string {
+	return "session_join_requests"
+}
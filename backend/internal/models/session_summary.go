@@ -0,0 +1,68 @@
+// WeWatch/backend/internal/models/session_summary.go
+package models
+
+import "time"
+
+// SessionSummary is the recap of a watch session, written when the session ends (the
+// session's chat and reactions may be deleted then, the summary stays).
+type SessionSummary struct {
+	ID              uint                     `gorm:"primarykey" json:"id"`
+	SessionID       string                   `gorm:"type:varchar(36);uniqueIndex;not null" json:"session_id"`
+	RoomID          uint                     `gorm:"not null;index" json:"room_id"`
+	HostID          uint                     `gorm:"not null;index" json:"host_id"` // host when the session ended
+	WatchType       string                   `gorm:"type:varchar(50)" json:"watch_type"`
+	StartedAt       time.Time                `json:"started_at"`
+	EndedAt         time.Time                `gorm:"index" json:"ended_at"`
+	EndReason       string                   `gorm:"type:varchar(30)" json:"end_reason"`
+	DurationSeconds float64                  `gorm:"type:decimal(12,3);not null;default:0" json:"duration_seconds"`
+	PeakViewers     int                      `gorm:"not null;default:0" json:"peak_viewers"`
+	AverageViewers  float64                  `gorm:"type:decimal(8,2);not null;default:0" json:"average_viewers"`
+	MessageCount    int                      `gorm:"not null;default:0" json:"message_count"`
+	TheaterCount    int                      `gorm:"not null;default:0" json:"theater_count"` // 3D cinema sessions only
+	Members         []SessionSummaryMember   `gorm:"serializer:json" json:"members"`
+	ItemsWatched    []SessionSummaryItem     `gorm:"serializer:json" json:"items_watched"`
+	TopReactions    []SessionSummaryReaction `gorm:"serializer:json" json:"top_reactions"`
+	CreatedAt       time.Time                `json:"created_at"`
+}
+
+// SessionSummaryMember is one participant and how long they were present.
+type SessionSummaryMember struct {
+	UserID         uint      `json:"user_id"`
+	Username       string    `json:"username"`
+	FirstJoinedAt  time.Time `json:"first_joined_at"`
+	SecondsPresent float64   `json:"seconds_present"`
+}
+
+// SessionSummaryItem is something played during the session.
+type SessionSummaryItem struct {
+	MediaItemID uint      `json:"media_item_id,omitempty"` // 0 for temporary uploads
+	Title       string    `json:"title"`
+	StartedAt   time.Time `json:"started_at"`
+}
+
+// SessionSummaryReaction is an emoji and how often it was used.
+type SessionSummaryReaction struct {
+	Emoji string `json:"emoji"`
+	Count int    `json:"count"`
+}
+
+// SessionMediaPlay records that an item started playing in a session; the summary's
+// ItemsWatched comes from these.
+type SessionMediaPlay struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	SessionID   string    `gorm:"type:varchar(36);not null;index" json:"session_id"`
+	MediaItemID uint      `json:"media_item_id,omitempty"` // 0 for temporary uploads
+	FilePath    string    `gorm:"type:varchar(500)" json:"-"`
+	Title       string    `gorm:"type:varchar(255)" json:"title"`
+	StartedAt   time.Time `gorm:"not null" json:"started_at"`
+}
+
+// TableName overrides the table name used by GORM.
+func (SessionSummary) TableName() string {
+	return "session_summaries"
+}
+
+// TableName overrides the table name used by GORM.
+func (SessionMediaPlay) TableName() string {
+	return "session_media_plays"
+}
@@ -0,0 +1,39 @@
+// WeWatch/backend/internal/models/session_timeline.go
+package models
+
+import "time"
+
+// SessionTimelineEvent is one moment of a watch session's timeline, stamped with the wall
+// clock and with where the media was at that moment, so the session can be replayed
+// against the video later.
+type SessionTimelineEvent struct {
+	ID            uint                   `gorm:"primarykey" json:"id"`
+	SessionID     string                 `gorm:"type:varchar(36);not null;index:idx_session_timeline_session_at,priority:1" json:"session_id"`
+	RoomID        uint                   `gorm:"not null;index" json:"room_id"`
+	Kind          string                 `gorm:"type:varchar(20);not null" json:"kind"` // Timeline* below
+	UserID        uint                   `json:"user_id,omitempty"`                     // who did it; 0 for the server
+	OccurredAt    time.Time              `gorm:"not null;index:idx_session_timeline_session_at,priority:2" json:"occurred_at"`
+	MediaItemID   uint                   `json:"media_item_id,omitempty"` // 0 for temporary uploads (see FilePath)
+	FilePath      string                 `gorm:"type:varchar(500)" json:"file_path,omitempty"`
+	MediaPosition *float64               `gorm:"type:decimal(12,3)" json:"media_position,omitempty"` // seconds into the media; nil when nothing was playing
+	Playing       bool                   `json:"playing"`
+	Command       string                 `gorm:"type:varchar(20)" json:"command,omitempty"` // playback: play, pause, resume, seek...
+	ChatMessageID uint                   `gorm:"index" json:"chat_message_id,omitempty"`
+	ReactionID    uint                   `json:"reaction_id,omitempty"`
+	Data          map[string]interface{} `gorm:"type:text;serializer:json" json:"data,omitempty"` // kind-specific extras (e.g. TV content)
+}
+
+// Timeline event kinds
+const (
+	TimelinePlayback  = "playback"
+	TimelineChat      = "chat"
+	TimelineReaction  = "reaction"
+	TimelineJoin      = "join"
+	TimelineLeave     = "leave"
+	TimelineTVContent = "tv_content"
+)
+
+// TableName overrides the table name used by GORM.
+func (SessionTimelineEvent) TableName() string {
+	return "session_timeline_events"
+}
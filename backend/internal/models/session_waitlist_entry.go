@@ -0,0 +1,30 @@
+// WeWatch/backend/internal/models/session_waitlist_entry.go
+package models
+
+import "time"
+
+// SessionWaitlistEntry is a viewer queued for a full watch session. Entries are admitted
+// in ID order.
+type SessionWaitlistEntry struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	SessionID  string     `gorm:"type:varchar(36);not null;index" json:"session_id"`
+	RoomID     uint       `gorm:"not null;index" json:"room_id"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	Status     string     `gorm:"type:varchar(20);not null;default:'waiting';index" json:"status"` // Waitlist* below
+	QueuedAt   time.Time  `gorm:"not null" json:"queued_at"`
+	AdmittedAt *time.Time `json:"admitted_at,omitempty"`
+	LeftAt     *time.Time `json:"left_at,omitempty"` // gave up, or the session ended
+}
+
+// Waitlist entry statuses
+const (
+	WaitlistWaiting  = "waiting"
+	WaitlistAdmitted = "admitted"
+	WaitlistLeft     = "left"   // the viewer disconnected or left the waitlist
+	WaitlistClosed   = "closed" // the session ended first
+)
+
+// TableName overrides the table name used by GORM.
+func (SessionWaitlistEntry) TableName() string {
+	return "session_waitlist_entries"
+}
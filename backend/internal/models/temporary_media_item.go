@@ -19,10 +19,18 @@ type TemporaryMediaItem struct {
 	OriginalName string    `gorm:"type:varchar(255);not null" json:"original_name"`
 	MimeType     string    `gorm:"type:varchar(100);not null" json:"mime_type"`
 	FileSize     int64     `gorm:"type:bigint;not null;default:0" json:"file_size"`
-	FilePath     string    `gorm:"type:text;not null" json:"file_path"` // Path to the uploaded file
-	PosterURL    string    `gorm:"type:text;not null" json:"poster_url"` // URL to the generated poster/thumbnail
+	FilePath     string    `gorm:"type:text;not null;index" json:"file_path"` // Storage key of the uploaded file (e.g. "blobs/<sha256>.mp4"; older rows "temp/<uuid>.mp4")
+	BlobHash     string    `gorm:"type:varchar(64);not null;default:'';index" json:"blob_hash,omitempty"` // Shared MediaBlob, empty for rows from before deduplication
+	PosterURL    string    `gorm:"type:text;not null;index" json:"poster_url"` // URL to the generated poster/thumbnail
+	PosterVariantKeys []string `gorm:"type:text;serializer:json" json:"-"` // Resized poster renditions (JPEG + WebP)
 	Duration     string    `gorm:"type:varchar(20);not null;default:'00:00:00'" json:"duration"` // Extracted duration (HH:MM:SS)
 	OrderIndex   int       `gorm:"type:int;default:0" json:"order_index"` // For playlist ordering
+	MediaMetadata          `gorm:"embedded"` // Probed technical metadata (same as MediaItem)
+
+	// Signed, short-lived playback URL for the requesting user (not stored)
+	FileURL      string `gorm:"-" json:"file_url,omitempty"`
+	URLExpiresAt int64  `gorm:"-" json:"url_expires_at,omitempty"` // Unix seconds; refresh the URLs before this
+	PosterVariants []PosterVariant `gorm:"-" json:"poster_variants,omitempty"` // Signed poster rendition URLs (not stored)
 
 	// --- Foreign Keys for Relationships ---
 	RoomID       uint      `gorm:"not null;index" json:"room_id"` // Link to the room this media belongs to
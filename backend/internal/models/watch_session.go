@@ -15,9 +15,40 @@ type WatchSession struct {
 	WatchType string    `gorm:"type:varchar(50);default:'video'" json:"watch_type"` // "video" or "3d_cinema"
 	StartedAt time.Time `json:"started_at"`
 	EndedAt   *time.Time `json:"ended_at,omitempty"`
+	State     string    `gorm:"type:varchar(20);not null;default:'live';index" json:"state"` // see SessionState*
+	EndReason string    `gorm:"type:varchar(30)" json:"end_reason,omitempty"`                // see SessionEnd*
+	EndedBy   *uint     `json:"ended_by,omitempty"`                                           // nil when the server ended it
+	// Heartbeat for idle expiry: bumped by members' messages and playback reports.
+	// ExpiryWarnedAt is set once members were warned the session is about to expire.
+	LastActivityAt time.Time  `gorm:"index" json:"last_activity_at"`
+	ExpiryWarnedAt *time.Time `json:"expiry_warned_at,omitempty"`
+	// Knock-to-join: new viewers wait in the lobby until the host approves them
+	RequiresApproval bool `gorm:"default:false" json:"requires_approval"`
+	// Capacity: viewers beyond the caps wait on the session's waitlist (0 = no limit)
+	MaxViewers  int `gorm:"default:0" json:"max_viewers"`
+	MaxTheaters int `gorm:"default:0" json:"max_theaters"` // 3D cinema only
 	Members   []WatchSessionMember `json:"members"` // Active session participants
 }
 
+// Session states. A session goes created → live ⇄ host_away → ending → ended;
+// EndedAt is set exactly when the state becomes ended.
+const (
+	SessionStateCreated  = "created"   // started, the host hasn't connected yet
+	SessionStateLive     = "live"      // the host is connected
+	SessionStateHostAway = "host_away" // the host disconnected; the session ends if they stay away
+	SessionStateEnding   = "ending"    // end hooks are running
+	SessionStateEnded    = "ended"
+)
+
+// Why a session ended (WatchSession.EndReason, and "reason" in the session_ended event).
+const (
+	SessionEndHost        = "host_ended"    // the room host ended it
+	SessionEndHostTimeout = "host_timeout"  // the host stayed away past the grace period
+	SessionEndStale       = "stale_cleanup" // still running after 24 hours
+	SessionEndExpired     = "expired"       // an instant watch that went idle or nobody is in any more
+	SessionEndRoomDeleted = "room_deleted"
+)
+
 // WatchSessionMember represents an active participant in a watch session
 type WatchSessionMember struct {
 	gorm.Model
@@ -28,9 +59,18 @@ type WatchSessionMember struct {
 	IsActive       bool      `gorm:"default:true" json:"is_active"`
 	UserRole       string    `gorm:"type:varchar(20);default:'viewer'" json:"user_role"` // viewer, broadcaster
 	CanBroadcast   bool      `gorm:"default:false" json:"can_broadcast"` // Host-granted permission to speak to whole room
+	IsCoHost       bool      `gorm:"default:false" json:"is_co_host"`    // First in line to take over when the host is away
 	// Client         *Client   `gorm:"-" json:"-"` // WebSocket client reference (not stored in DB)
 }
 
+// BeforeCreate starts the activity clock with the session.
+func (s *WatchSession) BeforeCreate(tx *gorm.DB) error {
+	if s.LastActivityAt.IsZero() {
+		s.LastActivityAt = time.Now()
+	}
+	return nil
+}
+
 // TableName overrides the table name used by GORM.
 func (WatchSession) TableName() string {
 	return "watch_sessions"
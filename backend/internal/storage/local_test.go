@@ -0,0 +1,89 @@
+// WeWatch/backend/internal/storage/local_test.go
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocal(t *testing.T) {
+	s, err := NewLocal(filepath.Join(t.TempDir(), "uploads"))
+	if err != nil {
+		t.Fatalf("NewLocal: %v", err)
+	}
+	exerciseStorage(t, s)
+}
+
+func TestLocalPutFileMovesFile(t *testing.T) {
+	root := t.TempDir()
+	s, err := NewLocal(filepath.Join(root, "uploads"))
+	if err != nil {
+		t.Fatalf("NewLocal: %v", err)
+	}
+	src := filepath.Join(root, "work.mp4")
+	if err := os.WriteFile(src, []byte("video"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.PutFile(context.Background(), "temp/work.mp4", src, "video/mp4"); err != nil {
+		t.Fatalf("PutFile: %v", err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("source still exists after PutFile (err = %v)", err)
+	}
+	info, err := s.Stat(context.Background(), "temp/work.mp4")
+	if err != nil || info.Size != 5 {
+		t.Errorf("Stat = %+v, %v; want size 5", info, err)
+	}
+}
+
+func TestLocalRejectsEscapingKeys(t *testing.T) {
+	s, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocal: %v", err)
+	}
+	if _, ok := s.LocalPath("../secret"); ok {
+		t.Error("LocalPath accepted a key outside the root")
+	}
+	if _, err := s.Stat(context.Background(), "a/../../secret"); err == nil {
+		t.Error("Stat accepted a key outside the root")
+	}
+}
+
+func TestLocalPresign(t *testing.T) {
+	s, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocal: %v", err)
+	}
+	u, err := s.Presign(context.Background(), "posters/p.jpg", 0)
+	if err != nil {
+		t.Fatalf("Presign: %v", err)
+	}
+	if u != "/uploads/posters/p.jpg" {
+		t.Errorf("Presign = %q, want /uploads/posters/p.jpg", u)
+	}
+}
+
+func TestLocalServeRange(t *testing.T) {
+	s, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocal: %v", err)
+	}
+	if err := s.Put(context.Background(), "blobs/v.mp4", strings.NewReader("0123456789"), 10, "video/mp4"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/uploads/blobs/v.mp4", nil)
+	req.Header.Set("Range", "bytes=2-4")
+	rec := httptest.NewRecorder()
+	Serve(rec, req, s, "blobs/v.mp4")
+	body, _ := io.ReadAll(rec.Body)
+	if rec.Code != http.StatusPartialContent || string(body) != "234" {
+		t.Errorf("Serve = %d %q, want 206 \"234\"", rec.Code, body)
+	}
+}
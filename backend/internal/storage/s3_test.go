@@ -0,0 +1,233 @@
+// WeWatch/backend/internal/storage/s3_test.go
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is a path-style, in-memory stand-in for the handful of S3 calls the driver makes.
+type fakeS3 struct {
+	mu      sync.Mutex
+	buckets map[string]map[string][]byte
+	modTime time.Time
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{buckets: map[string]map[string][]byte{}, modTime: time.Now().UTC().Truncate(time.Second)}
+}
+
+type fakeS3Object struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	Size         int64  `xml:"Size"`
+}
+
+type fakeS3List struct {
+	XMLName     xml.Name       `xml:"ListBucketResult"`
+	Name        string         `xml:"Name"`
+	Prefix      string         `xml:"Prefix"`
+	KeyCount    int            `xml:"KeyCount"`
+	MaxKeys     int            `xml:"MaxKeys"`
+	IsTruncated bool           `xml:"IsTruncated"`
+	Contents    []fakeS3Object `xml:"Contents"`
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	objects, ok := f.buckets[bucket]
+
+	if key == "" {
+		switch {
+		case r.Method == http.MethodHead:
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case r.Method == http.MethodPut:
+			f.buckets[bucket] = map[string][]byte{}
+		case r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+			prefix := r.URL.Query().Get("prefix")
+			list := fakeS3List{Name: bucket, Prefix: prefix, MaxKeys: 1000}
+			for k, data := range objects {
+				if strings.HasPrefix(k, prefix) {
+					list.Contents = append(list.Contents, fakeS3Object{
+						Key: k, LastModified: f.modTime.Format(time.RFC3339), ETag: `"etag"`, Size: int64(len(data)),
+					})
+				}
+			}
+			sort.Slice(list.Contents, func(i, j int) bool { return list.Contents[i].Key < list.Contents[j].Key })
+			list.KeyCount = len(list.Contents)
+			w.Header().Set("Content-Type", "application/xml")
+			xml.NewEncoder(w).Encode(list)
+		default:
+			http.Error(w, "unsupported bucket call", http.StatusNotImplemented)
+		}
+		return
+	}
+	if !ok {
+		f.writeError(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		data, err := readS3Payload(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		objects[key] = data
+		w.Header().Set("ETag", `"etag"`)
+	case http.MethodGet, http.MethodHead:
+		data, ok := objects[key]
+		if !ok {
+			f.writeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("ETag", `"etag"`)
+		w.Header().Set("Content-Type", "video/mp4")
+		http.ServeContent(w, r, key, f.modTime, bytes.NewReader(data))
+	case http.MethodDelete:
+		delete(objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "unsupported object call", http.StatusNotImplemented)
+	}
+}
+
+func (f *fakeS3) writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+}
+
+// readS3Payload returns the object bytes of a PUT, decoding aws-chunked bodies
+// ("<hex size>;chunk-signature=...\r\n<data>\r\n" ... "0;...", then optional trailers).
+func readS3Payload(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+	var out bytes.Buffer
+	br := bufio.NewReader(r.Body)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad chunk header %q", line)
+		}
+		if size == 0 {
+			return out.Bytes(), nil
+		}
+		if _, err := io.CopyN(&out, br, size); err != nil {
+			return nil, err
+		}
+		if _, err := br.Discard(2); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func newTestS3(t *testing.T) (*S3, *fakeS3, *httptest.Server) {
+	t.Helper()
+	fake := newFakeS3()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	s, err := NewS3(S3Config{
+		Endpoint:  srv.URL,
+		Bucket:    "media",
+		AccessKey: "test-access",
+		SecretKey: "test-secret",
+		Region:    "us-east-1",
+		UseSSL:    false,
+	})
+	if err != nil {
+		t.Fatalf("NewS3: %v", err)
+	}
+	return s, fake, srv
+}
+
+func TestS3(t *testing.T) {
+	s, fake, _ := newTestS3(t)
+	if _, ok := fake.buckets["media"]; !ok {
+		t.Fatal("NewS3 did not create the missing bucket")
+	}
+	exerciseStorage(t, s)
+}
+
+func TestS3Presign(t *testing.T) {
+	s, _, srv := newTestS3(t)
+	raw, err := s.Presign(context.Background(), "posters/p.jpg", 10*time.Minute)
+	if err != nil {
+		t.Fatalf("Presign: %v", err)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("Presign returned an invalid URL %q: %v", raw, err)
+	}
+	if want := strings.TrimPrefix(srv.URL, "http://"); u.Host != want {
+		t.Errorf("Presign host = %q, want %q", u.Host, want)
+	}
+	if u.Path != "/media/posters/p.jpg" {
+		t.Errorf("Presign path = %q, want /media/posters/p.jpg", u.Path)
+	}
+	q := u.Query()
+	if q.Get("X-Amz-Signature") == "" || q.Get("X-Amz-Expires") != "600" {
+		t.Errorf("Presign query = %v, want a signature expiring in 600s", q)
+	}
+
+	// The presigned URL is a plain GET the fake can answer
+	if err := s.Put(context.Background(), "posters/p.jpg", strings.NewReader("jpg"), 3, "image/jpeg"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	resp, err := http.Get(raw)
+	if err != nil {
+		t.Fatalf("GET presigned: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "jpg" {
+		t.Errorf("GET presigned = %d %q, want 200 \"jpg\"", resp.StatusCode, body)
+	}
+}
+
+func TestS3ServeRedirectsToPresignedURL(t *testing.T) {
+	s, _, srv := newTestS3(t)
+	if err := s.Put(context.Background(), "blobs/v.mp4", strings.NewReader("video"), 5, "video/mp4"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	Serve(rec, httptest.NewRequest(http.MethodGet, "/uploads/blobs/v.mp4", nil), s, "blobs/v.mp4")
+	if rec.Code != http.StatusFound {
+		t.Fatalf("Serve status = %d, want %d", rec.Code, http.StatusFound)
+	}
+	loc := rec.Header().Get("Location")
+	if !strings.HasPrefix(loc, srv.URL+"/media/blobs/v.mp4?") || !strings.Contains(loc, "X-Amz-Signature=") {
+		t.Errorf("Serve redirected to %q, want a presigned URL for the object", loc)
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store", cc)
+	}
+}
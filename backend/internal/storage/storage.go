@@ -0,0 +1,235 @@
+// WeWatch/backend/internal/storage/storage.go
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when a key does not exist in the backend.
+var ErrNotFound = errors.New("storage: object not found")
+
+// PublicPrefix is the URL path media objects are served under (GET /uploads/*filepath).
+const PublicPrefix = "/uploads/"
+
+// ObjectInfo describes a stored object.
+type ObjectInfo struct {
+	Key         string
+	Size        int64
+	ModTime     time.Time
+	ContentType string
+}
+
+// Storage is where media files (videos, posters, temp uploads) live.
+// Keys are slash-separated and relative to the storage root, e.g. "temp/<uuid>.mp4".
+type Storage interface {
+	// Put stores size bytes from r under key.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// PutFile stores a local file under key. Drivers may move the file instead of copying it,
+	// so callers must not rely on localPath existing afterwards.
+	PutFile(ctx context.Context, key string, localPath string, contentType string) error
+	// GetRange returns length bytes starting at offset. A negative length reads to the end.
+	GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+	// Stat returns ErrNotFound if the key doesn't exist.
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+	// Presign returns a URL that grants temporary GET access to key.
+	Presign(ctx context.Context, key string, expiry time.Duration) (string, error)
+	// List calls fn for every object whose key starts with prefix ("" lists everything).
+	// Returning an error from fn stops the walk.
+	List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error
+}
+
+// LocalPather is implemented by drivers that keep objects on the local filesystem,
+// letting callers hand paths straight to ffmpeg or http.ServeFile.
+type LocalPather interface {
+	LocalPath(key string) (string, bool)
+}
+
+// NewFromEnv builds the storage backend selected by STORAGE_DRIVER ("local" or "s3").
+func NewFromEnv() (Storage, error) {
+	driver := strings.ToLower(os.Getenv("STORAGE_DRIVER"))
+	switch driver {
+	case "", "local":
+		root := os.Getenv("STORAGE_LOCAL_ROOT")
+		if root == "" {
+			root = "./uploads"
+		}
+		log.Printf("📦 Storage: using local filesystem at '%s'", root)
+		return NewLocal(root)
+	case "s3":
+		cfg := S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			Region:    os.Getenv("S3_REGION"),
+			UseSSL:    os.Getenv("S3_USE_SSL") != "false",
+		}
+		log.Printf("📦 Storage: using S3-compatible bucket '%s' at %s", cfg.Bucket, cfg.Endpoint)
+		return NewS3(cfg)
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_DRIVER %q (expected \"local\" or \"s3\")", driver)
+	}
+}
+
+// KeyFromPath normalizes what we keep in FilePath/PosterURL columns into a storage key.
+// Older rows hold "./uploads/x.mp4" or "/uploads/x.mp4", newer rows hold the bare key.
+func KeyFromPath(p string) string {
+	p = strings.ReplaceAll(p, "\\", "/")
+	p = strings.TrimPrefix(p, "./")
+	p = strings.TrimPrefix(p, "/")
+	p = strings.TrimPrefix(p, "uploads/")
+	return path.Clean(p)
+}
+
+// IsStoredURL reports whether a URL points at an object we serve from storage
+// (as opposed to a static asset like /icons/placeholder-poster.jpg).
+func IsStoredURL(u string) bool {
+	return strings.HasPrefix(u, PublicPrefix)
+}
+
+// PublicURL returns the browser-facing path for a storage key.
+func PublicURL(key string) string {
+	return PublicPrefix + strings.TrimPrefix(key, "/")
+}
+
+// presignedRedirectExpiry is how long the link Serve redirects to stays valid. Players
+// re-request the /uploads URL for new ranges, so it only has to outlive one request.
+const presignedRedirectExpiry = 15 * time.Minute
+
+// Serve answers a request for the object, honouring Range requests. Local objects go
+// through http.ServeFile (sendfile). Other drivers redirect to a presigned URL so the
+// bytes don't pass through the app server, and are streamed from here only if
+// presigning fails.
+func Serve(w http.ResponseWriter, r *http.Request, s Storage, key string) {
+	if lp, ok := s.(LocalPather); ok {
+		if p, ok := lp.LocalPath(key); ok {
+			http.ServeFile(w, r, p)
+			return
+		}
+	} else if u, err := s.Presign(r.Context(), key, presignedRedirectExpiry); err == nil {
+		// The target expires, so the redirect itself must not be cached
+		w.Header().Set("Cache-Control", "no-store")
+		http.Redirect(w, r, u, http.StatusFound)
+		return
+	} else {
+		log.Printf("storage.Serve: presign %s failed, streaming it: %v", key, err)
+	}
+
+	info, err := s.Stat(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			http.NotFound(w, r)
+		} else {
+			log.Printf("storage.Serve: stat %s failed: %v", key, err)
+			http.Error(w, "storage error", http.StatusBadGateway)
+		}
+		return
+	}
+
+	rs := &rangeSeeker{ctx: r.Context(), store: s, key: key, size: info.Size}
+	defer rs.Close()
+	http.ServeContent(w, r, path.Base(key), info.ModTime, rs)
+}
+
+// rangeSeeker adapts GetRange to io.ReadSeeker so http.ServeContent can answer Range requests.
+// A new ranged GET is issued lazily after every Seek.
+type rangeSeeker struct {
+	ctx    context.Context
+	store  Storage
+	key    string
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+func (r *rangeSeeker) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.body == nil {
+		body, err := r.store.GetRange(r.ctx, r.key, r.offset, -1)
+		if err != nil {
+			return 0, err
+		}
+		r.body = body
+	}
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+func (r *rangeSeeker) Seek(offset int64, whence int) (int64, error) {
+	var next int64
+	switch whence {
+	case io.SeekStart:
+		next = offset
+	case io.SeekCurrent:
+		next = r.offset + offset
+	case io.SeekEnd:
+		next = r.size + offset
+	default:
+		return 0, errors.New("storage: invalid whence")
+	}
+	if next < 0 {
+		return 0, errors.New("storage: negative position")
+	}
+	if next != r.offset && r.body != nil {
+		r.body.Close()
+		r.body = nil
+	}
+	r.offset = next
+	return next, nil
+}
+
+func (r *rangeSeeker) Close() error {
+	if r.body != nil {
+		return r.body.Close()
+	}
+	return nil
+}
+
+// FetchToLocal returns a local filesystem path for key, downloading it to a temp file
+// when the driver isn't local. The returned cleanup func removes any temp copy.
+func FetchToLocal(ctx context.Context, s Storage, key string) (string, func(), error) {
+	if lp, ok := s.(LocalPather); ok {
+		if p, ok := lp.LocalPath(key); ok {
+			if _, err := os.Stat(p); err != nil {
+				return "", func() {}, ErrNotFound
+			}
+			return p, func() {}, nil
+		}
+	}
+
+	body, err := s.GetRange(ctx, key, 0, -1)
+	if err != nil {
+		return "", func() {}, err
+	}
+	defer body.Close()
+
+	tmp, err := os.CreateTemp("", "wewatch-*"+path.Ext(key))
+	if err != nil {
+		return "", func() {}, err
+	}
+	cleanup := func() { os.Remove(tmp.Name()) }
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		cleanup()
+		return "", func() {}, err
+	}
+	if err := tmp.Close(); err != nil {
+		cleanup()
+		return "", func() {}, err
+	}
+	return tmp.Name(), cleanup, nil
+}
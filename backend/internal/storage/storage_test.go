@@ -0,0 +1,129 @@
+// WeWatch/backend/internal/storage/storage_test.go
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// exerciseStorage runs the behaviour every driver must share: Put, GetRange, Stat, Delete and List.
+func exerciseStorage(t *testing.T, s Storage) {
+	t.Helper()
+	ctx := context.Background()
+	const body = "0123456789abcdef"
+
+	put := func(key, data string) {
+		t.Helper()
+		if err := s.Put(ctx, key, strings.NewReader(data), int64(len(data)), "video/mp4"); err != nil {
+			t.Fatalf("Put(%q): %v", key, err)
+		}
+	}
+	put("movies/a.mp4", body)
+	put("movies/b.mp4", "bb")
+	put("temp/c.mp4", "c")
+
+	info, err := s.Stat(ctx, "movies/a.mp4")
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if info.Key != "movies/a.mp4" || info.Size != int64(len(body)) {
+		t.Errorf("Stat = %+v, want key movies/a.mp4 size %d", info, len(body))
+	}
+	if _, err := s.Stat(ctx, "movies/missing.mp4"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat(missing) err = %v, want ErrNotFound", err)
+	}
+
+	ranges := []struct {
+		offset, length int64
+		want           string
+	}{
+		{0, -1, body},
+		{4, 3, "456"},
+		{10, -1, "abcdef"},
+		{0, 1, "0"},
+	}
+	for _, r := range ranges {
+		rc, err := s.GetRange(ctx, "movies/a.mp4", r.offset, r.length)
+		if err != nil {
+			t.Fatalf("GetRange(%d, %d): %v", r.offset, r.length, err)
+		}
+		got, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("GetRange(%d, %d) read: %v", r.offset, r.length, err)
+		}
+		if string(got) != r.want {
+			t.Errorf("GetRange(%d, %d) = %q, want %q", r.offset, r.length, got, r.want)
+		}
+	}
+
+	// Overwriting a key replaces its content
+	put("movies/b.mp4", "bbbb")
+	if info, err := s.Stat(ctx, "movies/b.mp4"); err != nil || info.Size != 4 {
+		t.Errorf("Stat after overwrite = %+v, %v; want size 4", info, err)
+	}
+
+	list := func(prefix string) []string {
+		t.Helper()
+		var keys []string
+		if err := s.List(ctx, prefix, func(o ObjectInfo) error {
+			keys = append(keys, o.Key)
+			return nil
+		}); err != nil {
+			t.Fatalf("List(%q): %v", prefix, err)
+		}
+		sort.Strings(keys)
+		return keys
+	}
+	if got := strings.Join(list("movies/"), ","); got != "movies/a.mp4,movies/b.mp4" {
+		t.Errorf("List(movies/) = %s", got)
+	}
+	if got := strings.Join(list(""), ","); got != "movies/a.mp4,movies/b.mp4,temp/c.mp4" {
+		t.Errorf("List(\"\") = %s", got)
+	}
+
+	// An error from fn stops the walk and is returned as is
+	stop := errors.New("stop")
+	calls := 0
+	err = s.List(ctx, "", func(ObjectInfo) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("List with failing fn: err = %v after %d calls, want stop after 1", err, calls)
+	}
+
+	if err := s.Delete(ctx, "movies/a.mp4"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Stat(ctx, "movies/a.mp4"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat after Delete err = %v, want ErrNotFound", err)
+	}
+	if rc, err := s.GetRange(ctx, "movies/a.mp4", 0, -1); !errors.Is(err, ErrNotFound) {
+		if rc != nil {
+			rc.Close()
+		}
+		t.Errorf("GetRange after Delete err = %v, want ErrNotFound", err)
+	}
+	if err := s.Delete(ctx, "movies/a.mp4"); err != nil {
+		t.Errorf("Delete(missing) = %v, want nil", err)
+	}
+}
+
+func TestKeyFromPath(t *testing.T) {
+	cases := map[string]string{
+		"abc.mp4":                 "abc.mp4",
+		"./uploads/abc.mp4":       "abc.mp4",
+		"/uploads/temp/abc.mp4":   "temp/abc.mp4",
+		"uploads\\posters\\x.jpg": "posters/x.jpg",
+	}
+	for in, want := range cases {
+		if got := KeyFromPath(in); got != want {
+			t.Errorf("KeyFromPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+-- Activity heartbeat for idle expiry of instant-watch sessions
+ALTER TABLE watch_sessions ADD COLUMN IF NOT EXISTS last_activity_at TIMESTAMP;
+ALTER TABLE watch_sessions ADD COLUMN IF NOT EXISTS expiry_warned_at TIMESTAMP;
+
+UPDATE watch_sessions SET last_activity_at = COALESCE(ended_at, started_at, created_at) WHERE last_activity_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_watch_sessions_last_activity_at ON watch_sessions(last_activity_at);
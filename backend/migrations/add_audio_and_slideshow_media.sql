@@ -0,0 +1,28 @@
+-- Media kinds: video, audio, slideshow (items) and image (blobs holding one slide's picture)
+ALTER TABLE media_items ADD COLUMN IF NOT EXISTS media_kind VARCHAR(20) NOT NULL DEFAULT 'video';
+ALTER TABLE temporary_media_items ADD COLUMN IF NOT EXISTS media_kind VARCHAR(20) NOT NULL DEFAULT 'video';
+ALTER TABLE media_blobs ADD COLUMN IF NOT EXISTS media_kind VARCHAR(20) NOT NULL DEFAULT 'video';
+
+-- Audio waveform peaks (JSON array), computed at first upload
+ALTER TABLE media_blobs ADD COLUMN IF NOT EXISTS waveform TEXT;
+
+-- Slideshows: seconds per slide (duration_seconds = slides x slide_duration)
+ALTER TABLE media_items ADD COLUMN IF NOT EXISTS slide_duration DECIMAL(8,3) NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS media_slides (
+    id BIGSERIAL PRIMARY KEY,
+    media_item_id BIGINT NOT NULL REFERENCES media_items(id), -- slides are deleted (and their blobs released) by the app
+    position INT NOT NULL DEFAULT 0,
+    blob_hash VARCHAR(64) NOT NULL, -- one reference on the image's media_blobs row
+    image_key TEXT NOT NULL,
+    mime_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL DEFAULT 0,
+    width INT NOT NULL DEFAULT 0,
+    height INT NOT NULL DEFAULT 0,
+    caption VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_media_slides_media_item_id ON media_slides(media_item_id);
+CREATE INDEX IF NOT EXISTS idx_media_slides_blob_hash ON media_slides(blob_hash);
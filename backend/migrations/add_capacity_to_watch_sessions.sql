@@ -0,0 +1,19 @@
+-- Session capacity: viewers beyond the caps wait on the session's waitlist (0 = no limit)
+ALTER TABLE watch_sessions ADD COLUMN IF NOT EXISTS max_viewers INTEGER DEFAULT 0;
+ALTER TABLE watch_sessions ADD COLUMN IF NOT EXISTS max_theaters INTEGER DEFAULT 0; -- 3D cinema only
+
+CREATE TABLE IF NOT EXISTS session_waitlist_entries (
+    id BIGSERIAL PRIMARY KEY,
+    session_id VARCHAR(36) NOT NULL,
+    room_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'waiting', -- waiting, admitted, left, closed
+    queued_at TIMESTAMP NOT NULL,
+    admitted_at TIMESTAMP,
+    left_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_waitlist_entries_session_id ON session_waitlist_entries(session_id);
+CREATE INDEX IF NOT EXISTS idx_session_waitlist_entries_room_id ON session_waitlist_entries(room_id);
+CREATE INDEX IF NOT EXISTS idx_session_waitlist_entries_user_id ON session_waitlist_entries(user_id);
+CREATE INDEX IF NOT EXISTS idx_session_waitlist_entries_status ON session_waitlist_entries(status);
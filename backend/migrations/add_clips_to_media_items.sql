@@ -0,0 +1,9 @@
+-- Clips cut from a media item point back at their source and remember the cut range
+ALTER TABLE media_items ADD COLUMN IF NOT EXISTS clip_of_id BIGINT REFERENCES media_items(id) ON DELETE SET NULL;
+ALTER TABLE media_items ADD COLUMN IF NOT EXISTS clip_start DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE media_items ADD COLUMN IF NOT EXISTS clip_end DOUBLE PRECISION NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_media_items_clip_of_id ON media_items(clip_of_id);
+
+-- Room chat messages can carry a media item (clips shared into chat)
+ALTER TABLE room_messages ADD COLUMN IF NOT EXISTS media_item_id BIGINT REFERENCES media_items(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_room_messages_media_item_id ON room_messages(media_item_id);
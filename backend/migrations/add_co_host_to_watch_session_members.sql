@@ -0,0 +1,2 @@
+-- Session co-hosts, first in line to take over when the host is away
+ALTER TABLE watch_session_members ADD COLUMN IF NOT EXISTS is_co_host BOOLEAN DEFAULT FALSE;
@@ -0,0 +1,19 @@
+-- Knock-to-join: sessions that require approval hold new viewers in a lobby
+ALTER TABLE watch_sessions ADD COLUMN IF NOT EXISTS requires_approval BOOLEAN DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS session_join_requests (
+    id BIGSERIAL PRIMARY KEY,
+    session_id VARCHAR(36) NOT NULL,
+    room_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, approved, denied, expired, cancelled
+    requested_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP,
+    decided_at TIMESTAMP,
+    decided_by BIGINT -- NULL when nobody decided (expired, cancelled)
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_join_requests_session_id ON session_join_requests(session_id);
+CREATE INDEX IF NOT EXISTS idx_session_join_requests_room_id ON session_join_requests(room_id);
+CREATE INDEX IF NOT EXISTS idx_session_join_requests_user_id ON session_join_requests(user_id);
+CREATE INDEX IF NOT EXISTS idx_session_join_requests_status ON session_join_requests(status);
@@ -0,0 +1,15 @@
+-- Editable library metadata on media items
+ALTER TABLE media_items ADD COLUMN IF NOT EXISTS title VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE media_items ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
+ALTER TABLE media_items ADD COLUMN IF NOT EXISTS year INT;
+ALTER TABLE media_items ADD COLUMN IF NOT EXISTS content_rating VARCHAR(20) NOT NULL DEFAULT '';
+
+-- Tags, one row per (item, tag)
+CREATE TABLE IF NOT EXISTS media_item_tags (
+    id BIGSERIAL PRIMARY KEY,
+    media_item_id BIGINT NOT NULL REFERENCES media_items(id) ON DELETE CASCADE,
+    tag VARCHAR(50) NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_media_item_tag ON media_item_tags(media_item_id, tag);
+CREATE INDEX IF NOT EXISTS idx_media_item_tags_tag ON media_item_tags(tag);
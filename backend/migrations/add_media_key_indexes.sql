@@ -0,0 +1,7 @@
+-- /uploads authorization: find the rooms using a storage key by exact match (file, poster, storyboard, slide image)
+CREATE INDEX IF NOT EXISTS idx_media_items_file_path ON media_items(file_path);
+CREATE INDEX IF NOT EXISTS idx_media_items_poster_url ON media_items(poster_url);
+CREATE INDEX IF NOT EXISTS idx_media_items_storyboard_key ON media_items(storyboard_key);
+CREATE INDEX IF NOT EXISTS idx_temporary_media_items_file_path ON temporary_media_items(file_path);
+CREATE INDEX IF NOT EXISTS idx_temporary_media_items_poster_url ON temporary_media_items(poster_url);
+CREATE INDEX IF NOT EXISTS idx_media_slides_image_key ON media_slides(image_key);
@@ -0,0 +1,21 @@
+-- Per-room retention of session chat, room messages and temporary media
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS chat_retention VARCHAR(20) DEFAULT 'delete'; -- delete, keep, archive
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS chat_retention_days INTEGER DEFAULT 0;
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS room_message_max_age_days INTEGER DEFAULT 0;
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS temp_media_max_hours INTEGER DEFAULT 0;
+
+-- What the retention purge job deleted
+CREATE TABLE IF NOT EXISTS retention_purges (
+    id BIGSERIAL PRIMARY KEY,
+    room_id BIGINT NOT NULL,
+    kind VARCHAR(30) NOT NULL, -- session_chat, room_messages, temporary_media
+    count INTEGER NOT NULL DEFAULT 0,
+    item_ids TEXT, -- JSON array of the deleted rows' IDs
+    session_ids TEXT, -- JSON array, session_chat only
+    cutoff TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_retention_purges_room_id ON retention_purges(room_id);
+CREATE INDEX IF NOT EXISTS idx_retention_purges_created_at ON retention_purges(created_at);
+CREATE INDEX IF NOT EXISTS idx_room_messages_created_at ON room_messages(created_at);
@@ -0,0 +1,3 @@
+-- Session history: a room's sessions newest first, and the members of a page of sessions
+CREATE INDEX IF NOT EXISTS idx_watch_sessions_room_started_at ON watch_sessions(room_id, started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_watch_session_members_session_joined_at ON watch_session_members(watch_session_id, joined_at);
@@ -0,0 +1,8 @@
+-- Explicit watch session states and how a session ended
+ALTER TABLE watch_sessions ADD COLUMN IF NOT EXISTS state VARCHAR(20) NOT NULL DEFAULT 'live';
+ALTER TABLE watch_sessions ADD COLUMN IF NOT EXISTS end_reason VARCHAR(30);
+ALTER TABLE watch_sessions ADD COLUMN IF NOT EXISTS ended_by BIGINT;
+
+UPDATE watch_sessions SET state = 'ended' WHERE ended_at IS NOT NULL AND state <> 'ended';
+
+CREATE INDEX IF NOT EXISTS idx_watch_sessions_state ON watch_sessions(state);
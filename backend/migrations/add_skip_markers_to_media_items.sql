@@ -0,0 +1,9 @@
+-- Intro/credits skip markers on media items (detected or set by the host)
+ALTER TABLE media_items ADD COLUMN IF NOT EXISTS intro_start DECIMAL(12,3) NOT NULL DEFAULT 0;
+ALTER TABLE media_items ADD COLUMN IF NOT EXISTS intro_end DECIMAL(12,3) NOT NULL DEFAULT 0;
+ALTER TABLE media_items ADD COLUMN IF NOT EXISTS credits_start DECIMAL(12,3) NOT NULL DEFAULT 0;
+ALTER TABLE media_items ADD COLUMN IF NOT EXISTS marker_source VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE media_items ADD COLUMN IF NOT EXISTS marker_status VARCHAR(20) NOT NULL DEFAULT '';
+
+-- Rooms can let the server move to the next item when the credits start
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS advance_at_credits BOOLEAN DEFAULT FALSE;
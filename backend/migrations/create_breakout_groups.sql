@@ -0,0 +1,30 @@
+-- Breakout groups: a watch session split into smaller groups, each with its own chat and LiveKit room
+CREATE TABLE IF NOT EXISTS breakout_groups (
+    id BIGSERIAL PRIMARY KEY,
+    session_id VARCHAR(36) NOT NULL,
+    room_id BIGINT NOT NULL,
+    number INTEGER NOT NULL, -- 1..N within one split
+    name VARCHAR(100),
+    livekit_room VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    closed_at TIMESTAMP -- set when the host recalls everyone
+);
+
+CREATE INDEX IF NOT EXISTS idx_breakout_groups_session_id ON breakout_groups(session_id);
+CREATE INDEX IF NOT EXISTS idx_breakout_groups_room_id ON breakout_groups(room_id);
+
+CREATE TABLE IF NOT EXISTS breakout_group_members (
+    id BIGSERIAL PRIMARY KEY,
+    group_id BIGINT NOT NULL REFERENCES breakout_groups(id) ON DELETE CASCADE,
+    session_id VARCHAR(36) NOT NULL,
+    user_id BIGINT NOT NULL,
+    assigned_at TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_breakout_group_user ON breakout_group_members(group_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_breakout_group_members_session_id ON breakout_group_members(session_id);
+CREATE INDEX IF NOT EXISTS idx_breakout_group_members_user_id ON breakout_group_members(user_id);
+
+-- Messages in a breakout group's chat (NULL for the session's main chat)
+ALTER TABLE chat_messages ADD COLUMN IF NOT EXISTS breakout_group_id BIGINT;
+CREATE INDEX IF NOT EXISTS idx_chat_messages_breakout_group_id ON chat_messages(breakout_group_id);
@@ -0,0 +1,18 @@
+-- Chapters and bookmarks on media items (manual, or imported from the file's own chapters)
+CREATE TABLE IF NOT EXISTS media_chapters (
+    id BIGSERIAL PRIMARY KEY,
+    media_item_id BIGINT NOT NULL REFERENCES media_items(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL DEFAULT 'chapter', -- chapter, bookmark
+    title VARCHAR(255) NOT NULL,
+    start_seconds DECIMAL(12,3) NOT NULL DEFAULT 0,
+    end_seconds DECIMAL(12,3) NOT NULL DEFAULT 0, -- 0 = runs until the next chapter
+    source VARCHAR(20) NOT NULL DEFAULT 'manual', -- manual, embedded
+    created_by_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_media_chapters_media_item_id ON media_chapters(media_item_id);
+
+-- Embedded chapters found at first upload, kept on the shared blob
+ALTER TABLE media_blobs ADD COLUMN IF NOT EXISTS chapters TEXT;
@@ -0,0 +1,37 @@
+-- Recaps of ended watch sessions (kept after the session's chat is deleted)
+CREATE TABLE IF NOT EXISTS session_summaries (
+    id BIGSERIAL PRIMARY KEY,
+    session_id VARCHAR(36) NOT NULL,
+    room_id BIGINT NOT NULL, -- no foreign key: instant-watch rooms go away with their session
+    host_id BIGINT NOT NULL,
+    watch_type VARCHAR(50),
+    started_at TIMESTAMP,
+    ended_at TIMESTAMP,
+    end_reason VARCHAR(30),
+    duration_seconds DECIMAL(12,3) NOT NULL DEFAULT 0,
+    peak_viewers INTEGER NOT NULL DEFAULT 0,
+    average_viewers DECIMAL(8,2) NOT NULL DEFAULT 0,
+    message_count INTEGER NOT NULL DEFAULT 0,
+    theater_count INTEGER NOT NULL DEFAULT 0, -- 3D cinema sessions only
+    members TEXT, -- JSON: user_id, username, first_joined_at, seconds_present
+    items_watched TEXT, -- JSON: media_item_id, title, started_at
+    top_reactions TEXT, -- JSON: emoji, count
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_session_summaries_session_id ON session_summaries(session_id);
+CREATE INDEX IF NOT EXISTS idx_session_summaries_room_id ON session_summaries(room_id);
+CREATE INDEX IF NOT EXISTS idx_session_summaries_host_id ON session_summaries(host_id);
+CREATE INDEX IF NOT EXISTS idx_session_summaries_ended_at ON session_summaries(ended_at);
+
+-- Items played in running sessions, folded into the summary when the session ends
+CREATE TABLE IF NOT EXISTS session_media_plays (
+    id BIGSERIAL PRIMARY KEY,
+    session_id VARCHAR(36) NOT NULL,
+    media_item_id BIGINT, -- 0 for temporary uploads
+    file_path VARCHAR(500),
+    title VARCHAR(255),
+    started_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_media_plays_session_id ON session_media_plays(session_id);
@@ -0,0 +1,22 @@
+-- Per-session timeline (playback, chat, reactions, joins/leaves, RoomTV) for replaying a
+-- session against the video later
+CREATE TABLE IF NOT EXISTS session_timeline_events (
+    id BIGSERIAL PRIMARY KEY,
+    session_id VARCHAR(36) NOT NULL,
+    room_id BIGINT NOT NULL,
+    kind VARCHAR(20) NOT NULL, -- playback, chat, reaction, join, leave, tv_content
+    user_id BIGINT, -- 0 for the server
+    occurred_at TIMESTAMP NOT NULL,
+    media_item_id BIGINT, -- 0 for temporary uploads (see file_path)
+    file_path VARCHAR(500),
+    media_position DECIMAL(12,3), -- NULL when nothing was playing
+    playing BOOLEAN DEFAULT FALSE,
+    command VARCHAR(20),
+    chat_message_id BIGINT,
+    reaction_id BIGINT,
+    data TEXT -- JSON, kind-specific
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_timeline_session_at ON session_timeline_events(session_id, occurred_at);
+CREATE INDEX IF NOT EXISTS idx_session_timeline_events_room_id ON session_timeline_events(room_id);
+CREATE INDEX IF NOT EXISTS idx_session_timeline_events_chat_message_id ON session_timeline_events(chat_message_id);
@@ -1,6 +1,7 @@
 import React, { useEffect, useRef, useState } from 'react';
 import * as THREE from 'three';
 import { OrbitControls } from 'three/examples/jsm/controls/OrbitControls';
+import { mediaFileUrl } from '../utils/mediaUrl';
 
 const CinemaScene = ({ userSeats, speakingUsers, authenticatedUserID, selectedMediaItem, ws }) => {
   const mountRef = useRef(null);
@@ -103,7 +104,7 @@ const CinemaScene = ({ userSeats, speakingUsers, authenticatedUserID, selectedMe
     if (!selectedMediaItem) return;
     const video = videoRef.current; // ← USE videoRef
     if (!video) return;
-    video.src = `http://localhost:8080/${selectedMediaItem.file_path}`;
+    video.src = mediaFileUrl(selectedMediaItem);
     video.load();
     // DO NOT call .play() here — let user click the button
   }, [selectedMediaItem]);
@@ -1,5 +1,6 @@
 // WeWatch/frontend/src/components/VideoPlayer.jsx
 import React, { useEffect, useRef } from 'react';
+import { mediaFileUrl } from '../utils/mediaUrl';
 
 const VideoPlayer = ({ 
   mediaItem,
@@ -59,7 +60,7 @@ const VideoPlayer = ({
     console.log("📹 VideoPlayer: mediaItem.file_path:", mediaItem.file_path);
 
     // ✅ Construct and log full URL
-    const videoUrl = `http://localhost:8080/${mediaItem.file_path}`;
+    const videoUrl = mediaFileUrl(mediaItem);
     console.log("📹 VideoPlayer: Constructed video URL:", videoUrl);
 
     // ✅ Set video source
@@ -224,7 +225,7 @@ const VideoPlayer = ({
       >
         {mediaItem && (
           <source 
-            src={`http://localhost:8080/${mediaItem.file_path}`} 
+            src={mediaFileUrl(mediaItem)} 
             type="video/mp4" 
           />
         )}
@@ -27,6 +27,7 @@ import UserProfileModal from "../../../components/UserProfileModal";
 import PrivateChatModal from "../../../components/PrivateChatModal";
 import ChatHomeModal from '../../ChatHomeModal';
 import useEmoteSounds from '../../../hooks/useEmoteSounds';
+import useSignedMediaUrl from '../../../hooks/useSignedMediaUrl';
 import RemoteAudioPlayer from '../ui/RemoteAudioPlayer';
 
 export default function CinemaScene3DDemo() {
@@ -127,6 +128,7 @@ export default function CinemaScene3DDemo() {
   const [currentTime, setCurrentTime] = useState(0);
   // === VIDEO/PLAYBACK STATE ===
   const [currentMedia, setCurrentMedia] = useState(null);
+  useSignedMediaUrl(roomId, currentMedia, setCurrentMedia);
   // === MEDIA PLAYLIST STATE ===
   const [playlist, setPlaylist] = useState([]);
   const [isPlaying, setIsPlaying] = useState(false);
@@ -517,6 +519,9 @@ export default function CinemaScene3DDemo() {
     const video = videoRef.current;
     if (!video) return;
 
+    // Only the signed URL of the same upload changed: keep the position
+    const resumeAt = currentMedia?.urlRefreshed && video.src ? video.currentTime : null;
+
     // Clean up previous stream
     if (video.srcObject) {
       video.srcObject.getTracks().forEach(t => t.stop());
@@ -549,6 +554,9 @@ export default function CinemaScene3DDemo() {
         video.srcObject = null; // Clear stream if any
         video.src = newUrl;
         video.muted = false;
+        if (resumeAt !== null) {
+          video.addEventListener('loadedmetadata', () => { video.currentTime = resumeAt; }, { once: true });
+        }
         video.load();
         video.play().catch(e => console.warn("Play failed (upload):", e));
       }
@@ -861,16 +869,13 @@ export default function CinemaScene3DDemo() {
         case "playback_control":
           if (msg.sender_id && msg.sender_id === currentUser?.id) break;
           if (msg.file_path) {
-            const baseUrl = import.meta.env.VITE_API_BASE_URL || 'http://localhost:8080';
-            const fileUrl = msg.file_url || msg.file_path;
-            const mediaUrl = fileUrl.startsWith('http') ? fileUrl : `${baseUrl}${fileUrl.startsWith('/') ? '' : '/'}${fileUrl}`;
-            setCurrentMedia({
+            // mediaUrl is filled in by useSignedMediaUrl with a URL signed for this viewer
+            setCurrentMedia(prev => (prev?.type === 'upload' && prev.ID === msg.media_item_id ? prev : {
               ID: msg.media_item_id,
               type: 'upload',
               file_path: msg.file_path,
-              mediaUrl: mediaUrl,
               original_name: msg.original_name || 'Unknown Media',
-            });
+            }));
             setIsPlaying(msg.command === "play");
           }
           break;
@@ -1892,6 +1897,7 @@ export default function CinemaScene3DDemo() {
 
                 const mediaItemWithUrl = {
                   ...media,
+                  ID: media.ID || media.id,
                   type: 'upload',
                   mediaUrl, // ✅ critical!
                   original_name: media.original_name || media.file_name || 'Unknown Media',
@@ -1904,7 +1910,6 @@ export default function CinemaScene3DDemo() {
                     command: "play",
                     media_item_id: media.ID || media.id,
                     file_path: media.file_path || media.file_name,
-                    file_url: mediaUrl, // ✅ include in WS message
                     original_name: mediaItemWithUrl.original_name,
                     seek_time: 0,
                     timestamp: Date.now(),
@@ -9,6 +9,7 @@ import apiClient from '../../services/api';
 import { getRoom, getRoomMembers } from '../../services/api';
 // ✅ Import LiveKit hook + events
 import useLiveKitRoom from '../../hooks/useLiveKitRoom';
+import useSignedMediaUrl from '../../hooks/useSignedMediaUrl';
 import { Track, ParticipantEvent, RoomEvent } from 'livekit-client';
 // UI Components
 import SeatsModal from './ui/SeatsModal';
@@ -80,6 +81,7 @@ export default function VideoWatch() {
 
   // 🎥 ALL STATE DECLARATIONS (must be before useEffects that use them)
   const [currentMedia, setCurrentMedia] = useState(null);
+  useSignedMediaUrl(roomId, currentMedia, setCurrentMedia);
   const [playlist, setPlaylist] = useState([]);
   const [isPlaying, setIsPlaying] = useState(false);
   const playbackPositionRef = useRef(0);
@@ -413,7 +415,6 @@ export default function VideoWatch() {
         command: "play",
         media_item_id: id,
         file_path: filePath,
-        file_url: normalizedMediaItem.mediaUrl, // ✅ Add this
         original_name: normalizedMediaItem.original_name,
         seek_time: 0,
         timestamp: Date.now(),
@@ -694,7 +695,6 @@ export default function VideoWatch() {
         command: "play",
         media_item_id: id,
         file_path: filePath,
-        file_url: normalizedMediaItem.mediaUrl, // ✅ add this
         original_name: normalizedMediaItem.original_name,
         seek_time: 0,
         timestamp: Date.now(),
@@ -977,18 +977,15 @@ export default function VideoWatch() {
           if (message.file_path) {
             const isSameMedia = currentMedia && currentMedia.file_path === message.file_path;
             if (!isSameMedia || isPlaying !== (message.command === "play")) {
-              // ✅ Construct full URL for uploaded media
-              const baseUrl = import.meta.env.VITE_API_BASE_URL || 'http://localhost:8080';
-              const fileUrl = message.file_url || message.file_path;
-              const mediaUrl = fileUrl.startsWith('http') ? fileUrl : `${baseUrl}${fileUrl.startsWith('/') ? '' : '/'}${fileUrl}`;
-              
-              setCurrentMedia({
-                ID: message.media_item_id,
-                type: 'upload',
-                file_path: message.file_path,
-                mediaUrl: mediaUrl,
-                original_name: message.original_name || 'Unknown Media',
-              });
+              // ✅ mediaUrl is filled in by useSignedMediaUrl with a URL signed for this viewer
+              if (!isSameMedia) {
+                setCurrentMedia({
+                  ID: message.media_item_id,
+                  type: 'upload',
+                  file_path: message.file_path,
+                  original_name: message.original_name || 'Unknown Media',
+                });
+              }
               const now = Date.now();
               const latency = now - message.timestamp;
               const adjustedTime = message.seek_time + (latency / 1000);
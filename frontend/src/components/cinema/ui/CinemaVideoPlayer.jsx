@@ -15,6 +15,8 @@ const CinemaVideoPlayer = forwardRef(function CinemaVideoPlayer({
   muted = false, // 👈 NEW: default to false (so 2D mode works unchanged)
 }, ref) {
   const videoRef = useRef(null);
+  // Position of the upload that was playing, restored when only its signed URL was refreshed
+  const resumeRef = useRef(null);
 
   // 🔑 Expose the actual <video> DOM element to parent
   useImperativeHandle(ref, () => videoRef.current, []);
@@ -60,9 +62,20 @@ const CinemaVideoPlayer = forwardRef(function CinemaVideoPlayer({
         });
       };
       video.addEventListener('error', handleLoadError, { once: true });
+
+      const resume = resumeRef.current;
+      const handleResume = () => {
+        video.currentTime = resume.time;
+        if (!resume.paused) video.play().catch(onError);
+      };
+      if (mediaItem.urlRefreshed && resume?.id === mediaItem.ID) {
+        video.addEventListener('loadedmetadata', handleResume, { once: true });
+      }
       video.load();
       return () => {
         video.removeEventListener('error', handleLoadError);
+        video.removeEventListener('loadedmetadata', handleResume);
+        resumeRef.current = { id: mediaItem.ID, time: video.currentTime, paused: video.paused };
         video.pause();
         video.src = '';
       };
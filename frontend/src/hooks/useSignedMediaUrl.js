@@ -0,0 +1,71 @@
+// frontend/src/hooks/useSignedMediaUrl.js
+import { useEffect, useRef } from 'react';
+import { getSignedMediaUrl } from '../services/api';
+import { mediaFileUrl } from '../utils/mediaUrl';
+
+// Refresh this long before the signed URL expires, and wait this long after a failed fetch
+const REFRESH_MARGIN_MS = 60 * 1000;
+const RETRY_DELAY_MS = 30 * 1000;
+
+/**
+ * Keeps currentMedia.mediaUrl signed for the current user. Upload URLs are signed per
+ * user and expire (MEDIA_URL_TTL), so every client fetches its own URL for the item id
+ * instead of playing the one the host was given, and refreshes it before it runs out.
+ * Refreshed URLs are flagged with urlRefreshed so players can keep their position.
+ * @param {string|number} roomId - The ID of the room
+ * @param {Object|null} currentMedia - The media item being played
+ * @param {Function} setCurrentMedia - State setter for currentMedia
+ */
+export default function useSignedMediaUrl(roomId, currentMedia, setCurrentMedia) {
+  const itemId = currentMedia?.type === 'upload' ? currentMedia.ID : null;
+  // Watch pages play session uploads unless the item says otherwise
+  const isTemporary = currentMedia?._isTemporary !== false;
+  const mediaRef = useRef(currentMedia);
+  mediaRef.current = currentMedia;
+
+  useEffect(() => {
+    if (!roomId || !itemId) return;
+    let cancelled = false;
+    let timer = null;
+
+    const schedule = (expiresAt) => {
+      if (!expiresAt || expiresAt <= 0) return; // unsigned (public room) URLs don't expire
+      const delay = Math.max(expiresAt * 1000 - Date.now() - REFRESH_MARGIN_MS, 0);
+      timer = setTimeout(() => load(true), delay);
+    };
+
+    const load = async (refreshed) => {
+      try {
+        const data = await getSignedMediaUrl(roomId, itemId, isTemporary);
+        if (cancelled) return;
+        const mediaUrl = mediaFileUrl({ file_url: data.file_url });
+        setCurrentMedia((prev) => (prev && prev.ID === itemId
+          ? { ...prev, mediaUrl, url_expires_at: data.url_expires_at, urlRefreshed: refreshed }
+          : prev));
+        schedule(data.url_expires_at);
+      } catch (err) {
+        if (cancelled) return;
+        const status = err.response?.status;
+        if (status === 403 || status === 404) {
+          console.warn(`⚠️ No signed URL for media ${itemId} (${status}), not retrying`);
+          return;
+        }
+        console.warn(`⚠️ Failed to fetch signed URL for media ${itemId}, retrying:`, err);
+        timer = setTimeout(() => load(refreshed), RETRY_DELAY_MS);
+      }
+    };
+
+    // The host already holds a URL signed for them from the playlist; only schedule its refresh
+    const initial = mediaRef.current;
+    if (initial?.mediaUrl) {
+      schedule(initial.url_expires_at);
+    } else {
+      load(false);
+    }
+
+    return () => {
+      cancelled = true;
+      clearTimeout(timer);
+    };
+  }, [roomId, itemId, isTemporary, setCurrentMedia]);
+}
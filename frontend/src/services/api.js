@@ -438,6 +438,24 @@ export const deleteTemporaryMediaItemsForRoom = async (roomId) => {
     }
 };
 
+/**
+ * Fetches a freshly signed URL for a media item, valid for the current user
+ * @param {string|number} roomId - The ID of the room
+ * @param {string|number} itemId - The ID of the media item
+ * @param {boolean} isTemporary - Whether the item is a temporary (session) upload
+ * @returns {Promise<Object>} Promise resolving to { file_url, poster_url, url_expires_at }
+ */
+export const getSignedMediaUrl = async (roomId, itemId, isTemporary = true) => {
+    try {
+        const kind = isTemporary ? 'temporary-media' : 'media';
+        const response = await apiClient.get(`/api/rooms/${roomId}/${kind}/${itemId}/signed-url`);
+        return response.data;
+    } catch (error) {
+        console.error('API Error (getSignedMediaUrl):', error);
+        throw error;
+    }
+};
+
 
 
 /**
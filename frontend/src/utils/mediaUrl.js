@@ -0,0 +1,20 @@
+// WeWatch/frontend/src/utils/mediaUrl.js
+const API_BASE_URL = import.meta.env.VITE_API_BASE_URL || 'http://localhost:8080';
+
+/**
+ * Returns the playable URL of an uploaded media item.
+ * Prefers the signed file_url from the API; file_path is a bare storage key
+ * (older rows hold "./uploads/..."), which the backend serves at /uploads/<key>.
+ * @param {Object} item - Media item (or temporary media item) from the API.
+ * @returns {string} Absolute URL for a <video>/<audio> src, or "" if the item has no file.
+ */
+export const mediaFileUrl = (item) => {
+  if (!item) return '';
+  const url = item.file_url || (item.file_path
+    ? `/uploads/${item.file_path.replace(/^\.?\/?uploads\//, '')}`
+    : '');
+  if (!url || url.startsWith('http')) return url;
+  return `${API_BASE_URL}${url.startsWith('/') ? '' : '/'}${url}`;
+};
+
+export default mediaFileUrl;
\ No newline at end of file